@@ -31,7 +31,8 @@ type CustomDomainSpec struct {
 	// This field is used to filter the set of namespaces serviced by the
 	// CustomDomain ingress. This is useful for implementing shards.
 	//
-	// If unset, the default is no filtering.
+	// If unset, the default is no filtering. RouteMigrations only move routes onto the CustomDomain from the namespaces
+	// it selects, so none can if it is unset.
 	//
 	// +optional
 	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
@@ -56,8 +57,216 @@ type CustomDomainSpec struct {
 	// +kubebuilder:default:="Classic"
 	// +optional
 	LoadBalancerType operatorv1.AWSLoadBalancerType `json:"loadBalancerType,omitempty"`
+
+	// This field determines how the CustomDomain ingress is published. Defaults to LoadBalancerService if empty.
+	//
+	// Valid values are:
+	//
+	// * "LoadBalancerService": Publishes the ingress using a cloud load balancer. A wildcard DNS record is managed by the ingress operator.
+	//
+	// * "HostNetwork": Publishes the ingress on host ports of the nodes running the router pods. No DNS record is managed.
+	//
+	// * "NodePortService": Publishes the ingress using a NodePort Service. No DNS record is managed.
+	//
+	// * "Private": Does not publish the ingress. No DNS record is managed.
+	//
+	// The scope and loadBalancerType fields are only used with the LoadBalancerService strategy.
+	//
+	// +kubebuilder:validation:Enum=LoadBalancerService;HostNetwork;NodePortService;Private
+	// +kubebuilder:default:="LoadBalancerService"
+	// +optional
+	EndpointPublishingStrategy operatorv1.EndpointPublishingStrategyType `json:"endpointPublishingStrategy,omitempty"`
+
+	// This field holds the host ports and protocol used with the HostNetwork endpoint publishing strategy.
+	// As the router pods are placed on the infra nodes, the ports should not clash with the default ingress.
+	//
+	// +optional
+	HostNetwork *operatorv1.HostNetworkStrategy `json:"hostNetwork,omitempty"`
+
+	// This field holds the protocol used with the NodePortService endpoint publishing strategy.
+	//
+	// +optional
+	NodePort *operatorv1.NodePortStrategy `json:"nodePort,omitempty"`
+
+	// This field enables PROXY protocol on the CustomDomain ingress so the router can report the client's source address.
+	//
+	// With the LoadBalancerService strategy, PROXY protocol is only supported with AWS Classic load balancers.
+	// An AWS Network Load Balancer preserves the client's source address without it.
+	// The ingress operator always enables PROXY protocol on AWS Classic load balancers, so the field has no effect there:
+	// setting it is reported with the ProxyProtocolIgnored condition, and leaving it unset doesn't disable PROXY protocol.
+	// With the HostNetwork and NodePortService strategies, the load balancer in front of the nodes must send PROXY protocol.
+	//
+	// +optional
+	ProxyProtocol bool `json:"proxyProtocol,omitempty"`
+
+	// This field determines how a change of the scope field is handled. Defaults to Reject if empty.
+	//
+	// Valid values are:
+	//
+	// * "Reject": The scope is immutable. Changing it sets the InvalidScope condition.
+	//
+	// * "Recreate": A new ingress controller with the new scope is created next to the existing one. Once its DNS record and load balancer are ready, the endpoint is switched over to it and the previous ingress controller is deleted. The endpoint changes, so the custom domain's CNAME record has to be updated.
+	//
+	// +kubebuilder:validation:Enum=Reject;Recreate
+	// +kubebuilder:default:="Reject"
+	// +optional
+	ScopeChangePolicy ScopeChangePolicyType `json:"scopeChangePolicy,omitempty"`
+
+	// This field allows the CustomDomain to adopt an existing ingress controller of the same name that was not created by the operator.
+	// The ingress controller is only adopted if its domain, scope, endpoint publishing strategy and certificate match the CustomDomain.
+	// The fields the operator keeps up to date, such as the load balancer parameters, must already have the values the operator sets:
+	// adopting an ingress controller never changes its spec, differences are reported with the IngressControllerConflict condition.
+	// Without it, an existing ingress controller that is not managed by the operator is left untouched.
+	//
+	// +optional
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+
+	// This field determines what happens to the ingress controller and its certificate when the CustomDomain is deleted. Defaults to Delete if empty.
+	//
+	// Valid values are:
+	//
+	// * "Delete": The ingress controller and the certificate secret in the openshift-ingress namespace are deleted.
+	//
+	// * "Retain": The ingress controller and the certificate secret are kept and the operator's management labels are removed from them, so the router keeps serving the domain and can be adopted by another CustomDomain. They are annotated with customdomains.managed.openshift.io/deletion-policy=Retain.
+	//
+	// * "Orphan": The ingress controller and the certificate secret are left in place with the operator's management labels, and are annotated with customdomains.managed.openshift.io/deletion-policy=Orphan so the operator's orphaned resource sweeper never deletes them.
+	//
+	// +kubebuilder:validation:Enum=Delete;Retain;Orphan
+	// +kubebuilder:default:="Delete"
+	// +optional
+	DeletionPolicy DeletionPolicyType `json:"deletionPolicy,omitempty"`
+
+	// This field sets the host label of the endpoint, which is the first label of status.endpoint.
+	// If empty, the label is derived from the CustomDomain's name, so a recreated CustomDomain keeps the same endpoint.
+	// Changing it changes the endpoint, so the custom domain's CNAME record has to be updated.
+	//
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	EndpointHost string `json:"endpointHost,omitempty"`
+
+	// This field configures a DNS provider hosting the custom domain's zone, in which the operator publishes the wildcard CNAME record
+	// for the custom domain pointing to the endpoint. The record is deleted along with the CustomDomain if the deletionPolicy is Delete.
+	// Without it, the CNAME record has to be created by hand.
+	//
+	// +optional
+	DNSProvider *CustomDomainDNSProvider `json:"dnsProvider,omitempty"`
+}
+
+// CustomDomainDNSProvider configures the DNS provider publishing the custom domain's CNAME record
+type CustomDomainDNSProvider struct {
+	// Type is the type of the DNS provider
+	//
+	// +kubebuilder:validation:Enum=Route53;RFC2136;File
+	Type DNSProviderType `json:"type"`
+
+	// CredentialsSecret references the secret with the credentials of the DNS provider.
+	// Route53 uses the aws_access_key_id, aws_secret_access_key and optional aws_session_token keys.
+	// RFC2136 uses the tsig-secret key, holding the base64 encoded TSIG secret.
+	//
+	// +optional
+	CredentialsSecret *corev1.SecretReference `json:"credentialsSecret,omitempty"`
+
+	// TTL is the time to live of the CNAME record in seconds
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default:=300
+	// +optional
+	TTL int64 `json:"ttl,omitempty"`
+
+	// Route53 configures the Route53 DNS provider
+	// +optional
+	Route53 *Route53DNSProvider `json:"route53,omitempty"`
+
+	// RFC2136 configures the RFC2136 (dynamic DNS update) DNS provider
+	// +optional
+	RFC2136 *RFC2136DNSProvider `json:"rfc2136,omitempty"`
+
+	// File configures the File DNS provider, which writes the records to a file. It is meant for development and testing.
+	// +optional
+	File *FileDNSProvider `json:"file,omitempty"`
+}
+
+// DNSProviderType is a valid value for CustomDomainDNSProvider.Type
+type DNSProviderType string
+
+const (
+	// DNSProviderRoute53 publishes records in an AWS Route53 hosted zone
+	DNSProviderRoute53 DNSProviderType = "Route53"
+
+	// DNSProviderRFC2136 publishes records with RFC2136 dynamic DNS updates
+	DNSProviderRFC2136 DNSProviderType = "RFC2136"
+
+	// DNSProviderFile writes records to a file
+	DNSProviderFile DNSProviderType = "File"
+)
+
+// Route53DNSProvider configures the Route53 DNS provider
+type Route53DNSProvider struct {
+	// HostedZoneID is the ID of the hosted zone of the custom domain
+	//
+	// +kubebuilder:validation:MinLength=1
+	HostedZoneID string `json:"hostedZoneID"`
+}
+
+// RFC2136DNSProvider configures the RFC2136 DNS provider
+type RFC2136DNSProvider struct {
+	// Nameserver is the host:port of the primary name server of the zone
+	//
+	// +kubebuilder:validation:MinLength=1
+	Nameserver string `json:"nameserver"`
+
+	// Zone is the zone containing the custom domain
+	//
+	// +kubebuilder:validation:MinLength=1
+	Zone string `json:"zone"`
+
+	// TSIGKeyName is the name of the TSIG key authenticating the updates. Updates are not signed if empty.
+	// +optional
+	TSIGKeyName string `json:"tsigKeyName,omitempty"`
+
+	// TSIGAlgorithm is the algorithm of the TSIG key
+	//
+	// +kubebuilder:validation:Enum=hmac-sha1;hmac-sha256;hmac-sha512
+	// +kubebuilder:default:="hmac-sha256"
+	// +optional
+	TSIGAlgorithm string `json:"tsigAlgorithm,omitempty"`
 }
 
+// FileDNSProvider configures the File DNS provider
+type FileDNSProvider struct {
+	// Path is the path of the file the records are written to, relative to the directory set by the
+	// --file-dns-provider-dir flag of the operator. The File DNS provider is disabled if the flag is not set.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+}
+
+// DeletionPolicyType is a valid value for CustomDomainSpec.DeletionPolicy
+type DeletionPolicyType string
+
+const (
+	// DeletionPolicyDelete deletes the resources of a CustomDomain when it is deleted
+	DeletionPolicyDelete DeletionPolicyType = "Delete"
+
+	// DeletionPolicyRetain keeps the resources of a CustomDomain when it is deleted and releases them from the operator
+	DeletionPolicyRetain DeletionPolicyType = "Retain"
+
+	// DeletionPolicyOrphan keeps the resources of a CustomDomain untouched when it is deleted
+	DeletionPolicyOrphan DeletionPolicyType = "Orphan"
+)
+
+// ScopeChangePolicyType is a valid value for CustomDomainSpec.ScopeChangePolicy
+type ScopeChangePolicyType string
+
+const (
+	// ScopeChangePolicyReject rejects changes to the scope of a CustomDomain
+	ScopeChangePolicyReject ScopeChangePolicyType = "Reject"
+
+	// ScopeChangePolicyRecreate migrates a CustomDomain to a new ingress controller when its scope changes
+	ScopeChangePolicyRecreate ScopeChangePolicyType = "Recreate"
+)
+
 // CustomDomainStatus defines the observed state of CustomDomain
 type CustomDomainStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
@@ -79,8 +288,183 @@ type CustomDomainStatus struct {
 	// The scope dictates whether the ingress controller is internal or external
 	// +optional
 	Scope string `json:"scope"`
+
+	// The name of the ingress controller serving the custom domain. Defaults to the CustomDomain's name if empty.
+	// +optional
+	IngressController string `json:"ingressController,omitempty"`
+
+	// The progress of a scope change when the scopeChangePolicy is Recreate
+	// +optional
+	ScopeMigration *CustomDomainScopeMigration `json:"scopeMigration,omitempty"`
+
+	// The challenge proving ownership of the custom domain, when the operator is configured to verify domain ownership
+	// +optional
+	OwnershipChallenge *CustomDomainOwnershipChallenge `json:"ownershipChallenge,omitempty"`
+
+	// The CNAME record published by the DNS provider
+	// +optional
+	PublishedDNSRecord *CustomDomainPublishedDNSRecord `json:"publishedDNSRecord,omitempty"`
+
+	// The analysis of the routes whose host is under the custom domain
+	// +optional
+	RouteAnalysis *CustomDomainRouteAnalysis `json:"routeAnalysis,omitempty"`
+
+	// The changes the operator would make for the CustomDomain, computed instead of reconciling when the CustomDomain
+	// is annotated with customdomains.managed.openshift.io/plan
+	// +optional
+	Plan *CustomDomainPlan `json:"plan,omitempty"`
+}
+
+// CustomDomainPlan lists the changes the operator would make to reconcile the CustomDomain, without making them
+type CustomDomainPlan struct {
+	// ObservedGeneration is the generation of the CustomDomain the plan was computed for
+	ObservedGeneration int64 `json:"observedGeneration"`
+	// Blockers lists the problems that would stop the reconcile before the changes are made
+	// +optional
+	Blockers []string `json:"blockers,omitempty"`
+	// Changes lists the objects that would be created or updated
+	// +optional
+	Changes []CustomDomainPlannedChange `json:"changes,omitempty"`
+}
+
+// CustomDomainPlannedActionType is a valid value for CustomDomainPlannedChange.Action
+type CustomDomainPlannedActionType string
+
+const (
+	// CustomDomainPlannedCreate is planned for objects that don't exist yet
+	CustomDomainPlannedCreate CustomDomainPlannedActionType = "Create"
+	// CustomDomainPlannedUpdate is planned for objects that differ from the desired ones
+	CustomDomainPlannedUpdate CustomDomainPlannedActionType = "Update"
+	// CustomDomainPlannedAdopt is planned for ingresscontrollers that exist but are not managed by the operator
+	CustomDomainPlannedAdopt CustomDomainPlannedActionType = "Adopt"
+	// CustomDomainPlannedPublish is planned for DNS records published with the DNS provider
+	CustomDomainPlannedPublish CustomDomainPlannedActionType = "Publish"
+)
+
+// CustomDomainPlannedChange describes a change to an object
+type CustomDomainPlannedChange struct {
+	// Action is the change made to the object
+	Action CustomDomainPlannedActionType `json:"action"`
+	// Kind is the kind of the object
+	Kind string `json:"kind"`
+	// Namespace is the namespace of the object, empty for cluster scoped objects
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the object
+	Name string `json:"name"`
+	// Diff lists the changed fields as "path: live -> desired". The contents of secrets are never included.
+	// +optional
+	Diff []string `json:"diff,omitempty"`
+	// Note explains the change, or why part of it can't be made
+	// +optional
+	Note string `json:"note,omitempty"`
+}
+
+// CustomDomainRouteAnalysis counts the routes whose host is under the custom domain, by the ingresscontrollers that
+// admitted them
+type CustomDomainRouteAnalysis struct {
+	// Routes is the number of routes whose host is under the custom domain
+	Routes int32 `json:"routes"`
+	// Admitted is the number of routes admitted by the ingresscontroller of the CustomDomain only
+	Admitted int32 `json:"admitted"`
+	// Unadmitted is the number of routes that no ingresscontroller admitted
+	Unadmitted int32 `json:"unadmitted"`
+	// DoubleAdmitted is the number of routes admitted by the ingresscontroller of the CustomDomain and by another one,
+	// such as the default ingresscontroller
+	DoubleAdmitted int32 `json:"doubleAdmitted"`
+	// WrongShard is the number of routes only admitted by other ingresscontrollers
+	WrongShard int32 `json:"wrongShard"`
+	// Problems lists the first misconfigured routes
+	// +optional
+	Problems []CustomDomainRouteProblem `json:"problems,omitempty"`
+}
+
+// CustomDomainRouteProblemType is a valid value for CustomDomainRouteProblem.Problem
+type CustomDomainRouteProblemType string
+
+const (
+	// CustomDomainRouteUnadmitted is reported for routes that no ingresscontroller admitted
+	CustomDomainRouteUnadmitted CustomDomainRouteProblemType = "Unadmitted"
+	// CustomDomainRouteDoubleAdmitted is reported for routes also admitted by another ingresscontroller
+	CustomDomainRouteDoubleAdmitted CustomDomainRouteProblemType = "DoubleAdmitted"
+	// CustomDomainRouteWrongShard is reported for routes only admitted by other ingresscontrollers
+	CustomDomainRouteWrongShard CustomDomainRouteProblemType = "WrongShard"
+)
+
+// CustomDomainRouteProblem describes a misconfigured route
+type CustomDomainRouteProblem struct {
+	// Route is the namespace/name of the route
+	Route string `json:"route"`
+	// Host is the host of the route
+	Host string `json:"host"`
+	// Problem is the misconfiguration of the route
+	Problem CustomDomainRouteProblemType `json:"problem"`
+	// IngressControllers are the ingresscontrollers that admitted the route
+	// +optional
+	IngressControllers []string `json:"ingressControllers,omitempty"`
+}
+
+// CustomDomainPublishedDNSRecord contains the CNAME record published by the DNS provider
+type CustomDomainPublishedDNSRecord struct {
+	// Provider is the type of the DNS provider the record was published with
+	Provider DNSProviderType `json:"provider"`
+	// Name is the name of the record
+	Name string `json:"name"`
+	// Target is the endpoint the record points to
+	Target string `json:"target"`
+	// TTL is the time to live of the record in seconds
+	TTL int64 `json:"ttl"`
+	// PublishedTime is the time the record was last published
+	// +optional
+	PublishedTime *metav1.Time `json:"publishedTime,omitempty"`
+}
+
+// CustomDomainOwnershipChallenge contains the TXT record that proves ownership of the custom domain
+type CustomDomainOwnershipChallenge struct {
+	// RecordName is the name of the TXT record to create in the custom domain's zone
+	RecordName string `json:"recordName"`
+	// Token is the value of the TXT record
+	Token string `json:"token"`
+	// VerifiedTime is the time the TXT record was found
+	// +optional
+	VerifiedTime *metav1.Time `json:"verifiedTime,omitempty"`
+}
+
+// CustomDomainScopeMigration contains details of an ongoing change of the ingress scope
+type CustomDomainScopeMigration struct {
+	// Phase is the current phase of the migration
+	Phase CustomDomainScopeMigrationPhase `json:"phase"`
+	// Scope is the scope being migrated to
+	Scope string `json:"scope"`
+	// PreviousIngressController is the name of the ingress controller being replaced
+	PreviousIngressController string `json:"previousIngressController"`
+	// IngressController is the name of the ingress controller with the new scope
+	IngressController string `json:"ingressController"`
+	// StartTime is the time the migration started
+	// +optional
+	StartTime metav1.Time `json:"startTime,omitempty"`
 }
 
+// CustomDomainScopeMigrationPhase is a valid value for CustomDomainScopeMigration.Phase
+type CustomDomainScopeMigrationPhase string
+
+const (
+	// ScopeMigrationPhaseCreatingIngressController is set while the ingress controller with the new scope is created
+	ScopeMigrationPhaseCreatingIngressController CustomDomainScopeMigrationPhase = "CreatingIngressController"
+
+	// ScopeMigrationPhaseWaitingForDNSRecord is set while waiting for the DNS record of the new ingress controller
+	ScopeMigrationPhaseWaitingForDNSRecord CustomDomainScopeMigrationPhase = "WaitingForDNSRecord"
+
+	// ScopeMigrationPhaseWaitingForLoadBalancer is set while waiting for the load balancer of the new ingress controller
+	ScopeMigrationPhaseWaitingForLoadBalancer CustomDomainScopeMigrationPhase = "WaitingForLoadBalancer"
+
+	// ScopeMigrationPhaseSwitchingEndpoint is set while the endpoint is switched to the new ingress controller
+	ScopeMigrationPhaseSwitchingEndpoint CustomDomainScopeMigrationPhase = "SwitchingEndpoint"
+
+	// ScopeMigrationPhaseDeletingPreviousIngressController is set while the previous ingress controller is deleted
+	ScopeMigrationPhaseDeletingPreviousIngressController CustomDomainScopeMigrationPhase = "DeletingPreviousIngressController"
+)
+
 // CustomDomainStateType is a valid value for CustomDomainStatus.State
 type CustomDomainStateType string
 
@@ -147,6 +531,55 @@ const (
 	// CustomDomainConditionInvalidScope is set when the loadbalancer scope is modified
 	CustomDomainConditionInvalidScope CustomDomainConditionType = "InvalidScope"
 
+	// CustomDomainConditionScopeMigrating is set while the CustomDomain is migrated to an ingress controller with a new scope
+	CustomDomainConditionScopeMigrating CustomDomainConditionType = "ScopeMigrating"
+
+	// CustomDomainConditionIngressControllerConflict is set when an ingress controller not managed by the operator already exists
+	CustomDomainConditionIngressControllerConflict CustomDomainConditionType = "IngressControllerConflict"
+
+	// CustomDomainConditionAdopted is set when an existing ingress controller has been adopted
+	CustomDomainConditionAdopted CustomDomainConditionType = "Adopted"
+
+	// CustomDomainConditionInvalidEndpointPublishingStrategy is set when the endpoint publishing strategy is modified
+	CustomDomainConditionInvalidEndpointPublishingStrategy CustomDomainConditionType = "InvalidEndpointPublishingStrategy"
+
+	// CustomDomainConditionInvalidProxyProtocol is set when PROXY protocol is not supported by the platform or endpoint publishing strategy
+	CustomDomainConditionInvalidProxyProtocol CustomDomainConditionType = "InvalidProxyProtocol"
+
+	// CustomDomainConditionProxyProtocolIgnored is set when PROXY protocol is requested on a load balancer on which the
+	// ingress operator always enables it, so the proxyProtocol field has no effect
+	CustomDomainConditionProxyProtocolIgnored CustomDomainConditionType = "ProxyProtocolIgnored"
+
+	// CustomDomainConditionInvalidEndpointHost is set when the endpoint host is not a valid DNS label or collides with an existing route
+	CustomDomainConditionInvalidEndpointHost CustomDomainConditionType = "InvalidEndpointHost"
+
+	// CustomDomainConditionDomainDelegated reports whether hosts under the custom domain resolve to the endpoint
+	CustomDomainConditionDomainDelegated CustomDomainConditionType = "DomainDelegated"
+
+	// CustomDomainConditionDomainOwnershipVerified reports whether the ownership challenge TXT record was found
+	CustomDomainConditionDomainOwnershipVerified CustomDomainConditionType = "DomainOwnershipVerified"
+
+	// CustomDomainConditionDNSRecordPublished reports whether the DNS provider published the CNAME record
+	CustomDomainConditionDNSRecordPublished CustomDomainConditionType = "DNSRecordPublished"
+
+	// CustomDomainConditionQuotaExceeded is set when the CustomDomain is over the CustomDomain quota of the cluster
+	CustomDomainConditionQuotaExceeded CustomDomainConditionType = "QuotaExceeded"
+
+	// CustomDomainConditionDomainNotAllowed is set when the custom domain is not allowed by the domain policy
+	CustomDomainConditionDomainNotAllowed CustomDomainConditionType = "DomainNotAllowed"
+
+	// CustomDomainConditionDomainConflict is set when the custom domain is the same as, or overlaps with, the custom
+	// domain of an older CustomDomain
+	CustomDomainConditionDomainConflict CustomDomainConditionType = "DomainConflict"
+
+	// CustomDomainConditionRoutesMisconfigured reports whether routes under the custom domain are unadmitted, admitted
+	// by another ingresscontroller too, or only admitted by other ingresscontrollers
+	CustomDomainConditionRoutesMisconfigured CustomDomainConditionType = "RoutesMisconfigured"
+
+	// CustomDomainConditionPaused reports whether the reconciliation of the CustomDomain is paused, by the paused
+	// annotation or by the operator configuration
+	CustomDomainConditionPaused CustomDomainConditionType = "Paused"
+
 	// CustomDomainConditionFailed is set when custom domain creation has failed
 	CustomDomainConditionFailed CustomDomainConditionType = "Failed"
 
@@ -0,0 +1,97 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CustomDomainClaimSpec defines the custom domain requested by a project
+type CustomDomainClaimSpec struct {
+	// This field is the custom domain. It must end with one of the domain suffixes allowed by the claim policy.
+	Domain string `json:"domain"`
+
+	// This field points to the custom TLS secret in the namespace of the claim
+	Certificate corev1.LocalObjectReference `json:"certificate"`
+
+	// This field determines whether the custom domain ingress is internal or external. Defaults to External if empty.
+	//
+	// +kubebuilder:validation:Enum=External;Internal
+	// +kubebuilder:default:="External"
+	// +optional
+	Scope string `json:"scope,omitempty"`
+}
+
+// CustomDomainClaimStatus reports the CustomDomain created for the claim, and mirrors its status
+type CustomDomainClaimStatus struct {
+	// Phase is Pending until the claim is accepted, Bound once its CustomDomain is created, or Rejected
+	// +optional
+	Phase CustomDomainClaimPhase `json:"phase,omitempty"`
+
+	// Message explains the phase
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// CustomDomain is the name of the CustomDomain created for the claim
+	// +optional
+	CustomDomain string `json:"customDomain,omitempty"`
+
+	// State is the state of the CustomDomain
+	// +optional
+	State CustomDomainStateType `json:"state,omitempty"`
+
+	// Endpoint is the endpoint of the CustomDomain, which the custom domain must be pointed to with a CNAME record
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// DNSRecord is the DNS record of the CustomDomain
+	// +optional
+	DNSRecord string `json:"dnsRecord,omitempty"`
+
+	// Conditions are the conditions of the CustomDomain
+	// +optional
+	Conditions []CustomDomainCondition `json:"conditions,omitempty"`
+}
+
+// CustomDomainClaimPhase is a valid value for CustomDomainClaimStatus.Phase
+type CustomDomainClaimPhase string
+
+const (
+	// CustomDomainClaimPhasePending is set until the claim is checked against the claim policy
+	CustomDomainClaimPhasePending CustomDomainClaimPhase = "Pending"
+
+	// CustomDomainClaimPhaseBound is set when the CustomDomain of the claim is created
+	CustomDomainClaimPhaseBound CustomDomainClaimPhase = "Bound"
+
+	// CustomDomainClaimPhaseRejected is set when the claim is not allowed by the claim policy
+	CustomDomainClaimPhaseRejected CustomDomainClaimPhase = "Rejected"
+)
+
+// +kubebuilder:object:root=true
+
+// CustomDomainClaim lets project owners request a CustomDomain serving the routes of their namespace
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Domain",type=string,JSONPath=`.spec.domain`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.endpoint`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.state`
+// +kubebuilder:resource:path=customdomainclaims,scope=Namespaced
+type CustomDomainClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CustomDomainClaimSpec   `json:"spec,omitempty"`
+	Status CustomDomainClaimStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CustomDomainClaimList contains a list of CustomDomainClaim
+type CustomDomainClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CustomDomainClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CustomDomainClaim{}, &CustomDomainClaimList{})
+}
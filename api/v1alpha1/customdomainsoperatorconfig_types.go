@@ -0,0 +1,205 @@
+package v1alpha1
+
+import (
+	operatorv1 "github.com/openshift/api/operator/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CustomDomainsOperatorConfigName is the name of the CustomDomainsOperatorConfig read by the operator
+const CustomDomainsOperatorConfigName = "cluster"
+
+// CustomDomainsOperatorConfigSpec defines the configuration of the custom domains operator
+type CustomDomainsOperatorConfigSpec struct {
+	// This field lists the ingress controller names that CustomDomains can not use, as they belong to the cluster.
+	// Defaults to default, apps2 and apps. The default ingresscontroller is always restricted, even when it is
+	// not listed.
+	//
+	// +kubebuilder:default:={"default","apps2","apps"}
+	// +optional
+	RestrictedIngressNames []string `json:"restrictedIngressNames,omitempty"`
+
+	// This field is how long to wait before checking an ingress controller or DNS record that is not ready yet again.
+	// Defaults to 1m.
+	//
+	// +kubebuilder:default:="1m"
+	// +optional
+	RequeueWait *metav1.Duration `json:"requeueWait,omitempty"`
+
+	// This field is the idle timeout of the classic load balancers created on AWS. Defaults to 30m.
+	//
+	// +kubebuilder:default:="30m"
+	// +optional
+	ELBIdleTimeout *metav1.Duration `json:"elbIdleTimeout,omitempty"`
+
+	// This field is the length of the endpoint hosts derived from the CustomDomain names. Changing it changes the
+	// endpoint of CustomDomains created afterwards only. Defaults to 6.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=32
+	// +kubebuilder:default:=6
+	// +optional
+	EndpointHostLength int32 `json:"endpointHostLength,omitempty"`
+
+	// This field is the node placement of the ingress controllers created for CustomDomains. It only applies to the
+	// ingress controllers created after it changes: moving the ingress controllers of existing CustomDomains would roll
+	// out their router pods, so their node placement is left as is. Defaults to the infra nodes.
+	//
+	// +optional
+	NodePlacement *operatorv1.NodePlacement `json:"nodePlacement,omitempty"`
+
+	// This field is how often all CustomDomains are reconciled. It is only read when the operator starts.
+	// Defaults to 15m.
+	//
+	// +kubebuilder:default:="15m"
+	// +optional
+	SyncPeriod *metav1.Duration `json:"syncPeriod,omitempty"`
+
+	// This field enables checking that custom domains resolve to their endpoint. Defaults to the
+	// --verify-domain-delegation flag of the operator, which is off.
+	//
+	// +optional
+	VerifyDomainDelegation *bool `json:"verifyDomainDelegation,omitempty"`
+
+	// This field is how long to wait before checking the delegation or ownership of a custom domain that is not
+	// verified yet again. Defaults to 5m.
+	//
+	// +kubebuilder:default:="5m"
+	// +optional
+	DomainVerificationInterval *metav1.Duration `json:"domainVerificationInterval,omitempty"`
+
+	// This field determines whether custom domains must prove their ownership with a TXT record. Defaults to the
+	// --domain-ownership-verification flag of the operator.
+	//
+	// +kubebuilder:validation:Enum=disabled;warn;block
+	// +optional
+	DomainOwnershipVerification string `json:"domainOwnershipVerification,omitempty"`
+
+	// This field limits the number of CustomDomains, as each one runs its own ingress controller, router pods and
+	// load balancer. Unlimited if empty.
+	//
+	// +optional
+	Quota *CustomDomainQuota `json:"quota,omitempty"`
+
+	// This field allows project owners to request custom domains with CustomDomainClaims. CustomDomainClaims are
+	// rejected if empty.
+	//
+	// +optional
+	ClaimPolicy *CustomDomainClaimPolicy `json:"claimPolicy,omitempty"`
+
+	// This field restricts the domains CustomDomains can register. CustomDomains out of policy are denied by admission,
+	// and existing ones are reported with the DomainNotAllowed condition. Public suffixes and the cluster base domain
+	// are forbidden if empty.
+	//
+	// +optional
+	DomainPolicy *CustomDomainDomainPolicy `json:"domainPolicy,omitempty"`
+
+	// This field pauses the reconciliation of every CustomDomain, which are left untouched and report the Paused
+	// condition, and stops the deletion of orphaned resources. CustomDomains being deleted are still finalized.
+	// Defaults to false.
+	//
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// CustomDomainDomainPolicy determines which domains CustomDomains can register
+type CustomDomainDomainPolicy struct {
+	// This field lists the domains that custom domains must be equal to or a subdomain of. Any domain if empty.
+	// +optional
+	AllowedDomainSuffixes []string `json:"allowedDomainSuffixes,omitempty"`
+
+	// This field lists the domains that custom domains must not be equal to or a subdomain of. A "*" label matches any
+	// label, e.g. "apps.*.openshiftapps.com" forbids the apps domains of other clusters.
+	// +optional
+	ForbiddenDomains []string `json:"forbiddenDomains,omitempty"`
+
+	// This field forbids custom domains that are public suffixes, such as "com" or "co.uk". Defaults to true.
+	// +optional
+	ForbidPublicSuffixes *bool `json:"forbidPublicSuffixes,omitempty"`
+
+	// This field forbids custom domains that are equal to or a subdomain of the base domain of the cluster. Defaults
+	// to true.
+	// +optional
+	ForbidClusterBaseDomain *bool `json:"forbidClusterBaseDomain,omitempty"`
+}
+
+// CustomDomainClaimPolicy determines which CustomDomainClaims are turned into CustomDomains
+type CustomDomainClaimPolicy struct {
+	// This field lists the domains that claimed domains must be equal to or a subdomain of
+	// +kubebuilder:validation:MinItems=1
+	AllowedDomainSuffixes []string `json:"allowedDomainSuffixes"`
+
+	// This field is the maximum number of CustomDomainClaims per namespace. The most recent claims over it are
+	// rejected. Unlimited if empty.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxClaimsPerNamespace *int32 `json:"maxClaimsPerNamespace,omitempty"`
+
+	// This field restricts the namespaces that can claim custom domains. All namespaces if empty.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// CustomDomainQuota limits the number of CustomDomains. CustomDomains over a limit are denied by admission, and the
+// most recent ones are reported with the QuotaExceeded condition if the limit is lowered. Limits that are not set
+// are unlimited.
+type CustomDomainQuota struct {
+	// This field is the maximum number of CustomDomains
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxCustomDomains *int32 `json:"maxCustomDomains,omitempty"`
+
+	// This field is the maximum number of CustomDomains with the External scope
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxExternal *int32 `json:"maxExternal,omitempty"`
+
+	// This field is the maximum number of CustomDomains with the Internal scope
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxInternal *int32 `json:"maxInternal,omitempty"`
+}
+
+// CustomDomainsOperatorConfigStatus reports the configuration in effect
+type CustomDomainsOperatorConfigStatus struct {
+	// ObservedGeneration is the generation of the spec last read by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Effective is the configuration in effect, with the defaults applied. If the spec is invalid, the previous
+	// configuration stays in effect.
+	// +optional
+	Effective *CustomDomainsOperatorConfigSpec `json:"effective,omitempty"`
+
+	// Conditions report whether the spec is valid and applied
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// CustomDomainsOperatorConfigConditionValid reports whether the spec was valid and applied
+const CustomDomainsOperatorConfigConditionValid = "Valid"
+
+// +kubebuilder:object:root=true
+
+// CustomDomainsOperatorConfig is the Schema for the customdomainsoperatorconfigs API. Only the one named cluster is read.
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=customdomainsoperatorconfigs,scope=Cluster
+type CustomDomainsOperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CustomDomainsOperatorConfigSpec   `json:"spec,omitempty"`
+	Status CustomDomainsOperatorConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CustomDomainsOperatorConfigList contains a list of CustomDomainsOperatorConfig
+type CustomDomainsOperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CustomDomainsOperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CustomDomainsOperatorConfig{}, &CustomDomainsOperatorConfigList{})
+}
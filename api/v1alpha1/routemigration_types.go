@@ -0,0 +1,125 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RouteMigrationSpec defines the routes to move onto a custom domain
+type RouteMigrationSpec struct {
+	// This field is the name of the CustomDomain the routes are moved onto
+	CustomDomain string `json:"customDomain"`
+
+	// This field lists the names of the routes to migrate, in the namespace of the RouteMigration
+	// +optional
+	Routes []string `json:"routes,omitempty"`
+
+	// This field selects the routes to migrate, in the namespace of the RouteMigration, in addition to the listed ones
+	// +optional
+	RouteSelector *metav1.LabelSelector `json:"routeSelector,omitempty"`
+
+	// This field determines whether the old routes are deleted once the new routes are admitted by the ingress
+	// controller of the CustomDomain. Defaults to Keep.
+	//
+	// +kubebuilder:validation:Enum=Keep;Delete
+	// +kubebuilder:default:="Keep"
+	// +optional
+	OldRoutePolicy OldRoutePolicyType `json:"oldRoutePolicy,omitempty"`
+
+	// This field is how long the old routes are kept after the new routes are admitted, when the oldRoutePolicy is
+	// Delete. Defaults to 24h.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// OldRoutePolicyType is a valid value for RouteMigrationSpec.OldRoutePolicy
+type OldRoutePolicyType string
+
+const (
+	// OldRoutePolicyKeep keeps the old routes
+	OldRoutePolicyKeep OldRoutePolicyType = "Keep"
+
+	// OldRoutePolicyDelete deletes the old routes after the grace period
+	OldRoutePolicyDelete OldRoutePolicyType = "Delete"
+)
+
+// RouteMigrationStatus reports the progress of the migration of each route
+type RouteMigrationStatus struct {
+	// Phase is the progress of the migration
+	// +optional
+	Phase RouteMigrationPhase `json:"phase,omitempty"`
+
+	// Message explains the phase
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Routes is the progress of the migration of each route
+	// +optional
+	Routes []MigratedRoute `json:"routes,omitempty"`
+}
+
+// MigratedRoute reports the progress of the migration of a route
+type MigratedRoute struct {
+	// Source is the name of the old route
+	Source string `json:"source"`
+	// Target is the name of the new route
+	Target string `json:"target"`
+	// Host is the host of the new route under the custom domain
+	Host string `json:"host"`
+	// AdmittedTime is the time the new route was first seen admitted by the ingress controller of the CustomDomain
+	// +optional
+	AdmittedTime *metav1.Time `json:"admittedTime,omitempty"`
+	// SourceDeleted is true once the old route is deleted
+	// +optional
+	SourceDeleted bool `json:"sourceDeleted,omitempty"`
+}
+
+// RouteMigrationPhase is a valid value for RouteMigrationStatus.Phase
+type RouteMigrationPhase string
+
+const (
+	// RouteMigrationPhasePending is set while the CustomDomain is not ready
+	RouteMigrationPhasePending RouteMigrationPhase = "Pending"
+
+	// RouteMigrationPhaseMigrating is set while the new routes wait to be admitted
+	RouteMigrationPhaseMigrating RouteMigrationPhase = "Migrating"
+
+	// RouteMigrationPhaseAdmitted is set when the new routes are admitted and the old routes wait for the grace period
+	RouteMigrationPhaseAdmitted RouteMigrationPhase = "Admitted"
+
+	// RouteMigrationPhaseCompleted is set when the new routes are admitted, and the old routes are deleted if the
+	// oldRoutePolicy is Delete
+	RouteMigrationPhaseCompleted RouteMigrationPhase = "Completed"
+
+	// RouteMigrationPhaseFailed is set when the routes can't be migrated onto the CustomDomain
+	RouteMigrationPhaseFailed RouteMigrationPhase = "Failed"
+)
+
+// +kubebuilder:object:root=true
+
+// RouteMigration creates copies of routes under a custom domain, so they are served by the ingress controller of
+// the CustomDomain, and optionally deletes the old routes. The namespaceSelector of the CustomDomain must select the
+// namespace of the RouteMigration.
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CustomDomain",type=string,JSONPath=`.spec.customDomain`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:resource:path=routemigrations,scope=Namespaced
+type RouteMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RouteMigrationSpec   `json:"spec,omitempty"`
+	Status RouteMigrationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RouteMigrationList contains a list of RouteMigration
+type RouteMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RouteMigration `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RouteMigration{}, &RouteMigrationList{})
+}
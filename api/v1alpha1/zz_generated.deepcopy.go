@@ -5,6 +5,8 @@
 package v1alpha1
 
 import (
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -36,6 +38,133 @@ func (in *CustomDomain) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainClaim) DeepCopyInto(out *CustomDomainClaim) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainClaim.
+func (in *CustomDomainClaim) DeepCopy() *CustomDomainClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CustomDomainClaim) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainClaimList) DeepCopyInto(out *CustomDomainClaimList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CustomDomainClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainClaimList.
+func (in *CustomDomainClaimList) DeepCopy() *CustomDomainClaimList {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainClaimList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CustomDomainClaimList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainClaimPolicy) DeepCopyInto(out *CustomDomainClaimPolicy) {
+	*out = *in
+	if in.AllowedDomainSuffixes != nil {
+		in, out := &in.AllowedDomainSuffixes, &out.AllowedDomainSuffixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxClaimsPerNamespace != nil {
+		in, out := &in.MaxClaimsPerNamespace, &out.MaxClaimsPerNamespace
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainClaimPolicy.
+func (in *CustomDomainClaimPolicy) DeepCopy() *CustomDomainClaimPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainClaimPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainClaimSpec) DeepCopyInto(out *CustomDomainClaimSpec) {
+	*out = *in
+	out.Certificate = in.Certificate
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainClaimSpec.
+func (in *CustomDomainClaimSpec) DeepCopy() *CustomDomainClaimSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainClaimSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainClaimStatus) DeepCopyInto(out *CustomDomainClaimStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]CustomDomainCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainClaimStatus.
+func (in *CustomDomainClaimStatus) DeepCopy() *CustomDomainClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomDomainCondition) DeepCopyInto(out *CustomDomainCondition) {
 	*out = *in
@@ -53,6 +182,76 @@ func (in *CustomDomainCondition) DeepCopy() *CustomDomainCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainDNSProvider) DeepCopyInto(out *CustomDomainDNSProvider) {
+	*out = *in
+	if in.CredentialsSecret != nil {
+		in, out := &in.CredentialsSecret, &out.CredentialsSecret
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
+	if in.Route53 != nil {
+		in, out := &in.Route53, &out.Route53
+		*out = new(Route53DNSProvider)
+		**out = **in
+	}
+	if in.RFC2136 != nil {
+		in, out := &in.RFC2136, &out.RFC2136
+		*out = new(RFC2136DNSProvider)
+		**out = **in
+	}
+	if in.File != nil {
+		in, out := &in.File, &out.File
+		*out = new(FileDNSProvider)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainDNSProvider.
+func (in *CustomDomainDNSProvider) DeepCopy() *CustomDomainDNSProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainDNSProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainDomainPolicy) DeepCopyInto(out *CustomDomainDomainPolicy) {
+	*out = *in
+	if in.AllowedDomainSuffixes != nil {
+		in, out := &in.AllowedDomainSuffixes, &out.AllowedDomainSuffixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ForbiddenDomains != nil {
+		in, out := &in.ForbiddenDomains, &out.ForbiddenDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ForbidPublicSuffixes != nil {
+		in, out := &in.ForbidPublicSuffixes, &out.ForbidPublicSuffixes
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ForbidClusterBaseDomain != nil {
+		in, out := &in.ForbidClusterBaseDomain, &out.ForbidClusterBaseDomain
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainDomainPolicy.
+func (in *CustomDomainDomainPolicy) DeepCopy() *CustomDomainDomainPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainDomainPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomDomainList) DeepCopyInto(out *CustomDomainList) {
 	*out = *in
@@ -85,6 +284,179 @@ func (in *CustomDomainList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainOwnershipChallenge) DeepCopyInto(out *CustomDomainOwnershipChallenge) {
+	*out = *in
+	if in.VerifiedTime != nil {
+		in, out := &in.VerifiedTime, &out.VerifiedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainOwnershipChallenge.
+func (in *CustomDomainOwnershipChallenge) DeepCopy() *CustomDomainOwnershipChallenge {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainOwnershipChallenge)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainPlan) DeepCopyInto(out *CustomDomainPlan) {
+	*out = *in
+	if in.Blockers != nil {
+		in, out := &in.Blockers, &out.Blockers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]CustomDomainPlannedChange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainPlan.
+func (in *CustomDomainPlan) DeepCopy() *CustomDomainPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainPlannedChange) DeepCopyInto(out *CustomDomainPlannedChange) {
+	*out = *in
+	if in.Diff != nil {
+		in, out := &in.Diff, &out.Diff
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainPlannedChange.
+func (in *CustomDomainPlannedChange) DeepCopy() *CustomDomainPlannedChange {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainPlannedChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainPublishedDNSRecord) DeepCopyInto(out *CustomDomainPublishedDNSRecord) {
+	*out = *in
+	if in.PublishedTime != nil {
+		in, out := &in.PublishedTime, &out.PublishedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainPublishedDNSRecord.
+func (in *CustomDomainPublishedDNSRecord) DeepCopy() *CustomDomainPublishedDNSRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainPublishedDNSRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainQuota) DeepCopyInto(out *CustomDomainQuota) {
+	*out = *in
+	if in.MaxCustomDomains != nil {
+		in, out := &in.MaxCustomDomains, &out.MaxCustomDomains
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxExternal != nil {
+		in, out := &in.MaxExternal, &out.MaxExternal
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxInternal != nil {
+		in, out := &in.MaxInternal, &out.MaxInternal
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainQuota.
+func (in *CustomDomainQuota) DeepCopy() *CustomDomainQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainRouteAnalysis) DeepCopyInto(out *CustomDomainRouteAnalysis) {
+	*out = *in
+	if in.Problems != nil {
+		in, out := &in.Problems, &out.Problems
+		*out = make([]CustomDomainRouteProblem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainRouteAnalysis.
+func (in *CustomDomainRouteAnalysis) DeepCopy() *CustomDomainRouteAnalysis {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainRouteAnalysis)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainRouteProblem) DeepCopyInto(out *CustomDomainRouteProblem) {
+	*out = *in
+	if in.IngressControllers != nil {
+		in, out := &in.IngressControllers, &out.IngressControllers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainRouteProblem.
+func (in *CustomDomainRouteProblem) DeepCopy() *CustomDomainRouteProblem {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainRouteProblem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainScopeMigration) DeepCopyInto(out *CustomDomainScopeMigration) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainScopeMigration.
+func (in *CustomDomainScopeMigration) DeepCopy() *CustomDomainScopeMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainScopeMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomDomainSpec) DeepCopyInto(out *CustomDomainSpec) {
 	*out = *in
@@ -99,6 +471,21 @@ func (in *CustomDomainSpec) DeepCopyInto(out *CustomDomainSpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.HostNetwork != nil {
+		in, out := &in.HostNetwork, &out.HostNetwork
+		*out = new(operatorv1.HostNetworkStrategy)
+		**out = **in
+	}
+	if in.NodePort != nil {
+		in, out := &in.NodePort, &out.NodePort
+		*out = new(operatorv1.NodePortStrategy)
+		**out = **in
+	}
+	if in.DNSProvider != nil {
+		in, out := &in.DNSProvider, &out.DNSProvider
+		*out = new(CustomDomainDNSProvider)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainSpec.
@@ -121,6 +508,31 @@ func (in *CustomDomainStatus) DeepCopyInto(out *CustomDomainStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ScopeMigration != nil {
+		in, out := &in.ScopeMigration, &out.ScopeMigration
+		*out = new(CustomDomainScopeMigration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OwnershipChallenge != nil {
+		in, out := &in.OwnershipChallenge, &out.OwnershipChallenge
+		*out = new(CustomDomainOwnershipChallenge)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PublishedDNSRecord != nil {
+		in, out := &in.PublishedDNSRecord, &out.PublishedDNSRecord
+		*out = new(CustomDomainPublishedDNSRecord)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RouteAnalysis != nil {
+		in, out := &in.RouteAnalysis, &out.RouteAnalysis
+		*out = new(CustomDomainRouteAnalysis)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(CustomDomainPlan)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainStatus.
@@ -132,3 +544,329 @@ func (in *CustomDomainStatus) DeepCopy() *CustomDomainStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainsOperatorConfig) DeepCopyInto(out *CustomDomainsOperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainsOperatorConfig.
+func (in *CustomDomainsOperatorConfig) DeepCopy() *CustomDomainsOperatorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainsOperatorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CustomDomainsOperatorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainsOperatorConfigList) DeepCopyInto(out *CustomDomainsOperatorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CustomDomainsOperatorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainsOperatorConfigList.
+func (in *CustomDomainsOperatorConfigList) DeepCopy() *CustomDomainsOperatorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainsOperatorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CustomDomainsOperatorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainsOperatorConfigSpec) DeepCopyInto(out *CustomDomainsOperatorConfigSpec) {
+	*out = *in
+	if in.RestrictedIngressNames != nil {
+		in, out := &in.RestrictedIngressNames, &out.RestrictedIngressNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequeueWait != nil {
+		in, out := &in.RequeueWait, &out.RequeueWait
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ELBIdleTimeout != nil {
+		in, out := &in.ELBIdleTimeout, &out.ELBIdleTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.NodePlacement != nil {
+		in, out := &in.NodePlacement, &out.NodePlacement
+		*out = new(operatorv1.NodePlacement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SyncPeriod != nil {
+		in, out := &in.SyncPeriod, &out.SyncPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.VerifyDomainDelegation != nil {
+		in, out := &in.VerifyDomainDelegation, &out.VerifyDomainDelegation
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DomainVerificationInterval != nil {
+		in, out := &in.DomainVerificationInterval, &out.DomainVerificationInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(CustomDomainQuota)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClaimPolicy != nil {
+		in, out := &in.ClaimPolicy, &out.ClaimPolicy
+		*out = new(CustomDomainClaimPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DomainPolicy != nil {
+		in, out := &in.DomainPolicy, &out.DomainPolicy
+		*out = new(CustomDomainDomainPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainsOperatorConfigSpec.
+func (in *CustomDomainsOperatorConfigSpec) DeepCopy() *CustomDomainsOperatorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainsOperatorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainsOperatorConfigStatus) DeepCopyInto(out *CustomDomainsOperatorConfigStatus) {
+	*out = *in
+	if in.Effective != nil {
+		in, out := &in.Effective, &out.Effective
+		*out = new(CustomDomainsOperatorConfigSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainsOperatorConfigStatus.
+func (in *CustomDomainsOperatorConfigStatus) DeepCopy() *CustomDomainsOperatorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainsOperatorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileDNSProvider) DeepCopyInto(out *FileDNSProvider) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileDNSProvider.
+func (in *FileDNSProvider) DeepCopy() *FileDNSProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(FileDNSProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigratedRoute) DeepCopyInto(out *MigratedRoute) {
+	*out = *in
+	if in.AdmittedTime != nil {
+		in, out := &in.AdmittedTime, &out.AdmittedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigratedRoute.
+func (in *MigratedRoute) DeepCopy() *MigratedRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(MigratedRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RFC2136DNSProvider) DeepCopyInto(out *RFC2136DNSProvider) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RFC2136DNSProvider.
+func (in *RFC2136DNSProvider) DeepCopy() *RFC2136DNSProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(RFC2136DNSProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Route53DNSProvider) DeepCopyInto(out *Route53DNSProvider) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Route53DNSProvider.
+func (in *Route53DNSProvider) DeepCopy() *Route53DNSProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(Route53DNSProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteMigration) DeepCopyInto(out *RouteMigration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteMigration.
+func (in *RouteMigration) DeepCopy() *RouteMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RouteMigration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteMigrationList) DeepCopyInto(out *RouteMigrationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RouteMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteMigrationList.
+func (in *RouteMigrationList) DeepCopy() *RouteMigrationList {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteMigrationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RouteMigrationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteMigrationSpec) DeepCopyInto(out *RouteMigrationSpec) {
+	*out = *in
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RouteSelector != nil {
+		in, out := &in.RouteSelector, &out.RouteSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteMigrationSpec.
+func (in *RouteMigrationSpec) DeepCopy() *RouteMigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteMigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteMigrationStatus) DeepCopyInto(out *RouteMigrationStatus) {
+	*out = *in
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]MigratedRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteMigrationStatus.
+func (in *RouteMigrationStatus) DeepCopy() *RouteMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	customdomaincontrollers "github.com/openshift/custom-domains-operator/controller"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// dnsCheckTimeout bounds the lookups of check-dns
+const dnsCheckTimeout = 10 * time.Second
+
+var checkCertCommand = &command{
+	name:  "check-cert",
+	args:  "<name>",
+	usage: "Check that the TLS secret of a CustomDomain covers its domain, matches its key and is valid",
+	run:   runCheckCert,
+}
+
+var dnsResolverAddress string
+
+var checkDNSCommand = &command{
+	name:  "check-dns",
+	args:  "<name>",
+	usage: "Check that the custom domain of a CustomDomain resolves to its endpoint",
+	run:   runCheckDNS,
+	flags: func(flags *flag.FlagSet) {
+		flags.StringVar(&dnsResolverAddress, "dns-resolver", "",
+			"The host:port of the DNS server used to resolve the custom domain. Defaults to the system resolver.")
+	},
+}
+
+func runCheckCert(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	name, err := singleName(flags, args)
+	if err != nil {
+		return err
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	instance := &customdomainv1alpha1.CustomDomain{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name}, instance); err != nil {
+		return err
+	}
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: instance.Spec.Certificate.Namespace, Name: instance.Spec.Certificate.Name}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return err
+	}
+
+	problems := customdomaincontrollers.CertificateProblems(secret, instance.Spec.Domain, time.Now())
+	if len(problems) > 0 {
+		fmt.Printf("Certificate %s is not valid for *.%s:\n", key, instance.Spec.Domain)
+		for _, problem := range problems {
+			fmt.Printf("  - %s\n", problem)
+		}
+		return errChecksFailed
+	}
+	chain, err := customdomaincontrollers.ParseCertificateChain(secret)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Certificate %s is valid for *.%s, %s\n", key, instance.Spec.Domain, expiresIn(chain[0].NotAfter, time.Now()))
+	return nil
+}
+
+func runCheckDNS(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	name, err := singleName(flags, args)
+	if err != nil {
+		return err
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	instance := &customdomainv1alpha1.CustomDomain{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name}, instance); err != nil {
+		return err
+	}
+	if instance.Status.Endpoint == "" {
+		return fmt.Errorf("CustomDomain %s has no endpoint yet", name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dnsCheckTimeout)
+	defer cancel()
+	delegated, message := customdomaincontrollers.CheckDelegation(ctx, customdomaincontrollers.NewDNSResolver(dnsResolverAddress), instance.Spec.Domain, instance.Status.Endpoint)
+	fmt.Println(message)
+	if !delegated {
+		return errChecksFailed
+	}
+	return nil
+}
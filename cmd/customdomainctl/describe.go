@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	customdomaincontrollers "github.com/openshift/custom-domains-operator/controller"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var describeCommand = &command{
+	name:  "describe",
+	args:  "<name>",
+	usage: "Show a CustomDomain with its conditions, secrets, ingresscontroller, DNS record and router pods",
+	run:   runDescribe,
+}
+
+func runDescribe(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	name, err := singleName(flags, args)
+	if err != nil {
+		return err
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	inspection, err := customdomaincontrollers.InspectCustomDomain(ctx, c, name)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	describe(w, inspection, time.Now())
+	return w.Flush()
+}
+
+// describe writes the inspection of a CustomDomain
+func describe(w io.Writer, inspection *customdomaincontrollers.CustomDomainInspection, now time.Time) {
+	instance := inspection.CustomDomain
+	fmt.Fprintf(w, "Name:\t%s\n", instance.Name)
+	fmt.Fprintf(w, "Domain:\t%s\n", instance.Spec.Domain)
+	fmt.Fprintf(w, "Scope:\t%s\n", orNone(instance.Spec.Scope))
+	fmt.Fprintf(w, "State:\t%s\n", orNone(string(instance.Status.State)))
+	fmt.Fprintf(w, "Endpoint:\t%s\n", orNone(instance.Status.Endpoint))
+	fmt.Fprintf(w, "DNS Record:\t%s\n", orNone(instance.Status.DNSRecord))
+	fmt.Fprintf(w, "Age:\t%s\n", age(instance.CreationTimestamp))
+	fmt.Fprintf(w, "Conditions:\n")
+	if len(instance.Status.Conditions) > 0 {
+		fmt.Fprintf(w, "  TYPE\tSTATUS\tREASON\tMESSAGE\n")
+		for _, cond := range instance.Status.Conditions {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", cond.Type, cond.Status, orNone(cond.Reason), cond.Message)
+		}
+	}
+
+	fmt.Fprintf(w, "Certificate:\t%s/%s\n", instance.Spec.Certificate.Namespace, instance.Spec.Certificate.Name)
+	if inspection.UserSecret == nil {
+		fmt.Fprintf(w, "  Secret:\tNot Found\n")
+	} else {
+		describeCertificate(w, inspection, now)
+	}
+	switch {
+	case inspection.IngressSecret == nil:
+		fmt.Fprintf(w, "Ingress Secret:\tNot Found\n")
+	case inspection.IngressSecretInSync():
+		fmt.Fprintf(w, "Ingress Secret:\t%s/%s (in sync)\n", inspection.IngressSecret.Namespace, inspection.IngressSecret.Name)
+	default:
+		fmt.Fprintf(w, "Ingress Secret:\t%s/%s (out of sync)\n", inspection.IngressSecret.Namespace, inspection.IngressSecret.Name)
+	}
+
+	if ic := inspection.IngressController; ic == nil {
+		fmt.Fprintf(w, "IngressController:\tNot Found\n")
+	} else {
+		fmt.Fprintf(w, "IngressController:\t%s/%s\n", ic.Namespace, ic.Name)
+		fmt.Fprintf(w, "  Domain:\t%s\n", ic.Spec.Domain)
+		if strategy := ic.Spec.EndpointPublishingStrategy; strategy != nil {
+			fmt.Fprintf(w, "  Strategy:\t%s\n", strategy.Type)
+			if strategy.LoadBalancer != nil {
+				fmt.Fprintf(w, "  Scope:\t%s\n", strategy.LoadBalancer.Scope)
+			}
+		}
+		for _, cond := range ic.Status.Conditions {
+			if cond.Type == "Available" || cond.Type == "Degraded" || cond.Type == "Progressing" || cond.Type == "LoadBalancerReady" || cond.Type == "DNSReady" {
+				fmt.Fprintf(w, "  %s:\t%s %s\n", cond.Type, cond.Status, cond.Message)
+			}
+		}
+	}
+
+	if record := inspection.DNSRecord; record == nil {
+		fmt.Fprintf(w, "DNSRecord:\tNot Found\n")
+	} else {
+		fmt.Fprintf(w, "DNSRecord:\t%s/%s\n", record.Namespace, record.Name)
+		fmt.Fprintf(w, "  Name:\t%s\n", record.Spec.DNSName)
+		fmt.Fprintf(w, "  Targets:\t%s %s\n", record.Spec.RecordType, strings.Join(record.Spec.Targets, ", "))
+		for _, zone := range record.Status.Zones {
+			for _, cond := range zone.Conditions {
+				fmt.Fprintf(w, "  Zone %s:\t%s=%s %s\n", zone.DNSZone.ID, cond.Type, cond.Status, cond.Message)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "Router Pods:\n")
+	if len(inspection.RouterPods) > 0 {
+		fmt.Fprintf(w, "  NAME\tREADY\tSTATUS\tNODE\n")
+		for _, pod := range inspection.RouterPods {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", pod.Name, podReady(pod), pod.Status.Phase, orNone(pod.Spec.NodeName))
+		}
+	}
+
+	if analysis := instance.Status.RouteAnalysis; analysis != nil {
+		fmt.Fprintf(w, "Routes:\t%d (%d admitted, %d unadmitted, %d double admitted, %d wrong shard)\n",
+			analysis.Routes, analysis.Admitted, analysis.Unadmitted, analysis.DoubleAdmitted, analysis.WrongShard)
+		for _, problem := range analysis.Problems {
+			fmt.Fprintf(w, "  %s\t%s %s\n", problem.Route, problem.Host, problem.Problem)
+		}
+	}
+}
+
+// describeCertificate writes the certificate of the CustomDomain's TLS secret and its problems
+func describeCertificate(w io.Writer, inspection *customdomaincontrollers.CustomDomainInspection, now time.Time) {
+	chain, err := customdomaincontrollers.ParseCertificateChain(inspection.UserSecret)
+	if err != nil {
+		fmt.Fprintf(w, "  Problem:\t%v\n", err)
+		return
+	}
+	leaf := chain[0]
+	fmt.Fprintf(w, "  Subject:\t%s\n", leaf.Subject)
+	fmt.Fprintf(w, "  DNS Names:\t%s\n", strings.Join(leaf.DNSNames, ", "))
+	fmt.Fprintf(w, "  Issuer:\t%s\n", leaf.Issuer)
+	fmt.Fprintf(w, "  Not After:\t%s (%s)\n", leaf.NotAfter.UTC().Format(time.RFC3339), expiresIn(leaf.NotAfter, now))
+	for _, problem := range customdomaincontrollers.CertificateProblems(inspection.UserSecret, inspection.CustomDomain.Spec.Domain, now) {
+		fmt.Fprintf(w, "  Problem:\t%s\n", problem)
+	}
+}
+
+// expiresIn returns how long until a certificate expires
+func expiresIn(notAfter, now time.Time) string {
+	if now.After(notAfter) {
+		return "expired"
+	}
+	return fmt.Sprintf("expires in %d days", int(notAfter.Sub(now).Hours()/24))
+}
+
+// podReady returns the number of ready containers of a pod, as kubectl shows it
+func podReady(pod corev1.Pod) string {
+	ready := 0
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+	}
+	return fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers))
+}
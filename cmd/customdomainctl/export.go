@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	customdomaincontrollers "github.com/openshift/custom-domains-operator/controller"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var exportOutputDir string
+
+var exportCommand = &command{
+	name:  "export",
+	args:  "[<name>...]",
+	usage: "Render CustomDomains as native IngressController and certificate Secret manifests",
+	run:   runExport,
+	flags: func(flags *flag.FlagSet) {
+		flags.StringVar(&exportOutputDir, "output-dir", "",
+			"The directory in which the manifests of each CustomDomain are written to <name>.yaml. Defaults to printing them.")
+	},
+}
+
+func runExport(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	instances := []customdomainv1alpha1.CustomDomain{}
+	if len(args) == 0 {
+		customDomains := &customdomainv1alpha1.CustomDomainList{}
+		if err := c.List(ctx, customDomains); err != nil {
+			return err
+		}
+		instances = customDomains.Items
+	}
+	for _, name := range args {
+		instance := &customdomainv1alpha1.CustomDomain{}
+		if err := c.Get(ctx, types.NamespacedName{Name: name}, instance); err != nil {
+			return err
+		}
+		instances = append(instances, *instance)
+	}
+
+	store := customdomaincontrollers.NewOperatorConfigStore(customdomaincontrollers.DefaultOperatorConfig())
+	if err := store.LoadOperatorConfig(ctx, c); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: unable to load the operator configuration, rendering with the defaults: %v\n", err)
+	}
+	r := &customdomaincontrollers.CustomDomainReconciler{Client: c, Scheme: scheme, Config: store}
+	for i := range instances {
+		export, err := r.Export(ctx, &instances[i])
+		if err != nil {
+			return fmt.Errorf("CustomDomain %s: %w", instances[i].Name, err)
+		}
+		if exportOutputDir == "" {
+			if i > 0 {
+				fmt.Println("---")
+			}
+			if err := writeExport(os.Stdout, &instances[i], export); err != nil {
+				return err
+			}
+			continue
+		}
+		file := filepath.Join(exportOutputDir, instances[i].Name+".yaml")
+		if err := writeExportFile(file, &instances[i], export); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "CustomDomain %s exported to %s\n", instances[i].Name, file)
+	}
+	return nil
+}
+
+// writeExportFile writes the manifests of an exported CustomDomain to a file
+func writeExportFile(file string, instance *customdomainv1alpha1.CustomDomain, export *customdomaincontrollers.CustomDomainExport) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := writeExport(f, instance, export); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeExport writes the manifests of an exported CustomDomain, preceded by its notes as comments
+func writeExport(w io.Writer, instance *customdomainv1alpha1.CustomDomain, export *customdomaincontrollers.CustomDomainExport) error {
+	if _, err := fmt.Fprintf(w, "# Exported from CustomDomain %s (*.%s)\n", instance.Name, instance.Spec.Domain); err != nil {
+		return err
+	}
+	for _, note := range export.Notes {
+		if _, err := fmt.Fprintf(w, "# - %s\n", note); err != nil {
+			return err
+		}
+	}
+	return writeManifests(w, export.IngressController, export.Secret)
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	customdomaincontrollers "github.com/openshift/custom-domains-operator/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+var generateOptions struct {
+	certFile           string
+	keyFile            string
+	pkcs12File         string
+	pkcs12PasswordFile string
+	namespace          string
+	secretName         string
+	domain             string
+	scope              string
+	apply              bool
+}
+
+var generateCommand = &command{
+	name:  "generate",
+	args:  "<name>",
+	usage: "Generate the TLS secret and CustomDomain manifests serving the wildcard domain of a certificate",
+	run:   runGenerate,
+	flags: func(flags *flag.FlagSet) {
+		flags.StringVar(&generateOptions.certFile, "cert", "", "The PEM encoded certificate chain, from the leaf to the intermediates.")
+		flags.StringVar(&generateOptions.keyFile, "key", "", "The PEM encoded private key of the certificate.")
+		flags.StringVar(&generateOptions.pkcs12File, "pkcs12", "", "A PKCS#12 bundle with the certificate chain and private key, instead of -cert and -key.")
+		flags.StringVar(&generateOptions.pkcs12PasswordFile, "pkcs12-password-file", "", "The file holding the password of the PKCS#12 bundle. Defaults to no password.")
+		flags.StringVar(&generateOptions.namespace, "namespace", "", "The namespace of the TLS secret.")
+		flags.StringVar(&generateOptions.secretName, "secret-name", "", "The name of the TLS secret. Defaults to <name>-tls.")
+		flags.StringVar(&generateOptions.domain, "domain", "", "The custom domain, one of the wildcard DNS names of the certificate. Required when it has several.")
+		flags.StringVar(&generateOptions.scope, "scope", "", "The scope of the custom domain ingress, External or Internal. Defaults to External.")
+		flags.BoolVar(&generateOptions.apply, "apply", false, "Create the TLS secret and CustomDomain in the cluster instead of printing them.")
+	},
+}
+
+func runGenerate(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	name, err := singleName(flags, args)
+	if err != nil {
+		return err
+	}
+	if generateOptions.namespace == "" {
+		return errors.New("the namespace of the TLS secret is required")
+	}
+	if generateOptions.scope != "" && generateOptions.scope != "External" && generateOptions.scope != "Internal" {
+		return fmt.Errorf("invalid scope %s, expected External or Internal", generateOptions.scope)
+	}
+	certPEM, keyPEM, err := loadCertificate()
+	if err != nil {
+		return err
+	}
+	secretName := generateOptions.secretName
+	if secretName == "" {
+		secretName = name + "-tls"
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: generateOptions.namespace},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+	domain, err := certificateDomain(secret, generateOptions.domain)
+	if err != nil {
+		return err
+	}
+	instance := &customdomainv1alpha1.CustomDomain{
+		TypeMeta:   metav1.TypeMeta{APIVersion: customdomainv1alpha1.GroupVersion.String(), Kind: "CustomDomain"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: customdomainv1alpha1.CustomDomainSpec{
+			Domain:      domain,
+			Scope:       generateOptions.scope,
+			Certificate: corev1.SecretReference{Name: secretName, Namespace: generateOptions.namespace},
+		},
+	}
+
+	if !generateOptions.apply {
+		if err := refuseProblems(customdomaincontrollers.CustomDomainProblems(customdomaincontrollers.DefaultOperatorConfig(), instance, secret, "", time.Now())); err != nil {
+			return err
+		}
+		return writeManifests(os.Stdout, secret, instance)
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	store := customdomaincontrollers.NewOperatorConfigStore(customdomaincontrollers.DefaultOperatorConfig())
+	if err := store.LoadOperatorConfig(ctx, c); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: unable to load the operator configuration, checking with the defaults: %v\n", err)
+	}
+	if err := refuseProblems(customdomaincontrollers.CustomDomainProblems(store.Get(), instance, secret, "", time.Now())); err != nil {
+		return err
+	}
+	// the admission checks of the operator, which look at the cluster's base domain and the other CustomDomains
+	validator := &customdomaincontrollers.CustomDomainValidator{Reader: c, Config: store}
+	if _, err := validator.ValidateCreate(ctx, instance); err != nil {
+		return fmt.Errorf("the operator would refuse the CustomDomain: %w", err)
+	}
+	return createManifests(ctx, c, secret, instance)
+}
+
+// createManifests creates the TLS secret and the CustomDomain using it. The secret is deleted if the CustomDomain
+// can't be created, so the command can be run again once the problem is fixed.
+func createManifests(ctx context.Context, c client.Client, secret *corev1.Secret, instance *customdomainv1alpha1.CustomDomain) error {
+	if err := c.Create(ctx, secret); err != nil {
+		return err
+	}
+	fmt.Printf("Secret %s created\n", client.ObjectKeyFromObject(secret))
+	if err := c.Create(ctx, instance); err != nil {
+		if deleteErr := c.Delete(ctx, secret); deleteErr != nil {
+			return fmt.Errorf("%w (the secret %s was created but couldn't be deleted: %v)", err, client.ObjectKeyFromObject(secret), deleteErr)
+		}
+		fmt.Printf("Secret %s deleted\n", client.ObjectKeyFromObject(secret))
+		return err
+	}
+	fmt.Printf("CustomDomain %s created\n", instance.Name)
+	return nil
+}
+
+// loadCertificate returns the PEM encoded certificate chain and private key given on the command line
+func loadCertificate() ([]byte, []byte, error) {
+	if generateOptions.pkcs12File != "" {
+		if generateOptions.certFile != "" || generateOptions.keyFile != "" {
+			return nil, nil, errors.New("-pkcs12 can't be used with -cert and -key")
+		}
+		return loadPKCS12(generateOptions.pkcs12File, generateOptions.pkcs12PasswordFile)
+	}
+	if generateOptions.certFile == "" || generateOptions.keyFile == "" {
+		return nil, nil, errors.New("either -cert and -key or -pkcs12 are required")
+	}
+	certPEM, err := os.ReadFile(generateOptions.certFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(generateOptions.keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return certPEM, keyPEM, nil
+}
+
+// loadPKCS12 returns the PEM encoded certificate chain and private key of a PKCS#12 bundle
+func loadPKCS12(file, passwordFile string) ([]byte, []byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	password := ""
+	if passwordFile != "" {
+		p, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		password = strings.TrimRight(string(p), "\r\n")
+	}
+	key, leaf, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("pkcs12: invalid private key: %w", err)
+	}
+	certs := append([]*x509.Certificate{leaf}, caCerts...)
+	chain, err := orderChain(certs, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM := []byte{}
+	for _, cert := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// orderChain returns the certificate of the private key followed by its issuers, as the router expects them
+func orderChain(certs []*x509.Certificate, key crypto.PrivateKey) ([]*x509.Certificate, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+	var leaf *x509.Certificate
+	for _, cert := range certs {
+		if publicKey, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); ok && publicKey.Equal(signer.Public()) {
+			leaf = cert
+			break
+		}
+	}
+	if leaf == nil {
+		return nil, errors.New("no certificate of the bundle matches its private key")
+	}
+	chain := []*x509.Certificate{leaf}
+	for current := leaf; !bytes.Equal(current.RawIssuer, current.RawSubject); {
+		var issuer *x509.Certificate
+		for _, cert := range certs {
+			if bytes.Equal(cert.RawSubject, current.RawIssuer) && current.CheckSignatureFrom(cert) == nil {
+				issuer = cert
+				break
+			}
+		}
+		if issuer == nil || len(chain) == len(certs) {
+			break
+		}
+		chain = append(chain, issuer)
+		current = issuer
+	}
+	return chain, nil
+}
+
+// certificateDomain returns the custom domain served by the certificate of a TLS secret, from its wildcard DNS names
+func certificateDomain(secret *corev1.Secret, domain string) (string, error) {
+	chain, err := customdomaincontrollers.ParseCertificateChain(secret)
+	if err != nil {
+		return "", err
+	}
+	domains := customdomaincontrollers.CertificateWildcardDomains(chain[0])
+	switch {
+	case domain != "":
+		// the wildcard DNS names are normalized, lower case without the trailing dot
+		for _, d := range domains {
+			if d == strings.ToLower(strings.TrimSuffix(domain, ".")) {
+				return d, nil
+			}
+		}
+		return "", fmt.Errorf("the certificate is not valid for *.%s, it is valid for %v", domain, chain[0].DNSNames)
+	case len(domains) == 0:
+		return "", fmt.Errorf("the certificate has no wildcard DNS name, it is valid for %v", chain[0].DNSNames)
+	case len(domains) > 1:
+		return "", fmt.Errorf("the certificate has several wildcard DNS names, choose the custom domain with -domain: %s", strings.Join(domains, ", "))
+	}
+	return domains[0], nil
+}
+
+// refuseProblems returns an error listing the problems, if any
+func refuseProblems(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("the operator would refuse the CustomDomain:\n  - %s", strings.Join(problems, "\n  - "))
+}
+
+// writeManifests writes objects as a multi-document YAML stream, without the fields set by the API server
+func writeManifests(w io.Writer, objs ...client.Object) error {
+	for i, obj := range objs {
+		manifest, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+		unstructured.RemoveNestedField(manifest, "metadata", "creationTimestamp")
+		unstructured.RemoveNestedField(manifest, "status")
+		data, err := yaml.Marshal(manifest)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	customdomaincontrollers "github.com/openshift/custom-domains-operator/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+var lintOptions struct {
+	secretsDir         string
+	operatorConfigFile string
+	baseDomain         string
+	output             string
+}
+
+var lintCommand = &command{
+	name:  "lint",
+	args:  "<file or directory>...",
+	usage: "Check CustomDomain manifests offline with the validation rules of the operator",
+	run:   runLint,
+	flags: func(flags *flag.FlagSet) {
+		flags.StringVar(&lintOptions.secretsDir, "secrets-dir", "",
+			"A directory of Secret manifests in which the TLS secrets of the CustomDomains are looked up and checked. Defaults to not checking the secrets.")
+		flags.StringVar(&lintOptions.operatorConfigFile, "operator-config", "",
+			"The CustomDomainsOperatorConfig manifest of the cluster, for its restricted names and domain policy. Defaults to the operator's defaults.")
+		flags.StringVar(&lintOptions.baseDomain, "base-domain", "",
+			"The base domain of the cluster, checked when the domain policy forbids it. Defaults to not checking it.")
+		flags.StringVar(&lintOptions.output, "o", "text", "The output format, text or json.")
+	},
+}
+
+// manifest is a YAML document of a file
+type manifest struct {
+	file string
+	// document is the position of the document in the file, from 1
+	document int
+	data     []byte
+	metav1.TypeMeta
+}
+
+// lintFinding is a problem found in a manifest
+type lintFinding struct {
+	File     string `json:"file"`
+	Document int    `json:"document"`
+	Name     string `json:"name,omitempty"`
+	Problem  string `json:"problem"`
+}
+
+// lintResult is the output of lint
+type lintResult struct {
+	// Checked is the number of CustomDomain manifests checked
+	Checked  int           `json:"checked"`
+	Findings []lintFinding `json:"findings"`
+}
+
+func runLint(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if len(args) == 0 {
+		flags.Usage()
+		return errors.New("expected the manifest files or directories to check")
+	}
+	if lintOptions.output != "text" && lintOptions.output != "json" {
+		return fmt.Errorf("invalid output format %s, expected text or json", lintOptions.output)
+	}
+	result, err := lint(args, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := writeLintResult(os.Stdout, result, lintOptions.output); err != nil {
+		return err
+	}
+	if len(result.Findings) > 0 {
+		return errChecksFailed
+	}
+	return nil
+}
+
+// lint checks the CustomDomain manifests under the paths
+func lint(paths []string, now time.Time) (*lintResult, error) {
+	config := customdomaincontrollers.DefaultOperatorConfig()
+	if lintOptions.operatorConfigFile != "" {
+		var err error
+		if config, err = loadOperatorConfig(lintOptions.operatorConfigFile); err != nil {
+			return nil, err
+		}
+	}
+	var secrets map[types.NamespacedName]*corev1.Secret
+	if lintOptions.secretsDir != "" {
+		var err error
+		if secrets, err = loadSecrets(lintOptions.secretsDir); err != nil {
+			return nil, err
+		}
+	}
+
+	manifests, findings, err := readManifests(paths)
+	if err != nil {
+		return nil, err
+	}
+	result := &lintResult{Findings: findings}
+	type customDomainManifest struct {
+		manifest
+		instance *customdomainv1alpha1.CustomDomain
+	}
+	customDomainManifests := []customDomainManifest{}
+	for _, m := range manifests {
+		if m.Kind != "CustomDomain" || m.GroupVersionKind().Group != customdomainv1alpha1.GroupVersion.Group {
+			continue
+		}
+		result.Checked++
+		instance := &customdomainv1alpha1.CustomDomain{}
+		if err := yaml.Unmarshal(m.data, instance); err != nil {
+			result.Findings = append(result.Findings, lintFinding{File: m.file, Document: m.document, Problem: err.Error()})
+			continue
+		}
+		// the API server drops unknown fields, which are usually typos
+		if err := yaml.UnmarshalStrict(m.data, &customdomainv1alpha1.CustomDomain{}); err != nil {
+			result.Findings = append(result.Findings, lintFinding{File: m.file, Document: m.document, Name: instance.Name, Problem: err.Error()})
+		}
+		customDomainManifests = append(customDomainManifests, customDomainManifest{manifest: m, instance: instance})
+	}
+
+	customDomains := []customdomainv1alpha1.CustomDomain{}
+	for _, m := range customDomainManifests {
+		customDomains = append(customDomains, *m.instance)
+	}
+	defined := map[string]manifest{}
+	for _, m := range customDomainManifests {
+		instance := m.instance
+		problems := customdomaincontrollers.CustomDomainSpecProblems(config, instance, lintOptions.baseDomain)
+		if previous, ok := defined[instance.Name]; ok && instance.Name != "" {
+			problems = append(problems, fmt.Sprintf("CustomDomain %s is also defined in %s, document %d", instance.Name, previous.file, previous.document))
+		} else {
+			defined[instance.Name] = m.manifest
+		}
+		problems = append(problems, customdomaincontrollers.CustomDomainConflictProblems(instance, customDomains)...)
+		if secrets != nil && instance.Spec.Certificate.Name != "" {
+			key := types.NamespacedName{Namespace: instance.Spec.Certificate.Namespace, Name: instance.Spec.Certificate.Name}
+			if secret, ok := secrets[key]; ok {
+				problems = append(problems, customdomaincontrollers.CertificateProblems(secret, instance.Spec.Domain, now)...)
+			} else {
+				problems = append(problems, fmt.Sprintf("secret %s is not in %s", key, lintOptions.secretsDir))
+			}
+		}
+		for _, problem := range problems {
+			result.Findings = append(result.Findings, lintFinding{File: m.file, Document: m.document, Name: instance.Name, Problem: problem})
+		}
+	}
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		if result.Findings[i].File != result.Findings[j].File {
+			return result.Findings[i].File < result.Findings[j].File
+		}
+		return result.Findings[i].Document < result.Findings[j].Document
+	})
+	return result, nil
+}
+
+// loadOperatorConfig returns the configuration of the operator from a CustomDomainsOperatorConfig manifest
+func loadOperatorConfig(file string) (customdomaincontrollers.OperatorConfig, error) {
+	manifests, findings, err := readManifests([]string{file})
+	if err != nil {
+		return customdomaincontrollers.OperatorConfig{}, err
+	}
+	if len(findings) > 0 {
+		return customdomaincontrollers.OperatorConfig{}, fmt.Errorf("%s: %s", file, findings[0].Problem)
+	}
+	for _, m := range manifests {
+		if m.Kind != "CustomDomainsOperatorConfig" {
+			continue
+		}
+		operatorConfig := &customdomainv1alpha1.CustomDomainsOperatorConfig{}
+		if err := yaml.UnmarshalStrict(m.data, operatorConfig); err != nil {
+			return customdomaincontrollers.OperatorConfig{}, fmt.Errorf("%s: %w", file, err)
+		}
+		config, err := customdomaincontrollers.OperatorConfigFromSpec(operatorConfig.Spec)
+		if err != nil {
+			return customdomaincontrollers.OperatorConfig{}, fmt.Errorf("%s: %w", file, err)
+		}
+		return config, nil
+	}
+	return customdomaincontrollers.OperatorConfig{}, fmt.Errorf("%s has no CustomDomainsOperatorConfig", file)
+}
+
+// loadSecrets returns the Secret manifests of a directory by namespace and name
+func loadSecrets(dir string) (map[types.NamespacedName]*corev1.Secret, error) {
+	manifests, findings, err := readManifests([]string{dir})
+	if err != nil {
+		return nil, err
+	}
+	if len(findings) > 0 {
+		return nil, fmt.Errorf("%s, document %d: %s", findings[0].File, findings[0].Document, findings[0].Problem)
+	}
+	secrets := map[types.NamespacedName]*corev1.Secret{}
+	for _, m := range manifests {
+		if m.Kind != "Secret" || m.APIVersion != "v1" {
+			continue
+		}
+		secret := &corev1.Secret{}
+		if err := yaml.Unmarshal(m.data, secret); err != nil {
+			return nil, fmt.Errorf("%s, document %d: %w", m.file, m.document, err)
+		}
+		// stringData is merged into data by the API server
+		for key, value := range secret.StringData {
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[key] = []byte(value)
+		}
+		secrets[client.ObjectKeyFromObject(secret)] = secret
+	}
+	return secrets, nil
+}
+
+// readManifests returns the YAML documents of the files, and of the .yaml, .yml and .json files under the directories.
+// Documents that aren't valid YAML are reported as findings.
+func readManifests(paths []string) ([]manifest, []lintFinding, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(file)) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					files = append(files, file)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	manifests := []manifest{}
+	findings := []lintFinding{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+		for document := 1; ; document++ {
+			doc, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				findings = append(findings, lintFinding{File: file, Document: document, Problem: err.Error()})
+				break
+			}
+			m := manifest{file: file, document: document, data: doc}
+			if err := yaml.Unmarshal(doc, &m.TypeMeta); err != nil {
+				findings = append(findings, lintFinding{File: file, Document: document, Problem: err.Error()})
+				continue
+			}
+			if m.Kind == "" {
+				// empty documents, e.g. after a trailing separator
+				continue
+			}
+			manifests = append(manifests, m)
+		}
+	}
+	return manifests, findings, nil
+}
+
+// writeLintResult writes the result of lint in the output format
+func writeLintResult(w io.Writer, result *lintResult, output string) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	for _, finding := range result.Findings {
+		name := ""
+		if finding.Name != "" {
+			name = fmt.Sprintf(" CustomDomain %s:", finding.Name)
+		}
+		if _, err := fmt.Fprintf(w, "%s:%d:%s %s\n", finding.File, finding.Document, name, finding.Problem); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d CustomDomains checked, %d problems found\n", result.Checked, len(result.Findings))
+	return err
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const lintTestManifests = `apiVersion: managed.openshift.io/v1alpha1
+kind: CustomDomain
+metadata:
+  name: tenant
+spec:
+  domain: apps.example.com
+  certificate:
+    name: tenant-tls
+    namespace: tenant
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unrelated
+---
+apiVersion: managed.openshift.io/v1alpha1
+kind: CustomDomain
+metadata:
+  name: other
+spec:
+  domain: shop.apps.example.com
+  scope: Public
+  routeSelector:
+    matchExpressions:
+    - key: shard
+      operator: In
+  certificate:
+    name: other-tls
+    namespace: other
+---
+apiVersion: managed.openshift.io/v1alpha1
+kind: CustomDomain
+metadata:
+  name: typo
+spec:
+  domian: typo.example.com
+`
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "customdomains.yaml"), []byte(lintTestManifests), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	secretsDir := filepath.Join(dir, "secrets")
+	if err := os.Mkdir(secretsDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := loadPKCS12("testdata/nopass.p12", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-tls", Namespace: "tenant"},
+		Data:       map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+	}
+	secretFile, err := os.Create(filepath.Join(secretsDir, "tenant-tls.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeManifests(secretFile, []client.Object{secret}...); err != nil {
+		t.Fatal(err)
+	}
+	secretFile.Close()
+
+	lintOptions.secretsDir = secretsDir
+	defer func() { lintOptions.secretsDir = "" }()
+	result, err := lint([]string{filepath.Join(dir, "customdomains.yaml")}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Checked != 3 {
+		t.Errorf("expected 3 CustomDomains to be checked, got %d", result.Checked)
+	}
+	expected := []struct {
+		document int
+		problem  string
+	}{
+		{1, "overlaps with custom domain shop.apps.example.com of CustomDomain other"},
+		{3, "scope Public is not one of External, Internal"},
+		{3, "routeSelector is invalid"},
+		{3, "overlaps with custom domain apps.example.com of CustomDomain tenant"},
+		{3, "secret other/other-tls is not in"},
+		{4, "unknown field \"domian\""},
+		{4, "domain is required"},
+		{4, "certificate.name and certificate.namespace are required"},
+	}
+	if len(result.Findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %+v", len(expected), result.Findings)
+	}
+	for i, finding := range result.Findings {
+		if finding.Document != expected[i].document || !strings.Contains(finding.Problem, expected[i].problem) {
+			t.Errorf("expected %q in document %d, got %+v", expected[i].problem, expected[i].document, finding)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+var listCommand = &command{
+	name:  "list",
+	usage: "List the CustomDomains with their state and endpoint",
+	run:   runList,
+}
+
+func runList(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if len(args) != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %v", args)
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	customDomains := &customdomainv1alpha1.CustomDomainList{}
+	if err := c.List(ctx, customDomains); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDOMAIN\tSCOPE\tSTATE\tENDPOINT\tAGE")
+	for _, instance := range customDomains.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", instance.Name, instance.Spec.Domain, orNone(instance.Spec.Scope),
+			orNone(string(instance.Status.State)), orNone(instance.Status.Endpoint), age(instance.CreationTimestamp))
+	}
+	return w.Flush()
+}
+
+// age returns the time since a timestamp, as kubectl shows it
+func age(timestamp metav1.Time) string {
+	if timestamp.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(timestamp.Time))
+}
+
+// orNone returns the value, or <none> if it is empty
+func orNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
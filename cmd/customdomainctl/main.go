@@ -0,0 +1,156 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// customdomainctl inspects the CustomDomains of a cluster and the objects serving them. Installed on the PATH as
+// oc-customdomains, it is also an oc plugin: oc customdomains describe <name>.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatoringressv1 "github.com/openshift/api/operatoringress/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(customdomainv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(configv1.AddToScheme(scheme))
+	utilruntime.Must(operatorv1.AddToScheme(scheme))
+	utilruntime.Must(operatoringressv1.AddToScheme(scheme))
+}
+
+// errChecksFailed is returned by the commands whose checks failed, after they reported why
+var errChecksFailed = errors.New("checks failed")
+
+// command is a subcommand of customdomainctl
+type command struct {
+	name  string
+	args  string
+	usage string
+	run   func(ctx context.Context, flags *flag.FlagSet, args []string) error
+	// flags registers the flags of the command
+	flags func(flags *flag.FlagSet)
+}
+
+// commands are the subcommands of customdomainctl
+var commands = []*command{
+	listCommand,
+	describeCommand,
+	checkCertCommand,
+	checkDNSCommand,
+	generateCommand,
+	lintCommand,
+	exportCommand,
+}
+
+// programName returns the name customdomainctl is invoked with, as an oc plugin when it is named oc-<plugin>
+func programName() string {
+	name := filepath.Base(os.Args[0])
+	for _, prefix := range []string{"oc-", "kubectl-"} {
+		if plugin := strings.TrimPrefix(name, prefix); plugin != name && plugin != "" {
+			return strings.TrimSuffix(prefix, "-") + " " + plugin
+		}
+	}
+	return name
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] <command> [command flags] [args]\n\nCommands:\n", programName())
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-12s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == flag.Arg(0) {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s [flags] %s\n\n%s\n", programName(), cmd.name, cmd.args, cmd.usage)
+		flags.PrintDefaults()
+	}
+	if cmd.flags != nil {
+		cmd.flags(flags)
+	}
+	_ = flags.Parse(flag.Args()[1:])
+
+	err := cmd.run(context.Background(), flags, flags.Args())
+	if errors.Is(err, errChecksFailed) {
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newClient returns a client for the cluster of the kubeconfig
+func newClient() (client.Client, error) {
+	restConfig, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return client.New(restConfig, client.Options{Scheme: scheme})
+}
+
+// singleName returns the only argument of a command taking a CustomDomain name
+func singleName(flags *flag.FlagSet, args []string) (string, error) {
+	if len(args) != 1 {
+		flags.Usage()
+		return "", fmt.Errorf("expected the name of a CustomDomain, got %d arguments", len(args))
+	}
+	return args[0], nil
+}
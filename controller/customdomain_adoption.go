@@ -0,0 +1,91 @@
+package managed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// adoptIngressController brings an existing ingresscontroller that was not created by the operator under management,
+// by updating it with the labels of the operator. The change to adopt it is computed by desiredIngressController.
+func (r *CustomDomainReconciler) adoptIngressController(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain, customIngress *operatorv1.IngressController) error {
+	reqLogger.Info(fmt.Sprintf("Adopting ingresscontroller %s", customIngress.Name))
+	err := r.Client.Update(context.TODO(), customIngress)
+	if err != nil {
+		reqLogger.Error(err, fmt.Sprintf("Error adopting ingresscontroller %s", customIngress.Name))
+		return err
+	}
+
+	instance.Status.Conditions = SetCustomDomainCondition(
+		instance.Status.Conditions,
+		customdomainv1alpha1.CustomDomainConditionAdopted,
+		corev1.ConditionTrue,
+		fmt.Sprintf("Adopted existing ingresscontroller %s", customIngress.Name),
+		UpdateConditionIfReasonOrMessageChange)
+	return r.statusUpdate(reqLogger, instance)
+}
+
+// ingressControllerAdoptionProblems lists the differences between an existing ingresscontroller and the one the
+// CustomDomain would create, which prevent the ingresscontroller from being adopted. The spec of an adopted
+// ingresscontroller must already be what the operator manages, so adopting it doesn't change the live router.
+func (r *CustomDomainReconciler) ingressControllerAdoptionProblems(instance *customdomainv1alpha1.CustomDomain, customIngress *operatorv1.IngressController, ingressDomain, ingressScope string, ingressStrategy operatorv1.EndpointPublishingStrategyType, userSecret *corev1.Secret, secretName string) ([]string, error) {
+	problems := []string{}
+	if contains(r.config().RestrictedIngressNames, customIngress.Name) {
+		problems = append(problems, "the name is restricted")
+	}
+	if customIngress.Spec.Domain != ingressDomain {
+		problems = append(problems, fmt.Sprintf("domain %s does not match %s", customIngress.Spec.Domain, ingressDomain))
+	}
+
+	strategy := customIngress.Spec.EndpointPublishingStrategy
+	if strategy == nil || strategy.Type != ingressStrategy {
+		problems = append(problems, fmt.Sprintf("endpoint publishing strategy does not match %s", ingressStrategy))
+	} else if strategy.LoadBalancer != nil && string(strategy.LoadBalancer.Scope) != ingressScope {
+		problems = append(problems, fmt.Sprintf("scope %s does not match %s", strategy.LoadBalancer.Scope, ingressScope))
+	}
+
+	// The certificate served by the ingresscontroller must not change when it is adopted
+	if customIngress.Spec.DefaultCertificate == nil {
+		problems = append(problems, fmt.Sprintf("it has no default certificate, expected secret %s", secretName))
+	} else if customIngress.Spec.DefaultCertificate.Name != secretName {
+		problems = append(problems, fmt.Sprintf("default certificate secret %s does not match %s", customIngress.Spec.DefaultCertificate.Name, secretName))
+	} else {
+		ingressSecret := &corev1.Secret{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{
+			Namespace: ingressNamespace,
+			Name:      customIngress.Spec.DefaultCertificate.Name,
+		}, ingressSecret)
+		if err != nil {
+			if !kerr.IsNotFound(err) {
+				return nil, err
+			}
+			problems = append(problems, fmt.Sprintf("default certificate secret %s was not found", customIngress.Spec.DefaultCertificate.Name))
+		} else if !bytes.Equal(ingressSecret.Data[corev1.TLSCertKey], userSecret.Data[corev1.TLSCertKey]) {
+			problems = append(problems, fmt.Sprintf("default certificate %s does not match secret %s/%s", ingressSecret.Name, userSecret.Namespace, userSecret.Name))
+		}
+	}
+
+	// The fields the operator keeps up to date must not change either
+	if strategy != nil && strategy.Type == ingressStrategy {
+		desired := customIngress.DeepCopy()
+		if err := r.updateIngressControllerSpec(*instance, desired, ingressScope, ingressStrategy); err != nil {
+			return nil, err
+		}
+		diff, err := diffObjects("spec", customIngress.Spec, desired.Spec)
+		if err != nil {
+			return nil, err
+		}
+		if len(diff) > 0 {
+			problems = append(problems, fmt.Sprintf("the operator would change %s", strings.Join(diff, ", ")))
+		}
+	}
+	return problems, nil
+}
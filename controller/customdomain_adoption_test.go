@@ -0,0 +1,230 @@
+package managed
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TestCustomDomainAdoptExisting tests that an unmanaged ingresscontroller is only adopted when requested and compatible
+func TestCustomDomainAdoptExisting(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain  = "cluster1.x8s0.s1.openshiftapps.com"
+		instanceName   = "test"
+		userNamespace  = "my-project"
+		userSecretName = "my-secret"
+	)
+
+	customdomain := &customdomainv1alpha1.CustomDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: instanceName},
+		Spec: customdomainv1alpha1.CustomDomainSpec{
+			Domain: "apps.foo.com",
+			Scope:  "External",
+			Certificate: corev1.SecretReference{
+				Name:      userSecretName,
+				Namespace: userNamespace,
+			},
+		},
+	}
+	existingIngress := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instanceName,
+			Namespace: ingressOperatorNamespace,
+		},
+		Spec: operatorv1.IngressControllerSpec{
+			Domain: "wrong." + clusterDomain,
+			EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+				Type: operatorv1.LoadBalancerServiceStrategyType,
+				LoadBalancer: &operatorv1.LoadBalancerStrategy{
+					Scope: operatorv1.ExternalLoadBalancer,
+				},
+			},
+		},
+	}
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, newTestSecret(userSecretName, userNamespace), customdomain, existingIngress, newTestDNSRecord(instanceName, clusterDomain))
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName}}
+
+	getIngress := func(t *testing.T) *operatorv1.IngressController {
+		ingress := &operatorv1.IngressController{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: instanceName, Namespace: ingressOperatorNamespace}, ingress); err != nil {
+			t.Fatalf("get ingress: (%v)", err)
+		}
+		return ingress
+	}
+	getCustomDomain := func(t *testing.T) *customdomainv1alpha1.CustomDomain {
+		customdomain := &customdomainv1alpha1.CustomDomain{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, customdomain); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		return customdomain
+	}
+
+	// The unlabeled ingresscontroller is not taken over without adoptExisting
+	if _, err := r.Reconcile(context.TODO(), req); err == nil {
+		t.Fatal("reconcile did not return an error for an unmanaged ingresscontroller")
+	}
+	if condition := FindCustomDomainCondition(getCustomDomain(t).Status.Conditions, customdomainv1alpha1.CustomDomainConditionIngressControllerConflict); condition == nil {
+		t.Errorf("condition %s was not set", customdomainv1alpha1.CustomDomainConditionIngressControllerConflict)
+	}
+	if _, ok := getIngress(t).Labels[managedLabelName]; ok {
+		t.Error("unmanaged ingresscontroller was labeled")
+	}
+
+	// An incompatible ingresscontroller is not adopted
+	instance := getCustomDomain(t)
+	instance.Spec.AdoptExisting = true
+	if err := cl.Update(context.TODO(), instance); err != nil {
+		t.Fatalf("update custom domain: (%v)", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), req); err == nil {
+		t.Fatal("reconcile did not return an error for an incompatible ingresscontroller")
+	}
+	if _, ok := getIngress(t).Labels[managedLabelName]; ok {
+		t.Error("incompatible ingresscontroller was labeled")
+	}
+
+	// An ingresscontroller whose spec the operator would change is not adopted, and is left untouched
+	ingress := getIngress(t)
+	rendered, err := r.newIngressController(*instance, instanceName, instanceName+"."+clusterDomain, "External", operatorv1.LoadBalancerServiceStrategyType, instanceName)
+	if err != nil {
+		t.Fatalf("render ingress: (%v)", err)
+	}
+	ingress.Spec = *rendered.Spec.DeepCopy()
+	idleTimeout := &ingress.Spec.EndpointPublishingStrategy.LoadBalancer.ProviderParameters.AWS.ClassicLoadBalancerParameters.ConnectionIdleTimeout
+	idleTimeout.Duration = time.Minute
+	if err := cl.Update(context.TODO(), ingress); err != nil {
+		t.Fatalf("update ingress: (%v)", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), req); err == nil || !strings.Contains(err.Error(), "connectionIdleTimeout") {
+		t.Fatalf("reconcile did not report the idle timeout mismatch: (%v)", err)
+	}
+	if ingress := getIngress(t); ingress.Labels[managedLabelName] != "" ||
+		ingress.Spec.EndpointPublishingStrategy.LoadBalancer.ProviderParameters.AWS.ClassicLoadBalancerParameters.ConnectionIdleTimeout.Duration != time.Minute {
+		t.Errorf("mismatched ingresscontroller was changed: (%+v)", ingress)
+	}
+
+	// The node placement is not managed after creation, and doesn't stop the adoption
+	ingress = getIngress(t)
+	ingress.Spec = rendered.Spec
+	ingress.Spec.NodePlacement = &operatorv1.NodePlacement{NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"router": "true"}}}
+
+	// A compatible ingresscontroller serving the certificate of the CustomDomain is adopted with its secret
+	if err := cl.Update(context.TODO(), ingress); err != nil {
+		t.Fatalf("update ingress: (%v)", err)
+	}
+	if err := cl.Create(context.TODO(), newTestSecret(instanceName, ingressNamespace)); err != nil {
+		t.Fatalf("create ingress secret: (%v)", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	ingress = getIngress(t)
+	if _, ok := ingress.Labels[managedLabelName]; !ok {
+		t.Error("adopted ingresscontroller was not labeled")
+	}
+	if ingress.Spec.DefaultCertificate == nil || ingress.Spec.DefaultCertificate.Name != instanceName {
+		t.Errorf("adopted ingresscontroller certificate mismatch: (%+v)", ingress.Spec.DefaultCertificate)
+	}
+	ingressSecret := &corev1.Secret{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: instanceName, Namespace: ingressNamespace}, ingressSecret); err != nil {
+		t.Fatalf("get ingress secret: (%v)", err)
+	}
+	if _, ok := ingressSecret.Labels[managedLabelName]; !ok {
+		t.Error("secret of the adopted ingresscontroller was not labeled")
+	}
+	if ingress.Spec.NodePlacement.NodeSelector.MatchLabels["router"] != "true" {
+		t.Errorf("adopted ingresscontroller node placement was changed: (%+v)", ingress.Spec.NodePlacement)
+	}
+	instance = getCustomDomain(t)
+	if condition := FindCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionAdopted); condition == nil || condition.Status != corev1.ConditionTrue {
+		t.Errorf("unexpected %s condition: (%+v)", customdomainv1alpha1.CustomDomainConditionAdopted, condition)
+	}
+	if instance.Status.State != customdomainv1alpha1.CustomDomainStateReady {
+		t.Errorf("Status.State does not equal (%s)", string(customdomainv1alpha1.CustomDomainStateReady))
+	}
+}
+
+// TestCustomDomainAdoptExistingCertificateMismatch tests that an unmanaged ingresscontroller serving another
+// certificate is not adopted, and that its certificate secret is left untouched
+func TestCustomDomainAdoptExistingCertificateMismatch(t *testing.T) {
+	const (
+		clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+		instanceName  = "test"
+	)
+
+	instance := newQuotaTestCustomDomain(instanceName, "External", 0)
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, newTestSecret("my-secret", "my-project"), instance, newTestDNSRecord(instanceName, clusterDomain))
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName}}
+
+	existingIngress, err := r.newIngressController(*instance, instanceName, instanceName+"."+clusterDomain, "External", operatorv1.LoadBalancerServiceStrategyType, instanceName)
+	if err != nil {
+		t.Fatalf("render ingress: (%v)", err)
+	}
+	existingIngress.Labels = nil
+	existingSecret := newTestSecret(instanceName, ingressNamespace)
+	existingSecret.Data[corev1.TLSCertKey] = []byte("CAFEBABE")
+	for _, obj := range []client.Object{existingIngress, existingSecret} {
+		if err := cl.Create(context.TODO(), obj); err != nil {
+			t.Fatalf("create %s: (%v)", obj.GetName(), err)
+		}
+	}
+
+	for _, adoptExisting := range []bool{false, true} {
+		if err := cl.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		instance.Spec.AdoptExisting = adoptExisting
+		if err := cl.Update(context.TODO(), instance); err != nil {
+			t.Fatalf("update custom domain: (%v)", err)
+		}
+		if _, err := r.Reconcile(context.TODO(), req); err == nil {
+			t.Fatalf("reconcile did not refuse the ingresscontroller with adoptExisting %v", adoptExisting)
+		}
+
+		ingressSecret := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: instanceName, Namespace: ingressNamespace}, ingressSecret); err != nil {
+			t.Fatalf("get ingress secret: (%v)", err)
+		}
+		if string(ingressSecret.Data[corev1.TLSCertKey]) != "CAFEBABE" || ingressSecret.Labels[managedLabelName] != "" {
+			t.Errorf("secret of the refused ingresscontroller was changed with adoptExisting %v: (%+v)", adoptExisting, ingressSecret)
+		}
+		ingress := &operatorv1.IngressController{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: instanceName, Namespace: ingressOperatorNamespace}, ingress); err != nil {
+			t.Fatalf("get ingress: (%v)", err)
+		}
+		if _, ok := ingress.Labels[managedLabelName]; ok {
+			t.Errorf("refused ingresscontroller was labeled with adoptExisting %v", adoptExisting)
+		}
+
+		if err := cl.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		plan, err := r.Plan(context.TODO(), log, instance)
+		if err != nil {
+			t.Fatalf("plan: (%v)", err)
+		}
+		if len(plan.Blockers) == 0 || len(plan.Changes) != 0 {
+			t.Errorf("expected the plan to be blocked with adoptExisting %v, got %+v", adoptExisting, plan)
+		}
+	}
+}
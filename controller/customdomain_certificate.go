@@ -0,0 +1,94 @@
+package managed
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// certificateHostLabel is the host label checked against the certificate of a custom domain, any route host under
+// the custom domain must be covered by it
+const certificateHostLabel = "route"
+
+// ParseCertificateChain returns the certificates of a TLS secret, from the leaf to the last intermediate
+func ParseCertificateChain(secret *corev1.Secret) ([]*x509.Certificate, error) {
+	data, ok := secret.Data[corev1.TLSCertKey]
+	if !ok || len(data) == 0 {
+		return nil, fmt.Errorf("secret %s/%s has no %s", secret.Namespace, secret.Name, corev1.TLSCertKey)
+	}
+	chain := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("secret %s/%s has an invalid certificate: %w", secret.Namespace, secret.Name, err)
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("secret %s/%s has no PEM encoded certificate in %s", secret.Namespace, secret.Name, corev1.TLSCertKey)
+	}
+	return chain, nil
+}
+
+// CertificateProblems lists why a TLS secret can't serve the routes of a custom domain at the given time: a missing or
+// mismatched key, a certificate that doesn't cover the hosts under the custom domain or isn't valid at that time, and
+// an intermediate chain out of order
+func CertificateProblems(secret *corev1.Secret, domain string, now time.Time) []string {
+	chain, err := ParseCertificateChain(secret)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	problems := []string{}
+	if _, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]); err != nil {
+		problems = append(problems, fmt.Sprintf("the private key doesn't match the certificate: %v", err))
+	}
+
+	leaf := chain[0]
+	host := fmt.Sprintf("%s.%s", certificateHostLabel, domain)
+	if err := leaf.VerifyHostname(host); err != nil {
+		var hostnameErr x509.HostnameError
+		if errors.As(err, &hostnameErr) {
+			problems = append(problems, fmt.Sprintf("the certificate doesn't cover *.%s, it is valid for %v", domain, leaf.DNSNames))
+		} else {
+			problems = append(problems, fmt.Sprintf("the certificate doesn't cover *.%s: %v", domain, err))
+		}
+	}
+	if now.Before(leaf.NotBefore) {
+		problems = append(problems, fmt.Sprintf("the certificate is not valid before %s", leaf.NotBefore.UTC().Format(time.RFC3339)))
+	}
+	if now.After(leaf.NotAfter) {
+		problems = append(problems, fmt.Sprintf("the certificate expired on %s", leaf.NotAfter.UTC().Format(time.RFC3339)))
+	}
+	for i := 1; i < len(chain); i++ {
+		if err := chain[i-1].CheckSignatureFrom(chain[i]); err != nil {
+			problems = append(problems, fmt.Sprintf("certificate %d of the chain (%s) is not signed by the next one (%s)", i, chain[i-1].Subject, chain[i].Subject))
+		}
+	}
+	return problems
+}
+
+// CertificateWildcardDomains returns the custom domains a certificate can serve, from its wildcard DNS names
+func CertificateWildcardDomains(cert *x509.Certificate) []string {
+	domains := []string{}
+	for _, name := range cert.DNSNames {
+		domain := normalizeDomain(strings.TrimPrefix(name, "*."))
+		if domain != normalizeDomain(name) && domain != "" && !contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
@@ -0,0 +1,100 @@
+package managed
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newTestCertificateSecret returns a TLS secret with a self-signed certificate for the DNS names, valid until notAfter
+func newTestCertificateSecret(t *testing.T, name, namespace string, dnsNames []string, notAfter time.Time) *corev1.Secret {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		},
+		Type: corev1.SecretTypeTLS,
+	}
+}
+
+func TestCertificateProblems(t *testing.T) {
+	now := time.Now()
+	valid := newTestCertificateSecret(t, "my-secret", "my-project", []string{"*.apps.example.com"}, now.Add(24*time.Hour))
+	mismatched := valid.DeepCopy()
+	mismatched.Data[corev1.TLSPrivateKeyKey] = newTestCertificateSecret(t, "other", "my-project", []string{"*.apps.example.com"}, now.Add(time.Hour)).Data[corev1.TLSPrivateKeyKey]
+
+	tests := []struct {
+		name     string
+		secret   *corev1.Secret
+		domain   string
+		expected string
+	}{
+		{name: "valid", secret: valid, domain: "apps.example.com"},
+		{name: "other domain", secret: valid, domain: "apps.foo.com", expected: "doesn't cover *.apps.foo.com"},
+		{name: "parent domain", secret: valid, domain: "example.com", expected: "doesn't cover *.example.com"},
+		{
+			name:     "expired",
+			secret:   newTestCertificateSecret(t, "my-secret", "my-project", []string{"*.apps.example.com"}, now.Add(-time.Hour)),
+			domain:   "apps.example.com",
+			expected: "expired",
+		},
+		{name: "mismatched key", secret: mismatched, domain: "apps.example.com", expected: "private key doesn't match"},
+		{name: "not a certificate", secret: newTestSecret("my-secret", "my-project"), domain: "apps.example.com", expected: "no PEM encoded certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := CertificateProblems(tt.secret, tt.domain, now)
+			if tt.expected == "" {
+				if len(problems) != 0 {
+					t.Errorf("expected no problems, got %v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || !strings.Contains(problems[0], tt.expected) {
+				t.Errorf("expected a problem containing %q, got %v", tt.expected, problems)
+			}
+		})
+	}
+}
+
+func TestCertificateWildcardDomains(t *testing.T) {
+	secret := newTestCertificateSecret(t, "my-secret", "my-project", []string{"*.apps.example.com", "apps.example.com", "*.APPS.example.com.", "*.foo.com"}, time.Now().Add(time.Hour))
+	chain, err := ParseCertificateChain(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	domains := CertificateWildcardDomains(chain[0])
+	if len(domains) != 2 || domains[0] != "apps.example.com" || domains[1] != "foo.com" {
+		t.Errorf("expected apps.example.com and foo.com, got %v", domains)
+	}
+}
@@ -0,0 +1,290 @@
+package managed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	customDomainClaimFinalizer = "finalizer.customdomainclaim.managed.openshift.io"
+
+	// claimNamespaceLabelName and claimNameLabelName identify the CustomDomainClaim of a CustomDomain
+	claimNamespaceLabelName = "customdomains.managed.openshift.io/claim-namespace"
+	claimNameLabelName      = "customdomains.managed.openshift.io/claim-name"
+
+	// namespaceNameLabelName is set by Kubernetes on every namespace, and locks the CustomDomain of a claim to its
+	// namespace
+	namespaceNameLabelName = "kubernetes.io/metadata.name"
+
+	// maxClaimCustomDomainNameLength is the length of the longest CustomDomain name derived from a claim
+	maxClaimCustomDomainNameLength = 63
+)
+
+// CustomDomainClaimReconciler turns the CustomDomainClaims allowed by the claim policy into CustomDomains serving the
+// routes of their namespace, and mirrors the status of the CustomDomains back to the claims
+type CustomDomainClaimReconciler struct {
+	Client client.Client
+	// Config holds the operator configuration, including the claim policy. Defaults to DefaultOperatorConfig.
+	Config *OperatorConfigStore
+}
+
+//+kubebuilder:rbac:groups=managed.openshift.io,resources=customdomainclaims,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=managed.openshift.io,resources=customdomainclaims/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=managed.openshift.io,resources=customdomainclaims/finalizers,verbs=update
+
+// Reconcile creates, updates or deletes the CustomDomain of a CustomDomainClaim
+func (r *CustomDomainClaimReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("CustomDomainClaim.Namespace", request.Namespace, "CustomDomainClaim.Name", request.Name)
+
+	claim := &customdomainv1alpha1.CustomDomainClaim{}
+	err := r.Client.Get(ctx, request.NamespacedName, claim)
+	if err != nil {
+		if kerr.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if claim.DeletionTimestamp != nil {
+		if contains(claim.GetFinalizers(), customDomainClaimFinalizer) {
+			if err := r.deleteClaimCustomDomain(ctx, reqLogger, claim); err != nil {
+				return reconcile.Result{}, err
+			}
+			claim.SetFinalizers(remove(claim.GetFinalizers(), customDomainClaimFinalizer))
+			if err := r.Client.Update(ctx, claim); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		return reconcile.Result{}, nil
+	}
+
+	// Claims being deleted are finalized even when paused, so they don't hang in Terminating
+	config := operatorConfigOrDefault(r.Config)
+	if config.Paused {
+		reqLogger.Info("Reconciliation is paused by the operator configuration")
+		return reconcile.Result{Requeue: true, RequeueAfter: config.RequeueWait}, nil
+	}
+
+	if !contains(claim.GetFinalizers(), customDomainClaimFinalizer) {
+		reqLogger.Info("Adding Finalizer for the CustomDomainClaim")
+		claim.SetFinalizers(append(claim.GetFinalizers(), customDomainClaimFinalizer))
+		if err := r.Client.Update(ctx, claim); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	violation, err := r.claimPolicyViolation(ctx, claim, config.ClaimPolicy)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if violation != "" {
+		// Claims are checked again later, as the claim policy or the other claims of the namespace may change.
+		// An existing CustomDomain is left untouched.
+		reqLogger.Info(fmt.Sprintf("CustomDomainClaim rejected: %s", violation))
+		claim.Status.Phase = customdomainv1alpha1.CustomDomainClaimPhaseRejected
+		claim.Status.Message = violation
+		return reconcile.Result{Requeue: true, RequeueAfter: config.RequeueWait}, r.Client.Status().Update(ctx, claim)
+	}
+
+	instance, err := r.ensureClaimCustomDomain(ctx, reqLogger, claim)
+	if err != nil {
+		claim.Status.Phase = customdomainv1alpha1.CustomDomainClaimPhasePending
+		claim.Status.Message = err.Error()
+		_ = r.Client.Status().Update(ctx, claim)
+		return reconcile.Result{}, err
+	}
+
+	claim.Status.Phase = customdomainv1alpha1.CustomDomainClaimPhaseBound
+	claim.Status.Message = fmt.Sprintf("The claim is served by CustomDomain %s", instance.Name)
+	claim.Status.CustomDomain = instance.Name
+	claim.Status.State = instance.Status.State
+	claim.Status.Endpoint = instance.Status.Endpoint
+	claim.Status.DNSRecord = instance.Status.DNSRecord
+	claim.Status.Conditions = instance.Status.Conditions
+	return reconcile.Result{}, r.Client.Status().Update(ctx, claim)
+}
+
+// claimPolicyViolation returns why the claim policy doesn't allow a claim, or an empty string if it does. The oldest
+// claims of a namespace fit in the maximum number of claims, so if it is lowered the most recent claims are rejected.
+func (r *CustomDomainClaimReconciler) claimPolicyViolation(ctx context.Context, claim *customdomainv1alpha1.CustomDomainClaim, policy *customdomainv1alpha1.CustomDomainClaimPolicy) (string, error) {
+	if policy == nil {
+		return "CustomDomainClaims are not enabled on this cluster", nil
+	}
+
+	if policy.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(policy.NamespaceSelector)
+		if err != nil {
+			return "", err
+		}
+		namespace := &corev1.Namespace{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: claim.Namespace}, namespace)
+		if err != nil {
+			return "", err
+		}
+		if !selector.Matches(labels.Set(namespace.Labels)) {
+			return fmt.Sprintf("namespace %s is not allowed to claim custom domains", claim.Namespace), nil
+		}
+	}
+
+	if !domainHasAllowedSuffix(claim.Spec.Domain, policy.AllowedDomainSuffixes) {
+		return fmt.Sprintf("domain %s is not a subdomain of the allowed domains: %s", claim.Spec.Domain, strings.Join(policy.AllowedDomainSuffixes, ", ")), nil
+	}
+
+	if policy.MaxClaimsPerNamespace != nil {
+		claims := &customdomainv1alpha1.CustomDomainClaimList{}
+		err := r.Client.List(ctx, claims, client.InNamespace(claim.Namespace))
+		if err != nil {
+			return "", err
+		}
+		counted := []customdomainv1alpha1.CustomDomainClaim{}
+		for _, c := range claims.Items {
+			if c.DeletionTimestamp == nil {
+				counted = append(counted, c)
+			}
+		}
+		sort.Slice(counted, func(i, j int) bool {
+			if !counted[i].CreationTimestamp.Equal(&counted[j].CreationTimestamp) {
+				return counted[i].CreationTimestamp.Before(&counted[j].CreationTimestamp)
+			}
+			return counted[i].Name < counted[j].Name
+		})
+		for rank, c := range counted {
+			if c.Name == claim.Name && rank >= int(*policy.MaxClaimsPerNamespace) {
+				return fmt.Sprintf("namespace %s is limited to %d CustomDomainClaims", claim.Namespace, *policy.MaxClaimsPerNamespace), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// claimCustomDomainName returns the name of the CustomDomain of a claim: <namespace>-<name>-<hash> if it is a valid
+// CustomDomain name, otherwise claim-<hash>. The hash of the namespace and name keeps the names of claims such as
+// a-b/c and a/b-c apart.
+func claimCustomDomainName(claim *customdomainv1alpha1.CustomDomainClaim) string {
+	sum := sha256.Sum256([]byte(claim.Namespace + "/" + claim.Name))
+	hash := hex.EncodeToString(sum[:])
+	name := fmt.Sprintf("%s-%s-%s", claim.Namespace, claim.Name, hash[:8])
+	if len(name) <= maxClaimCustomDomainNameLength && validObjectNames.MatchString(name) {
+		return name
+	}
+	return "claim-" + hash[:16]
+}
+
+// isClaimCustomDomain returns true if the CustomDomain was created for the claim
+func isClaimCustomDomain(instance *customdomainv1alpha1.CustomDomain, claim *customdomainv1alpha1.CustomDomainClaim) bool {
+	return instance.Labels[claimNamespaceLabelName] == claim.Namespace && instance.Labels[claimNameLabelName] == claim.Name
+}
+
+// claimCustomDomainSpec returns the spec of the CustomDomain of a claim, which only serves the routes of its namespace
+func claimCustomDomainSpec(claim *customdomainv1alpha1.CustomDomainClaim) customdomainv1alpha1.CustomDomainSpec {
+	return customdomainv1alpha1.CustomDomainSpec{
+		Domain: claim.Spec.Domain,
+		Certificate: corev1.SecretReference{
+			Name:      claim.Spec.Certificate.Name,
+			Namespace: claim.Namespace,
+		},
+		Scope: claim.Spec.Scope,
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{namespaceNameLabelName: claim.Namespace},
+		},
+	}
+}
+
+// ensureClaimCustomDomain creates the CustomDomain of a claim, or updates it to match the claim
+func (r *CustomDomainClaimReconciler) ensureClaimCustomDomain(ctx context.Context, reqLogger logr.Logger, claim *customdomainv1alpha1.CustomDomainClaim) (*customdomainv1alpha1.CustomDomain, error) {
+	name := claimCustomDomainName(claim)
+	instance := &customdomainv1alpha1.CustomDomain{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: name}, instance)
+	if err != nil {
+		if !kerr.IsNotFound(err) {
+			return nil, err
+		}
+		instance = &customdomainv1alpha1.CustomDomain{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					claimNamespaceLabelName: claim.Namespace,
+					claimNameLabelName:      claim.Name,
+				},
+			},
+			Spec: claimCustomDomainSpec(claim),
+		}
+		reqLogger.Info(fmt.Sprintf("Creating CustomDomain %s for the CustomDomainClaim", name))
+		return instance, r.Client.Create(ctx, instance)
+	}
+
+	if !isClaimCustomDomain(instance, claim) {
+		return nil, fmt.Errorf("CustomDomain %s already exists and was not created for this claim", name)
+	}
+
+	// Only the fields derived from the claim are updated, so the fields set by administrators are kept
+	spec := claimCustomDomainSpec(claim)
+	desired := instance.Spec.DeepCopy()
+	desired.Domain = spec.Domain
+	desired.Certificate = spec.Certificate
+	desired.Scope = spec.Scope
+	desired.NamespaceSelector = spec.NamespaceSelector
+	if !equality.Semantic.DeepEqual(&instance.Spec, desired) {
+		reqLogger.Info(fmt.Sprintf("Updating CustomDomain %s to match the CustomDomainClaim", name))
+		instance.Spec = *desired
+		if err := r.Client.Update(ctx, instance); err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+// deleteClaimCustomDomain deletes the CustomDomain of a claim, unless it wasn't created for the claim
+func (r *CustomDomainClaimReconciler) deleteClaimCustomDomain(ctx context.Context, reqLogger logr.Logger, claim *customdomainv1alpha1.CustomDomainClaim) error {
+	instance := &customdomainv1alpha1.CustomDomain{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: claimCustomDomainName(claim)}, instance)
+	if err != nil {
+		if kerr.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !isClaimCustomDomain(instance, claim) {
+		return nil
+	}
+	reqLogger.Info(fmt.Sprintf("Deleting CustomDomain %s of the CustomDomainClaim", instance.Name))
+	err = r.Client.Delete(ctx, instance)
+	if err != nil && !kerr.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CustomDomainClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// customDomainHandler reconciles the claim of a CustomDomain, so the claim mirrors its status
+	customDomainHandler := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+		namespace, name := obj.GetLabels()[claimNamespaceLabelName], obj.GetLabels()[claimNameLabelName]
+		if namespace == "" || name == "" {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&customdomainv1alpha1.CustomDomainClaim{}).
+		Watches(&customdomainv1alpha1.CustomDomain{}, customDomainHandler).
+		Complete(r)
+}
@@ -0,0 +1,168 @@
+package managed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// newTestClaim returns a CustomDomainClaim created the given number of minutes ago
+func newTestClaim(name, namespace, domain string, age int) *customdomainv1alpha1.CustomDomainClaim {
+	return &customdomainv1alpha1.CustomDomainClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Duration(age) * time.Minute)),
+		},
+		Spec: customdomainv1alpha1.CustomDomainClaimSpec{
+			Domain:      domain,
+			Certificate: corev1.LocalObjectReference{Name: "my-secret"},
+		},
+	}
+}
+
+// reconcileTestClaim reconciles a claim and returns it
+func reconcileTestClaim(t *testing.T, r *CustomDomainClaimReconciler, name, namespace string) *customdomainv1alpha1.CustomDomainClaim {
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	_, _ = r.Reconcile(context.TODO(), req)
+	claim := &customdomainv1alpha1.CustomDomainClaim{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, claim); err != nil {
+		t.Fatalf("get claim: (%v)", err)
+	}
+	return claim
+}
+
+// TestCustomDomainClaim tests that the claims allowed by the claim policy are turned into CustomDomains locked to
+// their namespace
+func TestCustomDomainClaim(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "my-project",
+		Labels: map[string]string{namespaceNameLabelName: "my-project", "tenant": "true"},
+	}}
+	cl := NewTestMock(t,
+		namespace,
+		newTestClaim("apps", "my-project", "apps.my-project.example.com", 30),
+		newTestClaim("other", "my-project", "other.my-project.example.com", 20),
+		newTestClaim("foreign", "my-project", "apps.foo.com", 10),
+	)
+	r := &CustomDomainClaimReconciler{Client: cl, Config: newTestOperatorConfigStore(func(c *OperatorConfig) {})}
+
+	// claims are rejected without a claim policy
+	claim := reconcileTestClaim(t, r, "apps", "my-project")
+	if claim.Status.Phase != customdomainv1alpha1.CustomDomainClaimPhaseRejected {
+		t.Errorf("expected the claim to be rejected without a claim policy, got %+v", claim.Status)
+	}
+
+	r.Config = newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.ClaimPolicy = &customdomainv1alpha1.CustomDomainClaimPolicy{
+			AllowedDomainSuffixes: []string{"example.com"},
+			MaxClaimsPerNamespace: pointer.Int32(1),
+			NamespaceSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "true"}},
+		}
+	})
+
+	claim = reconcileTestClaim(t, r, "apps", "my-project")
+	if claim.Status.Phase != customdomainv1alpha1.CustomDomainClaimPhaseBound || claim.Status.CustomDomain != "my-project-apps-75ac416d" {
+		t.Fatalf("expected the claim to be bound, got %+v", claim.Status)
+	}
+	instance := &customdomainv1alpha1.CustomDomain{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "my-project-apps-75ac416d"}, instance); err != nil {
+		t.Fatalf("get custom domain: (%v)", err)
+	}
+	if instance.Spec.Domain != "apps.my-project.example.com" || instance.Spec.Certificate.Namespace != "my-project" ||
+		instance.Spec.NamespaceSelector.MatchLabels[namespaceNameLabelName] != "my-project" {
+		t.Errorf("unexpected CustomDomain spec %+v", instance.Spec)
+	}
+
+	// the status of the CustomDomain is mirrored to the claim
+	instance.Status.State = customdomainv1alpha1.CustomDomainStateReady
+	instance.Status.Endpoint = "abcdef.my-project-apps-75ac416d.cluster1.x8s0.s1.openshiftapps.com"
+	if err := cl.Update(context.TODO(), instance); err != nil {
+		t.Fatalf("update custom domain: (%v)", err)
+	}
+	claim = reconcileTestClaim(t, r, "apps", "my-project")
+	if claim.Status.State != customdomainv1alpha1.CustomDomainStateReady || claim.Status.Endpoint != instance.Status.Endpoint {
+		t.Errorf("expected the CustomDomain status to be mirrored, got %+v", claim.Status)
+	}
+
+	// claims over the maximum or outside the allowed domains are rejected
+	for _, name := range []string{"other", "foreign"} {
+		claim = reconcileTestClaim(t, r, name, "my-project")
+		if claim.Status.Phase != customdomainv1alpha1.CustomDomainClaimPhaseRejected {
+			t.Errorf("%s: expected the claim to be rejected, got %+v", name, claim.Status)
+		}
+		err := cl.Get(context.TODO(), types.NamespacedName{Name: claimCustomDomainName(newTestClaim(name, "my-project", "", 0))}, &customdomainv1alpha1.CustomDomain{})
+		if !kerr.IsNotFound(err) {
+			t.Errorf("%s: expected no CustomDomain for a rejected claim: (%v)", name, err)
+		}
+	}
+
+	// deleting the claim deletes its CustomDomain, even while the operator is paused
+	claim = reconcileTestClaim(t, r, "apps", "my-project")
+	r.Config = newTestOperatorConfigStore(func(c *OperatorConfig) { c.Paused = true })
+	if err := cl.Delete(context.TODO(), claim); err != nil {
+		t.Fatalf("delete claim: (%v)", err)
+	}
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: "my-project-apps-75ac416d"}, &customdomainv1alpha1.CustomDomain{})
+	if !kerr.IsNotFound(err) {
+		t.Errorf("expected the CustomDomain of a deleted claim to be deleted: (%v)", err)
+	}
+	err = cl.Get(context.TODO(), client.ObjectKeyFromObject(claim), &customdomainv1alpha1.CustomDomainClaim{})
+	if !kerr.IsNotFound(err) {
+		t.Errorf("expected the deleted claim to be finalized: (%v)", err)
+	}
+}
+
+// TestCustomDomainClaimConflict tests that a claim doesn't take over a CustomDomain it didn't create
+func TestCustomDomainClaimConflict(t *testing.T) {
+	existing := &customdomainv1alpha1.CustomDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-project-apps-75ac416d"},
+		Spec:       customdomainv1alpha1.CustomDomainSpec{Domain: "apps.foo.com"},
+	}
+	cl := NewTestMock(t, existing, newTestClaim("apps", "my-project", "apps.example.com", 0))
+	r := &CustomDomainClaimReconciler{Client: cl, Config: newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.ClaimPolicy = &customdomainv1alpha1.CustomDomainClaimPolicy{AllowedDomainSuffixes: []string{"example.com"}}
+	})}
+
+	claim := reconcileTestClaim(t, r, "apps", "my-project")
+	if claim.Status.Phase != customdomainv1alpha1.CustomDomainClaimPhasePending {
+		t.Errorf("expected the claim to be pending, got %+v", claim.Status)
+	}
+	instance := &customdomainv1alpha1.CustomDomain{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "my-project-apps-75ac416d"}, instance); err != nil {
+		t.Fatalf("get custom domain: (%v)", err)
+	}
+	if instance.Spec.Domain != "apps.foo.com" {
+		t.Errorf("expected the existing CustomDomain to be left untouched, got %+v", instance.Spec)
+	}
+}
+
+func TestClaimCustomDomainName(t *testing.T) {
+	short := newTestClaim("apps", "my-project", "", 0)
+	if name := claimCustomDomainName(short); name != "my-project-apps-75ac416d" {
+		t.Errorf("expected my-project-apps-75ac416d, got %s", name)
+	}
+	dotted := newTestClaim("apps.v2", "my-project", "", 0)
+	name := claimCustomDomainName(dotted)
+	if !validObjectNames.MatchString(name) || name == claimCustomDomainName(short) {
+		t.Errorf("expected a valid and distinct name, got %s", name)
+	}
+	// the namespace and name of these claims join to the same string
+	first, second := claimCustomDomainName(newTestClaim("c", "a-b", "", 0)), claimCustomDomainName(newTestClaim("b-c", "a", "", 0))
+	if first == second {
+		t.Errorf("expected distinct names for a-b/c and a/b-c, got %s", first)
+	}
+}
@@ -0,0 +1,434 @@
+package managed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// maxELBIdleTimeout is the longest idle timeout supported by AWS classic load balancers
+	maxELBIdleTimeout = 4000 * time.Second
+	// maxEndpointHostLength is the number of bytes of the hash endpoint hosts are derived from
+	maxEndpointHostLength = 32
+	// defaultSyncPeriod is how often all CustomDomains are reconciled
+	defaultSyncPeriod = 15 * time.Minute
+	// defaultIngressName is the ingresscontroller of the cluster, always restricted
+	defaultIngressName = "default"
+)
+
+// OperatorConfig is the configuration in effect, read from the CustomDomainsOperatorConfig
+type OperatorConfig struct {
+	RestrictedIngressNames []string
+	RequeueWait            time.Duration
+	ELBIdleTimeout         time.Duration
+	EndpointHostLength     int
+	NodePlacement          *operatorv1.NodePlacement
+	SyncPeriod             time.Duration
+	VerifyDelegation       bool
+	// DomainVerificationInterval is how often an unverified custom domain is checked again
+	DomainVerificationInterval time.Duration
+	OwnershipVerification      OwnershipVerificationMode
+	Quota                      customdomainv1alpha1.CustomDomainQuota
+	ClaimPolicy                *customdomainv1alpha1.CustomDomainClaimPolicy
+	DomainPolicy               DomainPolicy
+	Paused                     bool
+}
+
+// DefaultOperatorConfig returns the configuration used when no CustomDomainsOperatorConfig exists
+func DefaultOperatorConfig() OperatorConfig {
+	return OperatorConfig{
+		RestrictedIngressNames: []string{defaultIngressName, "apps2", "apps"},
+		RequeueWait:            requeueWaitMinutes * time.Minute,
+		ELBIdleTimeout:         ELBIdleTimeoutDuration * time.Second,
+		EndpointHostLength:     hostLength,
+		NodePlacement: &operatorv1.NodePlacement{
+			NodeSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"node-role.kubernetes.io/infra": ""},
+			},
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "node-role.kubernetes.io/infra",
+					Effect:   corev1.TaintEffectNoSchedule,
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+		},
+		SyncPeriod:                 defaultSyncPeriod,
+		VerifyDelegation:           false,
+		DomainVerificationInterval: defaultDomainVerificationInterval,
+		OwnershipVerification:      OwnershipVerificationDisabled,
+		DomainPolicy: DomainPolicy{
+			ForbidPublicSuffixes:    true,
+			ForbidClusterBaseDomain: true,
+		},
+	}
+}
+
+// DeepCopy returns a copy of the configuration that does not share its slices and pointers
+func (c OperatorConfig) DeepCopy() OperatorConfig {
+	c.RestrictedIngressNames = append([]string{}, c.RestrictedIngressNames...)
+	c.NodePlacement = c.NodePlacement.DeepCopy()
+	c.Quota = *c.Quota.DeepCopy()
+	c.ClaimPolicy = c.ClaimPolicy.DeepCopy()
+	c.DomainPolicy.AllowedDomainSuffixes = append([]string{}, c.DomainPolicy.AllowedDomainSuffixes...)
+	c.DomainPolicy.ForbiddenDomains = append([]string{}, c.DomainPolicy.ForbiddenDomains...)
+	return c
+}
+
+// Spec returns the configuration as a CustomDomainsOperatorConfigSpec with every field set, to report it in the status
+func (c OperatorConfig) Spec() *customdomainv1alpha1.CustomDomainsOperatorConfigSpec {
+	verifyDelegation := c.VerifyDelegation
+	forbidPublicSuffixes, forbidClusterBaseDomain := c.DomainPolicy.ForbidPublicSuffixes, c.DomainPolicy.ForbidClusterBaseDomain
+	return &customdomainv1alpha1.CustomDomainsOperatorConfigSpec{
+		RestrictedIngressNames:      append([]string{}, c.RestrictedIngressNames...),
+		RequeueWait:                 &metav1.Duration{Duration: c.RequeueWait},
+		ELBIdleTimeout:              &metav1.Duration{Duration: c.ELBIdleTimeout},
+		EndpointHostLength:          int32(c.EndpointHostLength),
+		NodePlacement:               c.NodePlacement.DeepCopy(),
+		SyncPeriod:                  &metav1.Duration{Duration: c.SyncPeriod},
+		VerifyDomainDelegation:      &verifyDelegation,
+		DomainVerificationInterval:  &metav1.Duration{Duration: c.DomainVerificationInterval},
+		DomainOwnershipVerification: string(c.OwnershipVerification),
+		Quota:                       c.Quota.DeepCopy(),
+		ClaimPolicy:                 c.ClaimPolicy.DeepCopy(),
+		DomainPolicy: &customdomainv1alpha1.CustomDomainDomainPolicy{
+			AllowedDomainSuffixes:   append([]string{}, c.DomainPolicy.AllowedDomainSuffixes...),
+			ForbiddenDomains:        append([]string{}, c.DomainPolicy.ForbiddenDomains...),
+			ForbidPublicSuffixes:    &forbidPublicSuffixes,
+			ForbidClusterBaseDomain: &forbidClusterBaseDomain,
+		},
+		Paused: c.Paused,
+	}
+}
+
+// OperatorConfigFromSpec returns the configuration of a CustomDomainsOperatorConfigSpec applied over the defaults
+func OperatorConfigFromSpec(spec customdomainv1alpha1.CustomDomainsOperatorConfigSpec) (OperatorConfig, error) {
+	return operatorConfigFromSpec(spec, DefaultOperatorConfig())
+}
+
+// operatorConfigFromSpec applies a CustomDomainsOperatorConfigSpec over the defaults, and validates the result
+func operatorConfigFromSpec(spec customdomainv1alpha1.CustomDomainsOperatorConfigSpec, defaults OperatorConfig) (OperatorConfig, error) {
+	c := defaults.DeepCopy()
+	problems := []string{}
+
+	if spec.RestrictedIngressNames != nil {
+		c.RestrictedIngressNames = append([]string{}, spec.RestrictedIngressNames...)
+		// the default ingresscontroller is always reserved, a custom domain must never adopt or replace it
+		if !contains(c.RestrictedIngressNames, defaultIngressName) {
+			c.RestrictedIngressNames = append([]string{defaultIngressName}, c.RestrictedIngressNames...)
+		}
+	}
+	for _, name := range c.RestrictedIngressNames {
+		if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+			problems = append(problems, fmt.Sprintf("restrictedIngressNames: %s is not a valid ingresscontroller name", name))
+		}
+	}
+	if spec.RequeueWait != nil {
+		c.RequeueWait = spec.RequeueWait.Duration
+	}
+	if c.RequeueWait <= 0 {
+		problems = append(problems, "requeueWait must be positive")
+	}
+	if spec.ELBIdleTimeout != nil {
+		c.ELBIdleTimeout = spec.ELBIdleTimeout.Duration
+	}
+	if c.ELBIdleTimeout < time.Second || c.ELBIdleTimeout > maxELBIdleTimeout {
+		problems = append(problems, fmt.Sprintf("elbIdleTimeout must be between 1s and %s", maxELBIdleTimeout))
+	}
+	if spec.EndpointHostLength != 0 {
+		c.EndpointHostLength = int(spec.EndpointHostLength)
+	}
+	if c.EndpointHostLength < 1 || c.EndpointHostLength > maxEndpointHostLength {
+		problems = append(problems, fmt.Sprintf("endpointHostLength must be between 1 and %d", maxEndpointHostLength))
+	}
+	if spec.NodePlacement != nil {
+		c.NodePlacement = spec.NodePlacement.DeepCopy()
+		if c.NodePlacement.NodeSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(c.NodePlacement.NodeSelector); err != nil {
+				problems = append(problems, fmt.Sprintf("nodePlacement.nodeSelector: %v", err))
+			}
+		}
+	}
+	if spec.SyncPeriod != nil {
+		c.SyncPeriod = spec.SyncPeriod.Duration
+	}
+	if c.SyncPeriod <= 0 {
+		problems = append(problems, "syncPeriod must be positive")
+	}
+	if spec.VerifyDomainDelegation != nil {
+		c.VerifyDelegation = *spec.VerifyDomainDelegation
+	}
+	if spec.DomainVerificationInterval != nil {
+		c.DomainVerificationInterval = spec.DomainVerificationInterval.Duration
+	}
+	if c.DomainVerificationInterval <= 0 {
+		problems = append(problems, "domainVerificationInterval must be positive")
+	}
+	if spec.DomainOwnershipVerification != "" {
+		c.OwnershipVerification = OwnershipVerificationMode(spec.DomainOwnershipVerification)
+	}
+	switch c.OwnershipVerification {
+	case OwnershipVerificationDisabled, OwnershipVerificationWarn, OwnershipVerificationBlock:
+	default:
+		problems = append(problems, fmt.Sprintf("domainOwnershipVerification %s must be one of disabled, warn or block", c.OwnershipVerification))
+	}
+
+	if spec.Quota != nil {
+		c.Quota = *spec.Quota.DeepCopy()
+	}
+	if quotaLimitNegative(c.Quota.MaxCustomDomains) || quotaLimitNegative(c.Quota.MaxExternal) || quotaLimitNegative(c.Quota.MaxInternal) {
+		problems = append(problems, "quota limits must not be negative")
+	}
+	if spec.ClaimPolicy != nil {
+		c.ClaimPolicy = spec.ClaimPolicy.DeepCopy()
+		for _, suffix := range c.ClaimPolicy.AllowedDomainSuffixes {
+			if errs := validation.IsDNS1123Subdomain(suffix); len(errs) > 0 {
+				problems = append(problems, fmt.Sprintf("claimPolicy.allowedDomainSuffixes: %s is not a valid domain", suffix))
+			}
+		}
+		if quotaLimitNegative(c.ClaimPolicy.MaxClaimsPerNamespace) {
+			problems = append(problems, "claimPolicy.maxClaimsPerNamespace must not be negative")
+		}
+		if c.ClaimPolicy.NamespaceSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(c.ClaimPolicy.NamespaceSelector); err != nil {
+				problems = append(problems, fmt.Sprintf("claimPolicy.namespaceSelector: %v", err))
+			}
+		}
+	}
+	c.Paused = spec.Paused
+	if spec.DomainPolicy != nil {
+		c.DomainPolicy.AllowedDomainSuffixes = append([]string{}, spec.DomainPolicy.AllowedDomainSuffixes...)
+		c.DomainPolicy.ForbiddenDomains = append([]string{}, spec.DomainPolicy.ForbiddenDomains...)
+		if spec.DomainPolicy.ForbidPublicSuffixes != nil {
+			c.DomainPolicy.ForbidPublicSuffixes = *spec.DomainPolicy.ForbidPublicSuffixes
+		}
+		if spec.DomainPolicy.ForbidClusterBaseDomain != nil {
+			c.DomainPolicy.ForbidClusterBaseDomain = *spec.DomainPolicy.ForbidClusterBaseDomain
+		}
+	}
+	for _, suffix := range c.DomainPolicy.AllowedDomainSuffixes {
+		if errs := validation.IsDNS1123Subdomain(suffix); len(errs) > 0 {
+			problems = append(problems, fmt.Sprintf("domainPolicy.allowedDomainSuffixes: %s is not a valid domain", suffix))
+		}
+	}
+	for _, pattern := range c.DomainPolicy.ForbiddenDomains {
+		if !validDomainPattern(pattern) {
+			problems = append(problems, fmt.Sprintf("domainPolicy.forbiddenDomains: %s is not a valid domain pattern", pattern))
+		}
+	}
+
+	if len(problems) > 0 {
+		return defaults, errors.New(strings.Join(problems, "; "))
+	}
+	return c, nil
+}
+
+// OperatorConfigStore holds the configuration in effect, which changes when the CustomDomainsOperatorConfig is updated
+type OperatorConfigStore struct {
+	mu       sync.RWMutex
+	defaults OperatorConfig
+	current  OperatorConfig
+	// syncPeriod is the sync period the manager was started with
+	syncPeriod time.Duration
+}
+
+// NewOperatorConfigStore returns a store holding the defaults until the CustomDomainsOperatorConfig is read
+func NewOperatorConfigStore(defaults OperatorConfig) *OperatorConfigStore {
+	return &OperatorConfigStore{defaults: defaults.DeepCopy(), current: defaults.DeepCopy(), syncPeriod: defaults.SyncPeriod}
+}
+
+// SyncPeriod returns the sync period read when the operator started
+func (s *OperatorConfigStore) SyncPeriod() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.syncPeriod
+}
+
+// Get returns a copy of the configuration in effect
+func (s *OperatorConfigStore) Get() OperatorConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current.DeepCopy()
+}
+
+// Defaults returns a copy of the configuration used when no CustomDomainsOperatorConfig exists
+func (s *OperatorConfigStore) Defaults() OperatorConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.defaults.DeepCopy()
+}
+
+func (s *OperatorConfigStore) set(c OperatorConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = c.DeepCopy()
+}
+
+// LoadOperatorConfig reads the CustomDomainsOperatorConfig once, so that the settings only read at startup, such as
+// the sync period, are known before the manager starts
+func (s *OperatorConfigStore) LoadOperatorConfig(ctx context.Context, reader client.Reader) error {
+	operatorConfig := &customdomainv1alpha1.CustomDomainsOperatorConfig{}
+	err := reader.Get(ctx, client.ObjectKey{Name: customdomainv1alpha1.CustomDomainsOperatorConfigName}, operatorConfig)
+	if err != nil {
+		if kerr.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	c, err := operatorConfigFromSpec(operatorConfig.Spec, s.Defaults())
+	if err != nil {
+		return err
+	}
+	s.set(c)
+	s.mu.Lock()
+	s.syncPeriod = c.SyncPeriod
+	s.mu.Unlock()
+	return nil
+}
+
+// operatorConfigOrDefault returns the configuration in effect, or the defaults if there is no store
+func operatorConfigOrDefault(s *OperatorConfigStore) OperatorConfig {
+	if s == nil {
+		return DefaultOperatorConfig()
+	}
+	return s.Get()
+}
+
+// config returns the operator configuration in effect
+func (r *CustomDomainReconciler) config() OperatorConfig {
+	return operatorConfigOrDefault(r.Config)
+}
+
+// OperatorConfigReconciler applies the CustomDomainsOperatorConfig to the OperatorConfigStore, and reports the
+// configuration in effect in its status
+type OperatorConfigReconciler struct {
+	Client client.Client
+	Store  *OperatorConfigStore
+	// Changes enqueues every CustomDomain when the configuration changes, so they are reconciled with it
+	Changes *ConfigChangeSource
+}
+
+// ConfigChangeSource is the source the CustomDomain controller watches to reconcile the CustomDomains when the operator
+// configuration changes. It adds them straight to the workqueue of the controller, which is unbounded, so the
+// OperatorConfigReconciler never waits for the CustomDomain controller.
+type ConfigChangeSource struct {
+	mu    sync.Mutex
+	queue workqueue.RateLimitingInterface
+}
+
+// Start keeps the workqueue of the CustomDomain controller
+func (s *ConfigChangeSource) Start(_ context.Context, _ handler.EventHandler, queue workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = queue
+	return nil
+}
+
+// enqueue adds a CustomDomain to the workqueue. It does nothing until the CustomDomain controller starts, which
+// reconciles every CustomDomain with the configuration in effect when it does.
+func (s *ConfigChangeSource) enqueue(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queue != nil {
+		s.queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+}
+
+//+kubebuilder:rbac:groups=managed.openshift.io,resources=customdomainsoperatorconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=managed.openshift.io,resources=customdomainsoperatorconfigs/status,verbs=get;update;patch
+
+// Reconcile reads the CustomDomainsOperatorConfig and applies it if it is valid
+func (r *OperatorConfigReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("OperatorConfig.Name", request.Name)
+	if request.Name != customdomainv1alpha1.CustomDomainsOperatorConfigName {
+		reqLogger.Info(fmt.Sprintf("Ignoring CustomDomainsOperatorConfig %s, only %s is read", request.Name, customdomainv1alpha1.CustomDomainsOperatorConfigName))
+		return reconcile.Result{}, nil
+	}
+
+	previous := r.Store.Get()
+	operatorConfig := &customdomainv1alpha1.CustomDomainsOperatorConfig{}
+	err := r.Client.Get(ctx, request.NamespacedName, operatorConfig)
+	if err != nil {
+		if !kerr.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		reqLogger.Info("CustomDomainsOperatorConfig not found, using the default configuration")
+		c := r.Store.Defaults()
+		c.SyncPeriod = r.Store.SyncPeriod()
+		r.apply(ctx, previous, c)
+		return reconcile.Result{}, nil
+	}
+
+	c, err := operatorConfigFromSpec(operatorConfig.Spec, r.Store.Defaults())
+	condition := metav1.Condition{
+		Type:               customdomainv1alpha1.CustomDomainsOperatorConfigConditionValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Applied",
+		Message:            "The configuration is applied",
+		ObservedGeneration: operatorConfig.Generation,
+	}
+	if err != nil {
+		reqLogger.Info(fmt.Sprintf("Invalid CustomDomainsOperatorConfig, keeping the previous configuration: %v", err))
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Invalid"
+		condition.Message = fmt.Sprintf("The configuration is invalid, the previous configuration stays in effect: %v", err)
+		c = previous
+	} else {
+		// The sync period of the manager can't change while it runs
+		if syncPeriod := r.Store.SyncPeriod(); c.SyncPeriod != syncPeriod {
+			condition.Message += fmt.Sprintf(". The sync period of %s takes effect when the operator restarts", c.SyncPeriod)
+			c.SyncPeriod = syncPeriod
+		}
+		r.apply(ctx, previous, c)
+	}
+
+	operatorConfig.Status.ObservedGeneration = operatorConfig.Generation
+	operatorConfig.Status.Effective = c.Spec()
+	meta.SetStatusCondition(&operatorConfig.Status.Conditions, condition)
+	return reconcile.Result{}, r.Client.Status().Update(ctx, operatorConfig)
+}
+
+// apply stores the configuration, and reconciles every CustomDomain if it changed
+func (r *OperatorConfigReconciler) apply(ctx context.Context, previous, c OperatorConfig) {
+	r.Store.set(c)
+	if r.Changes == nil || equality.Semantic.DeepEqual(previous, c) {
+		return
+	}
+	log.Info("Operator configuration changed, reconciling all CustomDomains")
+	customDomains := &customdomainv1alpha1.CustomDomainList{}
+	err := r.Client.List(ctx, customDomains)
+	if err != nil {
+		log.Error(err, "Failed to list CustomDomains")
+		return
+	}
+	for i := range customDomains.Items {
+		r.Changes.enqueue(customDomains.Items[i].Name)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *OperatorConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&customdomainv1alpha1.CustomDomainsOperatorConfig{}).
+		Complete(r)
+}
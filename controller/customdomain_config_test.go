@@ -0,0 +1,231 @@
+package managed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// newTestOperatorConfigStore returns a store holding the default configuration with changes applied
+func newTestOperatorConfigStore(change func(*OperatorConfig)) *OperatorConfigStore {
+	c := DefaultOperatorConfig()
+	change(&c)
+	return NewOperatorConfigStore(c)
+}
+
+func TestOperatorConfigFromSpec(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      customdomainv1alpha1.CustomDomainsOperatorConfigSpec
+		expectErr bool
+		check     func(t *testing.T, c OperatorConfig)
+	}{
+		{
+			name: "empty spec keeps the defaults",
+			check: func(t *testing.T, c OperatorConfig) {
+				if c.RequeueWait != time.Minute || c.ELBIdleTimeout != 1800*time.Second || c.EndpointHostLength != 6 || c.SyncPeriod != 15*time.Minute ||
+					c.VerifyDelegation || c.DomainVerificationInterval != 5*time.Minute {
+					t.Errorf("unexpected configuration %+v", c)
+				}
+			},
+		},
+		{
+			name: "overrides",
+			spec: customdomainv1alpha1.CustomDomainsOperatorConfigSpec{
+				RestrictedIngressNames:      []string{"reserved"},
+				RequeueWait:                 &metav1.Duration{Duration: 30 * time.Second},
+				ELBIdleTimeout:              &metav1.Duration{Duration: time.Minute},
+				EndpointHostLength:          10,
+				DomainOwnershipVerification: string(OwnershipVerificationWarn),
+				DomainVerificationInterval:  &metav1.Duration{Duration: time.Minute},
+			},
+			check: func(t *testing.T, c OperatorConfig) {
+				if len(c.RestrictedIngressNames) != 2 || !contains(c.RestrictedIngressNames, "default") || c.RequeueWait != 30*time.Second || c.ELBIdleTimeout != time.Minute ||
+					c.EndpointHostLength != 10 || c.OwnershipVerification != OwnershipVerificationWarn || c.DomainVerificationInterval != time.Minute {
+					t.Errorf("unexpected configuration %+v", c)
+				}
+			},
+		},
+		{
+			name:      "invalid restricted name",
+			spec:      customdomainv1alpha1.CustomDomainsOperatorConfigSpec{RestrictedIngressNames: []string{"Not_Valid"}},
+			expectErr: true,
+		},
+		{
+			name:      "elb idle timeout too long",
+			spec:      customdomainv1alpha1.CustomDomainsOperatorConfigSpec{ELBIdleTimeout: &metav1.Duration{Duration: 2 * time.Hour}},
+			expectErr: true,
+		},
+		{
+			name:      "endpoint host too long",
+			spec:      customdomainv1alpha1.CustomDomainsOperatorConfigSpec{EndpointHostLength: 40},
+			expectErr: true,
+		},
+		{
+			name: "invalid claim policy domain suffix",
+			spec: customdomainv1alpha1.CustomDomainsOperatorConfigSpec{ClaimPolicy: &customdomainv1alpha1.CustomDomainClaimPolicy{
+				AllowedDomainSuffixes: []string{"not a domain"},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "invalid forbidden domain pattern",
+			spec: customdomainv1alpha1.CustomDomainsOperatorConfigSpec{DomainPolicy: &customdomainv1alpha1.CustomDomainDomainPolicy{
+				ForbiddenDomains: []string{"apps.*x.example.com"},
+			}},
+			expectErr: true,
+		},
+		{
+			name:      "invalid ownership verification",
+			spec:      customdomainv1alpha1.CustomDomainsOperatorConfigSpec{DomainOwnershipVerification: "sometimes"},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := operatorConfigFromSpec(tt.spec, DefaultOperatorConfig())
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got (%v)", tt.expectErr, err)
+			}
+			if tt.check != nil {
+				tt.check(t, c)
+			}
+		})
+	}
+}
+
+// TestOperatorConfigReconciler tests that changes to the CustomDomainsOperatorConfig are applied and reported
+func TestOperatorConfigReconciler(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain  = "cluster1.x8s0.s1.openshiftapps.com"
+		userNamespace  = "my-project"
+		userSecretName = "my-secret"
+	)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: customdomainv1alpha1.CustomDomainsOperatorConfigName}}
+
+	operatorConfig := &customdomainv1alpha1.CustomDomainsOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       customdomainv1alpha1.CustomDomainsOperatorConfigName,
+			Generation: 1,
+		},
+		Spec: customdomainv1alpha1.CustomDomainsOperatorConfigSpec{
+			RestrictedIngressNames: []string{"reserved"},
+			NodePlacement: &operatorv1.NodePlacement{
+				NodeSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"node-role.kubernetes.io/worker": ""},
+				},
+			},
+			SyncPeriod: &metav1.Duration{Duration: time.Hour},
+		},
+	}
+	customdomain := &customdomainv1alpha1.CustomDomain{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "reserved",
+		},
+		Spec: customdomainv1alpha1.CustomDomainSpec{
+			Domain: "apps.foo.com",
+			Certificate: corev1.SecretReference{
+				Name:      userSecretName,
+				Namespace: userNamespace,
+			},
+		},
+	}
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, newTestSecret(userSecretName, userNamespace), operatorConfig, customdomain)
+	cl := NewTestMock(t, objs...)
+
+	store := NewOperatorConfigStore(DefaultOperatorConfig())
+	changes := &ConfigChangeSource{}
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+	if err := changes.Start(context.TODO(), nil, queue); err != nil {
+		t.Fatalf("start config changes: (%v)", err)
+	}
+	r := &OperatorConfigReconciler{Client: cl, Store: store, Changes: changes}
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	c := store.Get()
+	if len(c.RestrictedIngressNames) != 2 || !contains(c.RestrictedIngressNames, "reserved") || !contains(c.RestrictedIngressNames, "default") {
+		t.Errorf("restricted names were not applied: %v", c.RestrictedIngressNames)
+	}
+	if c.SyncPeriod != 15*time.Minute {
+		t.Errorf("sync period changed while running: %s", c.SyncPeriod)
+	}
+	if queue.Len() != 1 {
+		t.Errorf("expected the CustomDomain to be reconciled, got %d requests", queue.Len())
+	}
+	if err := cl.Get(context.TODO(), req.NamespacedName, operatorConfig); err != nil {
+		t.Fatalf("get operator config: (%v)", err)
+	}
+	if operatorConfig.Status.ObservedGeneration != 1 || operatorConfig.Status.Effective == nil || operatorConfig.Status.Effective.EndpointHostLength != 6 {
+		t.Errorf("unexpected status %+v", operatorConfig.Status)
+	}
+	if !meta.IsStatusConditionTrue(operatorConfig.Status.Conditions, customdomainv1alpha1.CustomDomainsOperatorConfigConditionValid) {
+		t.Errorf("expected the configuration to be valid, got %+v", operatorConfig.Status.Conditions)
+	}
+
+	// the CustomDomain reconciler reads the new configuration
+	cdr := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Config: store}
+	if _, err := cdr.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "reserved"}}); err == nil {
+		t.Error("expected a CustomDomain with a restricted name to fail")
+	}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "reserved"}, customdomain); err != nil {
+		t.Fatalf("get custom domain: (%v)", err)
+	}
+	if cond := FindCustomDomainCondition(customdomain.Status.Conditions, customdomainv1alpha1.CustomDomainConditionInvalidName); cond == nil {
+		t.Errorf("expected InvalidName condition, got %+v", customdomain.Status.Conditions)
+	}
+	ingress, err := cdr.newIngressController(*customdomain, "test", "test."+clusterDomain, "External", operatorv1.LoadBalancerServiceStrategyType, "test")
+	if err != nil {
+		t.Fatalf("new ingresscontroller: (%v)", err)
+	}
+	if _, ok := ingress.Spec.NodePlacement.NodeSelector.MatchLabels["node-role.kubernetes.io/worker"]; !ok || len(ingress.Spec.NodePlacement.Tolerations) != 0 {
+		t.Errorf("node placement was not applied: %+v", ingress.Spec.NodePlacement)
+	}
+
+	// an invalid configuration is reported, and the previous one stays in effect
+	operatorConfig.Spec.EndpointHostLength = 40
+	operatorConfig.Generation = 2
+	if err := cl.Update(context.TODO(), operatorConfig); err != nil {
+		t.Fatalf("update operator config: (%v)", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if store.Get().EndpointHostLength != 6 || !contains(store.Get().RestrictedIngressNames, "reserved") {
+		t.Errorf("invalid configuration was applied: %+v", store.Get())
+	}
+	if err := cl.Get(context.TODO(), req.NamespacedName, operatorConfig); err != nil {
+		t.Fatalf("get operator config: (%v)", err)
+	}
+	if meta.IsStatusConditionTrue(operatorConfig.Status.Conditions, customdomainv1alpha1.CustomDomainsOperatorConfigConditionValid) {
+		t.Errorf("expected the configuration to be invalid, got %+v", operatorConfig.Status.Conditions)
+	}
+
+	// deleting the configuration restores the defaults
+	if err := cl.Delete(context.TODO(), operatorConfig); err != nil {
+		t.Fatalf("delete operator config: (%v)", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if len(store.Get().RestrictedIngressNames) != 3 {
+		t.Errorf("defaults were not restored: %+v", store.Get())
+	}
+}
@@ -0,0 +1,155 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// customDomainDomainIndex indexes CustomDomains by their custom domain
+	customDomainDomainIndex = "spec.domain"
+
+	// customDomainDomainSuffixesIndex indexes CustomDomains by their custom domain and each of its parent domains, to
+	// find the CustomDomains under a domain
+	customDomainDomainSuffixesIndex = "spec.domain.suffixes"
+)
+
+// parentDomains returns the parent domains of a domain, e.g. "example.com" and "com" for "api.example.com"
+func parentDomains(domain string) []string {
+	parents := []string{}
+	labels := strings.Split(normalizeDomain(domain), ".")
+	for i := 1; i < len(labels); i++ {
+		parents = append(parents, strings.Join(labels[i:], "."))
+	}
+	return parents
+}
+
+// indexCustomDomainDomain is the indexer of customDomainDomainIndex
+func indexCustomDomainDomain(obj client.Object) []string {
+	instance, ok := obj.(*customdomainv1alpha1.CustomDomain)
+	if !ok || instance.Spec.Domain == "" {
+		return nil
+	}
+	return []string{normalizeDomain(instance.Spec.Domain)}
+}
+
+// indexCustomDomainDomainSuffixes is the indexer of customDomainDomainSuffixesIndex
+func indexCustomDomainDomainSuffixes(obj client.Object) []string {
+	instance, ok := obj.(*customdomainv1alpha1.CustomDomain)
+	if !ok || instance.Spec.Domain == "" {
+		return nil
+	}
+	return append([]string{normalizeDomain(instance.Spec.Domain)}, parentDomains(instance.Spec.Domain)...)
+}
+
+// setupCustomDomainIndexes registers the indexes of CustomDomains and routes by domain with the Manager
+func setupCustomDomainIndexes(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &customdomainv1alpha1.CustomDomain{}, customDomainDomainIndex, indexCustomDomainDomain)
+	if err != nil {
+		return err
+	}
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &customdomainv1alpha1.CustomDomain{}, customDomainDomainSuffixesIndex, indexCustomDomainDomainSuffixes)
+	if err != nil {
+		return err
+	}
+	return mgr.GetFieldIndexer().IndexField(context.Background(), &routev1.Route{}, routeHostDomainsIndex, indexRouteHostDomains)
+}
+
+// domainsOverlap returns true if the domains are equal or one is a subdomain of the other, so the wildcard of one
+// ingresscontroller covers the routes of the other
+func domainsOverlap(a, b string) bool {
+	return domainHasAllowedSuffix(a, []string{b}) || domainHasAllowedSuffix(b, []string{a})
+}
+
+// createdBefore returns true if the first CustomDomain is older than the second one. CustomDomains created at the same
+// time are ordered by name.
+func createdBefore(a, b *customdomainv1alpha1.CustomDomain) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// overlappingCustomDomains returns the other CustomDomains whose domain overlaps with the CustomDomain, from the oldest
+// to the most recent. CustomDomains being deleted are left out.
+func overlappingCustomDomains(customDomains []customdomainv1alpha1.CustomDomain, instance *customdomainv1alpha1.CustomDomain) []customdomainv1alpha1.CustomDomain {
+	seen := map[string]bool{}
+	overlapping := []customdomainv1alpha1.CustomDomain{}
+	for _, other := range customDomains {
+		if other.Name == instance.Name || seen[other.Name] || other.DeletionTimestamp != nil {
+			continue
+		}
+		seen[other.Name] = true
+		if domainsOverlap(other.Spec.Domain, instance.Spec.Domain) {
+			overlapping = append(overlapping, other)
+		}
+	}
+	sort.Slice(overlapping, func(i, j int) bool {
+		return createdBefore(&overlapping[i], &overlapping[j])
+	})
+	return overlapping
+}
+
+// domainConflictMessage describes the conflict of a custom domain with another CustomDomain
+func domainConflictMessage(instance, other *customdomainv1alpha1.CustomDomain) string {
+	if normalizeDomain(instance.Spec.Domain) == normalizeDomain(other.Spec.Domain) {
+		return fmt.Sprintf("custom domain %s is already used by CustomDomain %s", instance.Spec.Domain, other.Name)
+	}
+	return fmt.Sprintf("custom domain %s overlaps with custom domain %s of CustomDomain %s", instance.Spec.Domain, other.Spec.Domain, other.Name)
+}
+
+// listOverlappingCustomDomains uses the domain indexes to return the other CustomDomains whose domain overlaps with the
+// CustomDomain
+func (r *CustomDomainReconciler) listOverlappingCustomDomains(ctx context.Context, instance *customdomainv1alpha1.CustomDomain) ([]customdomainv1alpha1.CustomDomain, error) {
+	domain := normalizeDomain(instance.Spec.Domain)
+	// CustomDomains with the same domain or under it
+	customDomains := &customdomainv1alpha1.CustomDomainList{}
+	err := r.Client.List(ctx, customDomains, client.MatchingFields{customDomainDomainSuffixesIndex: domain})
+	if err != nil {
+		return nil, err
+	}
+	candidates := customDomains.Items
+	// CustomDomains whose domain is a parent of the domain
+	for _, parent := range parentDomains(domain) {
+		parentCustomDomains := &customdomainv1alpha1.CustomDomainList{}
+		err := r.Client.List(ctx, parentCustomDomains, client.MatchingFields{customDomainDomainIndex: parent})
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, parentCustomDomains.Items...)
+	}
+	return overlappingCustomDomains(candidates, instance), nil
+}
+
+// domainConflictProblem returns how the domain of the CustomDomain overlaps with the domain of an older CustomDomain,
+// or an empty string if it doesn't
+func (r *CustomDomainReconciler) domainConflictProblem(ctx context.Context, instance *customdomainv1alpha1.CustomDomain) (string, error) {
+	overlapping, err := r.listOverlappingCustomDomains(ctx, instance)
+	if err != nil {
+		return "", err
+	}
+	if len(overlapping) == 0 || !createdBefore(&overlapping[0], instance) {
+		return "", nil
+	}
+	return fmt.Sprintf("Domain conflict: %s", domainConflictMessage(instance, &overlapping[0])), nil
+}
+
+// checkDomainConflict returns true if the domain of the CustomDomain overlaps with the domain of an older
+// CustomDomain. The oldest CustomDomain keeps the domain, the others stop being reconciled and are reported with the
+// DomainConflict condition. Their existing resources are left untouched.
+func (r *CustomDomainReconciler) checkDomainConflict(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) (bool, error) {
+	problem, err := r.domainConflictProblem(context.TODO(), instance)
+	if err != nil {
+		return false, err
+	}
+	return r.reportBlocker(reqLogger, instance, customdomainv1alpha1.CustomDomainConditionDomainConflict, problem,
+		"The custom domain does not conflict with older CustomDomains")
+}
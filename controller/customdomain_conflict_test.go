@@ -0,0 +1,97 @@
+package managed
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// newConflictTestCustomDomain returns a CustomDomain with the given domain, created the given number of minutes ago
+func newConflictTestCustomDomain(name, domain string, age int) *customdomainv1alpha1.CustomDomain {
+	instance := newQuotaTestCustomDomain(name, "External", age)
+	instance.Spec.Domain = domain
+	return instance
+}
+
+// TestCustomDomainDomainConflict tests that the most recent of the CustomDomains with overlapping domains are reported
+func TestCustomDomainDomainConflict(t *testing.T) {
+	cl := NewTestMock(t,
+		newConflictTestCustomDomain("oldest", "example.com", 30),
+		newConflictTestCustomDomain("subdomain", "API.example.com.", 20),
+		newConflictTestCustomDomain("duplicate", "example.com", 10),
+		newConflictTestCustomDomain("unrelated", "myexample.com", 5),
+	)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+
+	tests := map[string]string{
+		"oldest":    "",
+		"subdomain": "CustomDomain oldest",
+		"duplicate": "already used by CustomDomain oldest",
+		"unrelated": "",
+	}
+	for name, expectConflict := range tests {
+		instance := &customdomainv1alpha1.CustomDomain{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: name}, instance); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		conflict, err := r.checkDomainConflict(log, instance)
+		if err != nil {
+			t.Fatalf("check domain conflict: (%v)", err)
+		}
+		if conflict != (expectConflict != "") {
+			t.Errorf("%s: expected conflict %v, got %v", name, expectConflict != "", conflict)
+			continue
+		}
+		cond := FindCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDomainConflict)
+		if conflict && (cond == nil || cond.Status != corev1.ConditionTrue || !strings.Contains(cond.Message, expectConflict)) {
+			t.Errorf("%s: expected DomainConflict condition naming %q, got %+v", name, expectConflict, cond)
+		}
+	}
+
+	// once the oldest CustomDomain is deleted, the next oldest keeps the domain
+	if err := cl.Delete(context.TODO(), &customdomainv1alpha1.CustomDomain{ObjectMeta: metav1.ObjectMeta{Name: "oldest"}}); err != nil {
+		t.Fatalf("delete custom domain: (%v)", err)
+	}
+	instance := &customdomainv1alpha1.CustomDomain{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "subdomain"}, instance); err != nil {
+		t.Fatalf("get custom domain: (%v)", err)
+	}
+	conflict, err := r.checkDomainConflict(log, instance)
+	if err != nil {
+		t.Fatalf("check domain conflict: (%v)", err)
+	}
+	cond := FindCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDomainConflict)
+	if conflict || cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Errorf("expected the conflict to be resolved, got %+v", cond)
+	}
+}
+
+// TestCustomDomainValidatorDomainConflict tests that duplicate or overlapping custom domains are denied
+func TestCustomDomainValidatorDomainConflict(t *testing.T) {
+	existing := newConflictTestCustomDomain("existing", "apps.example.com", 10)
+	cl := NewTestMock(t, existing)
+	v := &CustomDomainValidator{Reader: cl}
+
+	for domain, expectDenied := range map[string]bool{
+		"apps.example.com":      true,
+		"team.apps.example.com": true,
+		"example.com":           true,
+		"apps2.example.com":     false,
+	} {
+		_, err := v.ValidateCreate(context.TODO(), newConflictTestCustomDomain("new", domain, 0))
+		if expectDenied != (err != nil) {
+			t.Errorf("%s: expected denied %v, got (%v)", domain, expectDenied, err)
+		}
+	}
+
+	moved := existing.DeepCopy()
+	moved.Spec.Domain = "apps2.example.com"
+	if _, err := v.ValidateUpdate(context.TODO(), existing, moved); err != nil {
+		t.Errorf("expected a CustomDomain not to conflict with itself: (%v)", err)
+	}
+}
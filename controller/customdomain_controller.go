@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"reflect"
 	"regexp"
 	"time"
 
@@ -28,21 +27,21 @@ import (
 
 var log = logf.Log.WithName("controller_customdomain")
 
-// restrictedIngressNames contains an array of known managed ingresscontroller
-var restrictedIngressNames = []string{"default", "apps2", "apps"}
-
 // validObjectNames defines the format customdomains object names must adhere to. Derived from ingresscontroller objects, which require a DNS-1035 label
 var validObjectNames = regexp.MustCompile("^[a-z]([-a-z0-9]*[a-z0-9])?$")
 
 const (
-	ingressNamespace         = "openshift-ingress"
-	ingressOperatorNamespace = "openshift-ingress-operator"
-	dnsConfigName            = "cluster"
-	managedLabelName         = "customdomains.managed.openshift.io/managed"
-	requeueWaitMinutes       = 1
-	hostLength               = 6
-	ingressDefaultScope      = "External"
-	ELBIdleTimeoutDuration   = 1800
+	ingressNamespace                         = "openshift-ingress"
+	ingressOperatorNamespace                 = "openshift-ingress-operator"
+	dnsConfigName                            = "cluster"
+	managedLabelName                         = "customdomains.managed.openshift.io/managed"
+	customDomainsAnnotationName              = "customdomains.managed.openshift.io/customdomains"
+	deletionPolicyAnnotationName             = "customdomains.managed.openshift.io/deletion-policy"
+	requeueWaitMinutes                       = 1
+	hostLength                               = 6
+	ingressDefaultScope                      = "External"
+	ingressDefaultEndpointPublishingStrategy = operatorv1.LoadBalancerServiceStrategyType
+	ELBIdleTimeoutDuration                   = 1800
 )
 
 var IngressControllerELBIdleTimeout metav1.Duration = metav1.Duration{Duration: ELBIdleTimeoutDuration * time.Second}
@@ -53,6 +52,16 @@ type CustomDomainReconciler struct {
 	// that reads objects from the cache and writes to the apiserver
 	Client client.Client
 	Scheme *runtime.Scheme
+	// APIReader reads objects that are not cached, such as routes, directly from the apiserver. Defaults to Client.
+	APIReader client.Reader
+	// Resolver looks up the DNS records of custom domains. Defaults to the system resolver.
+	Resolver DNSResolver
+	// Config holds the operator configuration read from the CustomDomainsOperatorConfig. Defaults to DefaultOperatorConfig.
+	Config *OperatorConfigStore
+	// ConfigChanges enqueues the CustomDomains to reconcile when the operator configuration changes
+	ConfigChanges *ConfigChangeSource
+	// DNSProviderFactory builds the DNS providers publishing the CNAME records of custom domains. Defaults to NewDNSProvider.
+	DNSProviderFactory DNSProviderFactory
 }
 
 const customDomainFinalizer = "finalizer.customdomain.managed.openshift.io"
@@ -84,6 +93,29 @@ func (r *CustomDomainReconciler) Reconcile(ctx context.Context, request ctrl.Req
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
+
+	// Only publish the plan of the changes when it is asked for, CustomDomains being deleted are finalized as usual
+	if isPlanRequested(instance) && instance.GetDeletionTimestamp() == nil {
+		return reconcile.Result{}, r.publishPlan(reqLogger, instance)
+	}
+
+	// Leave paused CustomDomains untouched, they are reconciled again when the annotation or configuration changes.
+	// CustomDomains being deleted are finalized even when paused.
+	if instance.GetDeletionTimestamp() == nil {
+		paused, err := r.checkPaused(reqLogger, instance)
+		if err != nil || paused {
+			return reconcile.Result{}, err
+		}
+	}
+
+	// The plan is stale once the CustomDomain is reconciled
+	if instance.Status.Plan != nil {
+		instance.Status.Plan = nil
+		if err := r.statusUpdate(reqLogger, instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
 	clusterVersion, err := r.GetClusterVersion(r.Client)
 	if err != nil {
 		return reconcile.Result{}, err
@@ -130,10 +162,10 @@ func (r *CustomDomainReconciler) Reconcile(ctx context.Context, request ctrl.Req
 		}
 	}
 
-	// Check that the instance name does not clash with known managed names
-	if contains(restrictedIngressNames, instance.Name) {
+	// Check that the instance name is a valid ingresscontroller name that does not clash with known managed names
+	if problem := CustomDomainNameProblem(r.config(), instance.Name); problem != "" {
 		errStr := fmt.Sprintf("Invalid CR name (%s)", instance.Name)
-		reqLogger.Info(fmt.Sprintf("Instance name (%s) clashes with known names (%v)!", instance.Name, restrictedIngressNames))
+		reqLogger.Info(fmt.Sprintf("Invalid instance name: %s", problem))
 		SetCustomDomainStatus(
 			reqLogger,
 			instance,
@@ -144,18 +176,32 @@ func (r *CustomDomainReconciler) Reconcile(ctx context.Context, request ctrl.Req
 		return reconcile.Result{}, errors.New(errStr)
 	}
 
-	// Check that the instance name is valid
-	if !validObjectNames.Match([]byte(instance.Name)) {
-		errStr := fmt.Sprintf("Invalid CR name (%s)", instance.Name)
-		reqLogger.Info(fmt.Sprintf("Instance name (%s) does not conform to DNS guidelines: a DNS-1035 label must consist of lower case alphanumeric characters or '-', start with an alphabetic character, and end with an alphanumeric character (e.g. 'my-name',  or 'abc-123', regex used for validation is '%s')", instance.Name, validObjectNames.String()))
-		SetCustomDomainStatus(
-			reqLogger,
-			instance,
-			errStr,
-			customdomainv1alpha1.CustomDomainConditionInvalidName,
-			customdomainv1alpha1.CustomDomainStateNotReady)
-		_ = r.statusUpdate(reqLogger, instance)
-		return reconcile.Result{}, errors.New(errStr)
+	// Check that the domain policy allows the custom domain, CustomDomains out of policy are checked again as it changes
+	notAllowed, err := r.checkDomainPolicy(reqLogger, instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if notAllowed {
+		return reconcile.Result{Requeue: true, RequeueAfter: r.config().RequeueWait}, nil
+	}
+
+	// Check that the custom domain doesn't conflict with an older CustomDomain, conflicting CustomDomains are checked
+	// again as the older ones are deleted
+	conflict, err := r.checkDomainConflict(reqLogger, instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if conflict {
+		return reconcile.Result{Requeue: true, RequeueAfter: r.config().RequeueWait}, nil
+	}
+
+	// Check that the CustomDomain fits in the quota, CustomDomains over it are checked again as others are deleted
+	exceeded, err := r.checkQuota(reqLogger, instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if exceeded {
+		return reconcile.Result{Requeue: true, RequeueAfter: r.config().RequeueWait}, nil
 	}
 
 	if instance.Status.State != customdomainv1alpha1.CustomDomainStateReady {
@@ -172,6 +218,15 @@ func (r *CustomDomainReconciler) Reconcile(ctx context.Context, request ctrl.Req
 		}
 	}
 
+	// check that the custom domain is owned by whoever created the CustomDomain
+	result, err := r.verifyOwnership(reqLogger, instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if result != nil {
+		return *result, nil
+	}
+
 	// look up secret
 	userSecret := &corev1.Secret{}
 	err = r.Client.Get(context.TODO(), types.NamespacedName{
@@ -191,229 +246,389 @@ func (r *CustomDomainReconciler) Reconcile(ctx context.Context, request ctrl.Req
 		return reconcile.Result{}, err
 	}
 
-	// add the CustomDomain's label to the secret for future monitoring
-	_, labelFound := userSecret.Labels[managedLabelName]
-	if !labelFound {
+	// add the CustomDomain's label and annotation to the secret for future monitoring
+	if claimUserSecret(userSecret, instance.Name) {
 		reqLogger.Info(fmt.Sprintf("Adding label to the CustomDomain's secret (%s)", userSecret.Name))
-		secretLabels := userSecret.GetLabels()
-		if secretLabels == nil {
-			secretLabels = make(map[string]string)
-		}
-		secretLabels[managedLabelName] = instance.Name
-		userSecret.SetLabels(secretLabels)
 		err = r.Client.Update(context.TODO(), userSecret)
 		if err != nil {
 			reqLogger.Error(err, fmt.Sprintf("Error updating labels for secret (%s)", userSecret.Name))
 			return reconcile.Result{}, err
 		}
 	}
+	// release the secret the CustomDomain used before its certificate was changed
+	if err := r.releasePreviousUserSecrets(reqLogger, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// get the ingresscontroller serving the custom domain, under the base domain of dnses.config.openshift.io/cluster
+	ingress, err := r.desiredIngressFor(context.TODO(), instance)
+	if err != nil {
+		reqLogger.Error(err, fmt.Sprintf("Error getting dns.config/%s", dnsConfigName))
+		return reconcile.Result{}, err
+	}
 
-	// set the secret name to be the name of the customdomain instance
-	secretName := instance.Name
+	// Check that PROXY protocol is supported by the platform and endpoint publishing strategy
+	proxyProtocolIgnored := ""
+	if instance.Spec.ProxyProtocol {
+		proxyProtocolIgnored, err = r.validateProxyProtocol(*instance, ingress.strategy)
+		if err != nil {
+			reqLogger.Info(fmt.Sprintf("Invalid PROXY protocol configuration: %v", err))
+			SetCustomDomainStatus(
+				reqLogger,
+				instance,
+				err.Error(),
+				customdomainv1alpha1.CustomDomainConditionInvalidProxyProtocol,
+				customdomainv1alpha1.CustomDomainStateNotReady)
+			_ = r.statusUpdate(reqLogger, instance)
+			return reconcile.Result{}, err
+		}
+	}
+	if setProxyProtocolIgnored(instance, proxyProtocolIgnored) {
+		if err := r.statusUpdate(reqLogger, instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
 
-	// create secret in the openshift-ingress namespace
+	// decide how the ingresscontrollers.openshift.io is created, adopted or updated, before its certificate is written,
+	// so an ingresscontroller that is not adopted keeps serving its certificate
+	change, err := r.desiredIngressController(context.TODO(), instance, ingress, userSecret)
+	if err != nil {
+		reqLogger.Error(err, fmt.Sprintf("Error getting ingresscontroller %s in %s namespace", ingress.name, ingressOperatorNamespace))
+		return reconcile.Result{}, err
+	}
+	if change.blocker != "" {
+		reqLogger.Info(change.blocker)
+		SetCustomDomainStatus(
+			reqLogger,
+			instance,
+			change.blocker,
+			change.condition,
+			customdomainv1alpha1.CustomDomainStateNotReady)
+		_ = r.statusUpdate(reqLogger, instance)
+		return reconcile.Result{}, errors.New(change.blocker)
+	}
+	// create or update the secret in the openshift-ingress namespace, once the ingresscontroller serving it is known
+	desiredSecret := desiredIngressSecret(instance, userSecret)
 	ingressSecret := &corev1.Secret{}
 	err = r.Client.Get(context.TODO(), types.NamespacedName{
-		Namespace: ingressNamespace,
-		Name:      secretName,
+		Namespace: desiredSecret.Namespace,
+		Name:      desiredSecret.Name,
 	}, ingressSecret)
 	if err != nil {
 		if kerr.IsNotFound(err) {
-			ingressSecret.Name = secretName
-			ingressSecret.Namespace = ingressNamespace
-			ingressSecret.Labels = labelsForOwnedResources()
-			ingressSecret.Data = userSecret.Data
-			ingressSecret.Type = userSecret.Type
-			err = r.Client.Create(context.TODO(), ingressSecret)
+			err = r.Client.Create(context.TODO(), desiredSecret)
 			if err != nil {
-				reqLogger.Error(err, fmt.Sprintf("Error creating custom certificate secret %s", secretName))
+				reqLogger.Error(err, fmt.Sprintf("Error creating custom certificate secret %s", desiredSecret.Name))
 				return reconcile.Result{}, err
 			}
 		} else {
-			reqLogger.Error(err, fmt.Sprintf("Error getting custom certificate secret %s", secretName))
+			reqLogger.Error(err, fmt.Sprintf("Error getting custom certificate secret %s", desiredSecret.Name))
 			return reconcile.Result{}, err
 		}
 	} else {
-		certificateUpdated := !reflect.DeepEqual(ingressSecret, userSecret)
-		if certificateUpdated {
+		updatedSecret, problem := updatedIngressSecret(ingressSecret, desiredSecret, change.action)
+		if problem != "" {
+			reqLogger.Info(problem)
+			SetCustomDomainStatus(
+				reqLogger,
+				instance,
+				problem,
+				customdomainv1alpha1.CustomDomainConditionIngressControllerConflict,
+				customdomainv1alpha1.CustomDomainStateNotReady)
+			_ = r.statusUpdate(reqLogger, instance)
+			return reconcile.Result{}, errors.New(problem)
+		}
+		if updatedSecret != nil {
 			reqLogger.Info("Secret change detected, updating certificate.")
-			ingressSecret.Data = userSecret.Data
-			err = r.Client.Update(context.TODO(), ingressSecret)
+			err = r.Client.Update(context.TODO(), updatedSecret)
 			if err != nil {
-				reqLogger.Error(err, fmt.Sprintf("Error updating custom certificate secret %s", ingressSecret.Name))
+				reqLogger.Error(err, fmt.Sprintf("Error updating custom certificate secret %s", updatedSecret.Name))
 				return reconcile.Result{}, err
 			}
 		} else {
-			reqLogger.Info(fmt.Sprintf("Certificate secret %s already exists in the %s namespace", secretName, ingressNamespace))
+			reqLogger.Info(fmt.Sprintf("Certificate secret %s already exists in the %s namespace", desiredSecret.Name, ingressNamespace))
 		}
 	}
 
-	// get dnses.config.openshift.io/cluster for base domain
-	dnsConfig := &configv1.DNS{}
-	err = r.Client.Get(context.TODO(), types.NamespacedName{
-		Name: dnsConfigName,
-	}, dnsConfig)
-	if err != nil {
-		reqLogger.Error(err, fmt.Sprintf("Error getting dns.config/%s", dnsConfigName))
-		return reconcile.Result{}, err
-	}
-
-	// set the ingress domain to be a subdomain under the cluster's installed basedomain
-	// such that the record is added to the zone and external DNS can point to it
-	ingressDomain := fmt.Sprintf("%s.%s", instance.Name, dnsConfig.Spec.BaseDomain)
-	ingressName := instance.Name
-	ingressScope := instance.Spec.Scope
-	if ingressScope == "" {
-		ingressScope = ingressDefaultScope
-	}
-
-	// create new ingresscontrollers.openshift.io
-	customIngress := &operatorv1.IngressController{}
-	err = r.Client.Get(context.TODO(), types.NamespacedName{
-		Namespace: ingressOperatorNamespace,
-		Name:      ingressName,
-	}, customIngress)
-
-	if err != nil {
-		if kerr.IsNotFound(err) {
-			customIngress.Name = ingressName
-			customIngress.Namespace = ingressOperatorNamespace
-			customIngress.Labels = labelsForOwnedResources()
-			customIngress.Spec.Domain = ingressDomain
-			customIngress.Spec.EndpointPublishingStrategy = &operatorv1.EndpointPublishingStrategy{
-				Type: operatorv1.LoadBalancerServiceStrategyType,
-				LoadBalancer: &operatorv1.LoadBalancerStrategy{
-					Scope: operatorv1.LoadBalancerScope(ingressScope),
-					ProviderParameters: &operatorv1.ProviderLoadBalancerParameters{
-						Type: operatorv1.LoadBalancerProviderType(operatorv1.LoadBalancerServiceStrategyType),
-					},
-				},
-			}
-
-			cloudPlatform, err := GetPlatformType(r.Client)
-			if err != nil {
-				return reconcile.Result{}, err
-			}
-			isAWS := *cloudPlatform == "AWS"
-
-			if isAWS {
-				r.setAWSProviderParameters(*instance, customIngress)
-			} else if *cloudPlatform == "GCP" {
-				r.setGCPProviderParameters(*instance, customIngress)
-			}
-
-			customIngress.Spec.NodePlacement = &operatorv1.NodePlacement{
-				NodeSelector: &metav1.LabelSelector{
-					MatchLabels: map[string]string{"node-role.kubernetes.io/infra": ""},
-				},
-				Tolerations: []corev1.Toleration{
-					{
-						Key:      "node-role.kubernetes.io/infra",
-						Effect:   corev1.TaintEffectNoSchedule,
-						Operator: corev1.TolerationOpExists,
-					},
-				},
-			}
-			if instance.Spec.RouteSelector != nil {
-				customIngress.Spec.RouteSelector = instance.Spec.RouteSelector
-			}
-			if instance.Spec.NamespaceSelector != nil {
-				customIngress.Spec.NamespaceSelector = instance.Spec.NamespaceSelector
-			}
-			if customIngress.Spec.DefaultCertificate != nil {
-				customIngress.Spec.DefaultCertificate.Name = secretName
-			} else {
-				customIngress.Spec.DefaultCertificate = &corev1.LocalObjectReference{Name: secretName}
-			}
-			err = r.Client.Create(context.TODO(), customIngress)
+	switch change.action {
+	case ingressControllerCreate:
+		err = r.Client.Create(context.TODO(), change.desired)
+		if err != nil {
+			reqLogger.Error(err, fmt.Sprintf("Error creating ingresscontroller %s in %s namespace", ingress.name, ingressOperatorNamespace))
+			return reconcile.Result{}, err
+		}
+	case ingressControllerMigrateScope:
+		return r.migrateScope(reqLogger, instance, change.live, ingress.scope, ingress.strategy, ingress.baseDomain, instance.Name)
+	default:
+		// Clean up a scope migration that was completed or reverted
+		if instance.Status.ScopeMigration != nil {
+			err = r.finishScopeMigration(reqLogger, instance)
 			if err != nil {
-				reqLogger.Error(err, fmt.Sprintf("Error creating ingresscontroller %s in %s namespace", ingressName, ingressOperatorNamespace))
 				return reconcile.Result{}, err
 			}
+		}
+		if change.action == ingressControllerAdopt {
+			err = r.adoptIngressController(reqLogger, instance, change.desired)
 		} else {
-			reqLogger.Error(err, fmt.Sprintf("Error getting ingresscontroller %s in %s namespace", ingressName, ingressOperatorNamespace))
+			err = r.Client.Update(context.TODO(), change.desired)
+		}
+		if err != nil {
 			return reconcile.Result{}, err
 		}
-	} else {
-		// Validate ingress' EndpointPublishingStrategy
-		if customIngress.Spec.EndpointPublishingStrategy != nil {
-			// Validate the EndpointPublishingStrategy's LB configuration
-			if customIngress.Spec.EndpointPublishingStrategy.LoadBalancer != nil {
-				// Ensure scope has not been modified
-				if string(customIngress.Spec.EndpointPublishingStrategy.LoadBalancer.Scope) != ingressScope {
-					// TODO: Check for scope change when customIngress.Spec.EndpointPublishingStrategy is nil
-					errStr := fmt.Sprintf("Invalid update to ingress scope (detected change from %s to %s)", customIngress.Spec.EndpointPublishingStrategy.LoadBalancer.Scope, ingressScope)
-					reqLogger.Info(fmt.Sprintf("The 'scope' field is immutable: detected change from %s to %s. To register a domain with %s scope, a new CustomDomain object will need to be defined.", customIngress.Spec.EndpointPublishingStrategy.LoadBalancer.Scope, ingressScope, ingressScope))
-					SetCustomDomainStatus(
-						reqLogger,
-						instance,
-						errStr,
-						customdomainv1alpha1.CustomDomainConditionInvalidScope,
-						customdomainv1alpha1.CustomDomainStateNotReady)
-					_ = r.statusUpdate(reqLogger, instance)
-					return reconcile.Result{}, errors.New(errStr)
-				}
-				// Ensure the timeout is set correctly
-				platform, err := GetPlatformType(r.Client)
-				if err != nil {
-					return reconcile.Result{}, fmt.Errorf("failed to determine platform type: %w", err)
-				}
-				switch *platform {
-				case "AWS":
-					r.setAWSProviderParameters(*instance, customIngress)
-				case "GCP":
-					r.setGCPProviderParameters(*instance, customIngress)
-				default:
-					return reconcile.Result{}, fmt.Errorf("unknown platform: %s", *platform)
-				}
+		reqLogger.Info(fmt.Sprintf("Validated existing ingresscontroller (%s/%s)", change.desired.Namespace, change.desired.Name))
+	}
+
+	readyMessage := fmt.Sprintf("Custom Apps Domain (%s) Is Ready", instance.Spec.Domain)
+	if ingress.strategy == operatorv1.LoadBalancerServiceStrategyType {
+		// Obtain the dnsRecord to set in the CR status for final completion, requeue if not available
+		dnsRecord := &operatoringressv1.DNSRecord{}
+		dnsRecordName := fmt.Sprintf("%s-wildcard", ingress.name)
+		err = r.Client.Get(context.TODO(), types.NamespacedName{
+			Namespace: ingressOperatorNamespace,
+			Name:      dnsRecordName,
+		}, dnsRecord)
+		if err != nil {
+			if kerr.IsNotFound(err) {
+				// requeue and wait for record
+				return reconcile.Result{Requeue: true, RequeueAfter: r.config().RequeueWait}, nil
 			}
+			return reconcile.Result{}, err
 		}
-		err = r.Client.Update(context.TODO(), customIngress)
+
+		// Set the DNS record in the status from the actual DNS record created by ingress operator
+		reqLogger.Info(fmt.Sprintf("DNSRecord %s created with value %s", dnsRecordName, dnsRecord.Spec.DNSName))
+		instance.Status.DNSRecord = dnsRecord.Spec.DNSName
+	} else {
+		// The ingress operator only manages DNS for the LoadBalancerService strategy, so no DNSRecord will ever
+		// be created. Report the wildcard record that has to be published for the ingress domain instead.
+		reqLogger.Info(fmt.Sprintf("No DNSRecord is managed for the %s endpoint publishing strategy", ingress.strategy))
+		instance.Status.DNSRecord = fmt.Sprintf("*.%s", ingress.domain)
+		readyMessage = fmt.Sprintf("Custom Apps Domain (%s) Is Ready (DNS for %s is not managed with the %s endpoint publishing strategy)", instance.Spec.Domain, instance.Status.DNSRecord, ingress.strategy)
+	}
+
+	instance.Status.IngressController = ingress.name
+	if ingress.strategy == operatorv1.LoadBalancerServiceStrategyType {
+		instance.Status.Scope = ingress.scope
+	}
+
+	// endpoint is a resolvable dns address under the ingress domain, with a host that is set in the spec or derived from the CR name
+	if endpoint, changed := r.desiredEndpoint(instance, ingress.domain); changed {
+		err = r.validateEndpoint(instance, endpoint)
 		if err != nil {
-			return reconcile.Result{}, err
+			errStr := fmt.Sprintf("Invalid endpoint host: %v", err)
+			reqLogger.Info(errStr)
+			SetCustomDomainStatus(
+				reqLogger,
+				instance,
+				errStr,
+				customdomainv1alpha1.CustomDomainConditionInvalidEndpointHost,
+				customdomainv1alpha1.CustomDomainStateNotReady)
+			_ = r.statusUpdate(reqLogger, instance)
+			return reconcile.Result{}, errors.New(errStr)
 		}
-		reqLogger.Info(fmt.Sprintf("Validated existing ingresscontroller (%s/%s)", customIngress.Namespace, customIngress.Name))
+		reqLogger.Info(fmt.Sprintf("Setting endpoint to %s", endpoint))
+		instance.Status.Endpoint = endpoint
 	}
 
-	// Obtain the dnsRecord to set in the CR status for final completion, requeue if not available
-	dnsRecord := &operatoringressv1.DNSRecord{}
-	dnsRecordName := fmt.Sprintf("%s-wildcard", instance.Name)
-	err = r.Client.Get(context.TODO(), types.NamespacedName{
-		Namespace: ingressOperatorNamespace,
-		Name:      dnsRecordName,
-	}, dnsRecord)
+	// publish the CNAME record pointing the custom domain to the endpoint, if a DNS provider is configured
+	err = r.publishDNSRecord(reqLogger, instance)
 	if err != nil {
-		if kerr.IsNotFound(err) {
-			// requeue and wait for record
-			return reconcile.Result{Requeue: true, RequeueAfter: time.Duration(requeueWaitMinutes) * time.Minute}, nil
-		}
 		return reconcile.Result{}, err
 	}
 
-	// Set the DNS record in the status from the actual DNS record created by ingress operator
-	reqLogger.Info(fmt.Sprintf("DNSRecord %s created with value %s", dnsRecordName, dnsRecord.Spec.DNSName))
-	instance.Status.DNSRecord = dnsRecord.Spec.DNSName
-
-	// endpoint is a resolvable dns address w/ a random host under the ingress domain
-	if len(instance.Status.Endpoint) == 0 {
-		endpoint := fmt.Sprintf("%s.%s", randSeq(hostLength), ingressDomain)
-		instance.Status.Endpoint = endpoint
+	// check that the customer's CNAME record points the custom domain to the endpoint
+	delegated := true
+	if r.config().VerifyDelegation {
+		delegated = r.verifyDelegation(reqLogger, instance)
 	}
 
+	// report the routes under the custom domain that are not served by its ingresscontroller only
+	r.analyzeCustomDomainRoutes(reqLogger, instance)
+
 	// Update the status on CustomDomain
 	SetCustomDomainStatus(
 		reqLogger,
 		instance,
-		fmt.Sprintf("Custom Apps Domain (%s) Is Ready", instance.Spec.Domain),
+		readyMessage,
 		customdomainv1alpha1.CustomDomainConditionReady,
 		customdomainv1alpha1.CustomDomainStateReady)
 	err = r.statusUpdate(reqLogger, instance)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	if !delegated || r.isOwnershipPending(instance) {
+		return reconcile.Result{Requeue: true, RequeueAfter: r.config().DomainVerificationInterval}, nil
+	}
 	return reconcile.Result{}, nil
 }
 
+// newIngressController returns the ingresscontroller serving a CustomDomain
+func (r *CustomDomainReconciler) newIngressController(instance customdomainv1alpha1.CustomDomain, name, domain, scope string, strategy operatorv1.EndpointPublishingStrategyType, secretName string) (*operatorv1.IngressController, error) {
+	customIngress := &operatorv1.IngressController{}
+	customIngress.Name = name
+	customIngress.Namespace = ingressOperatorNamespace
+	customIngress.Labels = labelsForOwnedResources()
+	customIngress.Spec.Domain = domain
+	customIngress.Spec.EndpointPublishingStrategy = endpointPublishingStrategyFor(instance, strategy, scope)
+
+	// Provider parameters only apply to load balancers, other strategies can be used on any platform
+	if strategy == operatorv1.LoadBalancerServiceStrategyType {
+		cloudPlatform, err := GetPlatformType(r.Client)
+		if err != nil {
+			return nil, err
+		}
+		isAWS := *cloudPlatform == "AWS"
+
+		if isAWS {
+			r.setAWSProviderParameters(instance, customIngress)
+		} else if *cloudPlatform == "GCP" {
+			r.setGCPProviderParameters(instance, customIngress)
+		}
+	}
+
+	customIngress.Spec.NodePlacement = r.config().NodePlacement
+	if instance.Spec.RouteSelector != nil {
+		customIngress.Spec.RouteSelector = instance.Spec.RouteSelector
+	}
+	if instance.Spec.NamespaceSelector != nil {
+		customIngress.Spec.NamespaceSelector = instance.Spec.NamespaceSelector
+	}
+	if customIngress.Spec.DefaultCertificate != nil {
+		customIngress.Spec.DefaultCertificate.Name = secretName
+	} else {
+		customIngress.Spec.DefaultCertificate = &corev1.LocalObjectReference{Name: secretName}
+	}
+	return customIngress, nil
+}
+
+// updateIngressControllerSpec brings the fields of an existing ingresscontroller that follow the CustomDomain and the
+// operator configuration up to date: the port and protocol options and the load balancer parameters. The node placement
+// is only set when the ingresscontroller is created, as changing it rolls out the router pods.
+func (r *CustomDomainReconciler) updateIngressControllerSpec(instance customdomainv1alpha1.CustomDomain, customIngress *operatorv1.IngressController, scope string, strategy operatorv1.EndpointPublishingStrategyType) error {
+	if customIngress.Spec.EndpointPublishingStrategy != nil {
+		// Ensure the port and protocol options are set correctly
+		desiredStrategy := endpointPublishingStrategyFor(instance, strategy, scope)
+		switch strategy {
+		case operatorv1.HostNetworkStrategyType:
+			customIngress.Spec.EndpointPublishingStrategy.HostNetwork = desiredStrategy.HostNetwork
+		case operatorv1.NodePortServiceStrategyType:
+			customIngress.Spec.EndpointPublishingStrategy.NodePort = desiredStrategy.NodePort
+		}
+		if customIngress.Spec.EndpointPublishingStrategy.LoadBalancer != nil {
+			// Ensure the timeout is set correctly
+			platform, err := GetPlatformType(r.Client)
+			if err != nil {
+				return fmt.Errorf("failed to determine platform type: %w", err)
+			}
+			switch *platform {
+			case "AWS":
+				r.setAWSProviderParameters(instance, customIngress)
+			case "GCP":
+				r.setGCPProviderParameters(instance, customIngress)
+			default:
+				return fmt.Errorf("unknown platform: %s", *platform)
+			}
+		}
+	}
+	return nil
+}
+
+// endpointPublishingStrategyFor returns the EndpointPublishingStrategy of the CustomDomain's ingresscontroller
+func endpointPublishingStrategyFor(instance customdomainv1alpha1.CustomDomain, strategyType operatorv1.EndpointPublishingStrategyType, scope string) *operatorv1.EndpointPublishingStrategy {
+	strategy := &operatorv1.EndpointPublishingStrategy{Type: strategyType}
+	switch strategyType {
+	case operatorv1.HostNetworkStrategyType:
+		strategy.HostNetwork = &operatorv1.HostNetworkStrategy{}
+		if instance.Spec.HostNetwork != nil {
+			strategy.HostNetwork = instance.Spec.HostNetwork.DeepCopy()
+		}
+		if instance.Spec.ProxyProtocol {
+			strategy.HostNetwork.Protocol = operatorv1.ProxyProtocol
+		}
+	case operatorv1.NodePortServiceStrategyType:
+		strategy.NodePort = &operatorv1.NodePortStrategy{}
+		if instance.Spec.NodePort != nil {
+			strategy.NodePort = instance.Spec.NodePort.DeepCopy()
+		}
+		if instance.Spec.ProxyProtocol {
+			strategy.NodePort.Protocol = operatorv1.ProxyProtocol
+		}
+	case operatorv1.PrivateStrategyType:
+		strategy.Private = &operatorv1.PrivateStrategy{}
+	default:
+		strategy.LoadBalancer = &operatorv1.LoadBalancerStrategy{
+			Scope: operatorv1.LoadBalancerScope(scope),
+			ProviderParameters: &operatorv1.ProviderLoadBalancerParameters{
+				Type: operatorv1.LoadBalancerProviderType(operatorv1.LoadBalancerServiceStrategyType),
+			},
+		}
+	}
+	return strategy
+}
+
+// validateProxyProtocol checks that PROXY protocol can be enabled for the CustomDomain's ingresscontroller. It returns
+// why the proxyProtocol field has no effect, if the load balancer always uses PROXY protocol.
+func (r *CustomDomainReconciler) validateProxyProtocol(instance customdomainv1alpha1.CustomDomain, strategyType operatorv1.EndpointPublishingStrategyType) (string, error) {
+	if strategyType != operatorv1.LoadBalancerServiceStrategyType {
+		return "", proxyProtocolSpecError(instance, strategyType)
+	}
+	platform, err := GetPlatformType(r.Client)
+	if err != nil {
+		return "", fmt.Errorf("failed to determine platform type: %w", err)
+	}
+	if *platform != configv1.AWSPlatformType {
+		return "", fmt.Errorf("PROXY protocol is not supported for load balancers on platform %s", *platform)
+	}
+	if err := proxyProtocolSpecError(instance, strategyType); err != nil {
+		return "", err
+	}
+	// The ingress operator has no setting for it, AWS Classic load balancers always send PROXY protocol
+	return "PROXY protocol is always enabled on AWS Classic load balancers, the proxyProtocol field has no effect", nil
+}
+
+// setProxyProtocolIgnored reports with the ProxyProtocolIgnored condition why the proxyProtocol field has no effect,
+// or clears the condition if the reason is empty. It returns true if the condition changed.
+func setProxyProtocolIgnored(instance *customdomainv1alpha1.CustomDomain, reason string) bool {
+	status, message := corev1.ConditionTrue, reason
+	existing := FindCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionProxyProtocolIgnored)
+	if reason == "" {
+		if existing == nil {
+			return false
+		}
+		status, message = corev1.ConditionFalse, "The proxyProtocol field is in effect or unset"
+	}
+	if existing != nil && existing.Status == status && existing.Message == message {
+		return false
+	}
+	instance.Status.Conditions = SetCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionProxyProtocolIgnored,
+		status, message, UpdateConditionIfReasonOrMessageChange)
+	return true
+}
+
+// proxyProtocolSpecError checks the PROXY protocol settings of a CustomDomain that don't depend on the platform
+func proxyProtocolSpecError(instance customdomainv1alpha1.CustomDomain, strategyType operatorv1.EndpointPublishingStrategyType) error {
+	switch strategyType {
+	case operatorv1.HostNetworkStrategyType:
+		if instance.Spec.HostNetwork != nil && instance.Spec.HostNetwork.Protocol == operatorv1.TCPProtocol {
+			return errors.New("PROXY protocol conflicts with the TCP protocol set for the HostNetwork strategy")
+		}
+		return nil
+	case operatorv1.NodePortServiceStrategyType:
+		if instance.Spec.NodePort != nil && instance.Spec.NodePort.Protocol == operatorv1.TCPProtocol {
+			return errors.New("PROXY protocol conflicts with the TCP protocol set for the NodePortService strategy")
+		}
+		return nil
+	case operatorv1.PrivateStrategyType:
+		return errors.New("PROXY protocol is not supported with the Private endpoint publishing strategy")
+	}
+	// Classic load balancers are configured with PROXY protocol by the ingress operator, an NLB
+	// forwards the client's source address as is
+	if instance.Spec.LoadBalancerType == operatorv1.AWSNetworkLoadBalancer {
+		return errors.New("PROXY protocol is not supported with NLB load balancers, which preserve the client source address")
+	}
+	return nil
+}
+
 func (r *CustomDomainReconciler) setAWSProviderParameters(instance customdomainv1alpha1.CustomDomain, customIngress *operatorv1.IngressController) {
 	lbType := instance.Spec.LoadBalancerType
 	if lbType != operatorv1.AWSNetworkLoadBalancer {
@@ -422,7 +637,7 @@ func (r *CustomDomainReconciler) setAWSProviderParameters(instance customdomainv
 			AWS: &operatorv1.AWSLoadBalancerParameters{
 				Type: operatorv1.AWSClassicLoadBalancer,
 				ClassicLoadBalancerParameters: &operatorv1.AWSClassicLoadBalancerParameters{
-					ConnectionIdleTimeout: IngressControllerELBIdleTimeout,
+					ConnectionIdleTimeout: metav1.Duration{Duration: r.config().ELBIdleTimeout},
 				},
 			},
 		}
@@ -449,6 +664,10 @@ func labelsForOwnedResources() map[string]string {
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CustomDomainReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := setupCustomDomainIndexes(mgr); err != nil {
+		return err
+	}
+
 	// secretSelectorPredicate filters the controller's reconcile events down to only Secrets that have the managedLabelName
 	secretSelector := metav1.LabelSelector{
 		MatchExpressions: []metav1.LabelSelectorRequirement{
@@ -464,14 +683,25 @@ func (r *CustomDomainReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	}
 
 	secretHandler := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
-		customDomainName := obj.GetLabels()[managedLabelName]
-		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: customDomainName}}}
+		requests := []reconcile.Request{}
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return requests
+		}
+		for _, customDomainName := range secretCustomDomains(secret) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: customDomainName}})
+		}
+		return requests
 	})
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&customdomainv1alpha1.CustomDomain{}).
 		Watches(&corev1.Secret{},
 			secretHandler,
-			builder.WithPredicates(secretSelectorPredicate)).
-		Complete(r)
+			builder.WithPredicates(secretSelectorPredicate))
+	// reconcile the CustomDomains when the operator configuration changes
+	if r.ConfigChanges != nil {
+		b = b.WatchesRawSource(r.ConfigChanges, &handler.EnqueueRequestForObject{})
+	}
+	return b.Complete(r)
 }
@@ -3,20 +3,25 @@ package managed
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
 	operatoringressv1 "github.com/openshift/api/operatoringress/v1"
+	routev1 "github.com/openshift/api/route/v1"
 	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
 	"github.com/openshift/custom-domains-operator/config"
 	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -268,7 +273,7 @@ func TestCustomDomainController(t *testing.T) {
 	}
 
 	// generate CustomDomains w/ restricted ingress names
-	for _, n := range restrictedIngressNames {
+	for _, n := range DefaultOperatorConfig().RestrictedIngressNames {
 		cd := &customdomainv1alpha1.CustomDomain{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      n,
@@ -503,6 +508,13 @@ func TestCustomDomainController(t *testing.T) {
 	}
 	objs = append(objs, infra)
 
+	// Give the other CustomDomains distinct domains, so they don't conflict with the instance under test
+	for i, obj := range objs {
+		if cd, ok := obj.(*customdomainv1alpha1.CustomDomain); ok && cd.Name != instanceName {
+			cd.Spec.Domain = fmt.Sprintf("apps-%d.foo.com", i)
+		}
+	}
+
 	// Create a fake client to mock API calls.
 	cl := NewTestMock(t, objs...)
 	if err := UpdatePlatformStatus(cl); err != nil {
@@ -659,7 +671,7 @@ func TestCustomDomainController(t *testing.T) {
 	}
 
 	// Test reconcile of customdomain with restricted ingress name
-	for _, n := range restrictedIngressNames {
+	for _, n := range DefaultOperatorConfig().RestrictedIngressNames {
 		// Check reconcile of customdomain with invalid ingress name
 		reqInvalidName := reconcile.Request{
 			NamespacedName: types.NamespacedName{
@@ -798,7 +810,7 @@ func TestCustomDomainController(t *testing.T) {
 	// ========= DELETION =========
 	// deletion with restricted ingress names
 	now := metav1.NewTime(time.Now())
-	for _, n := range restrictedIngressNames {
+	for _, n := range DefaultOperatorConfig().RestrictedIngressNames {
 		customdomain.Name = n
 		req.Name = n
 		err = r.Client.Get(context.TODO(), types.NamespacedName{
@@ -974,6 +986,493 @@ func TestCustomDomainController(t *testing.T) {
 	if _, ok := validSecret.Labels[managedLabelName]; ok {
 		t.Error("reconcile did not remove secret labels")
 	}
+	if _, ok := validSecret.Annotations[customDomainsAnnotationName]; ok {
+		t.Error("reconcile did not remove secret annotations")
+	}
+}
+
+// TestCustomDomainEndpointPublishingStrategies checks that CustomDomains not published
+// through a load balancer become ready without waiting for a DNSRecord.
+func TestCustomDomainEndpointPublishingStrategies(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain  = "cluster1.x8s0.s1.openshiftapps.com"
+		userNamespace  = "my-project"
+		userSecretName = "my-secret"
+	)
+
+	tests := []struct {
+		name             string
+		strategy         operatorv1.EndpointPublishingStrategyType
+		hostNetwork      *operatorv1.HostNetworkStrategy
+		nodePort         *operatorv1.NodePortStrategy
+		expectedStrategy operatorv1.EndpointPublishingStrategy
+	}{
+		{
+			name:     "hostnetwork",
+			strategy: operatorv1.HostNetworkStrategyType,
+			hostNetwork: &operatorv1.HostNetworkStrategy{
+				Protocol:  operatorv1.TCPProtocol,
+				HTTPPort:  8080,
+				HTTPSPort: 8443,
+				StatsPort: 8936,
+			},
+			expectedStrategy: operatorv1.EndpointPublishingStrategy{
+				Type: operatorv1.HostNetworkStrategyType,
+				HostNetwork: &operatorv1.HostNetworkStrategy{
+					Protocol:  operatorv1.TCPProtocol,
+					HTTPPort:  8080,
+					HTTPSPort: 8443,
+					StatsPort: 8936,
+				},
+			},
+		},
+		{
+			name:     "nodeport",
+			strategy: operatorv1.NodePortServiceStrategyType,
+			nodePort: &operatorv1.NodePortStrategy{
+				Protocol: operatorv1.ProxyProtocol,
+			},
+			expectedStrategy: operatorv1.EndpointPublishingStrategy{
+				Type: operatorv1.NodePortServiceStrategyType,
+				NodePort: &operatorv1.NodePortStrategy{
+					Protocol: operatorv1.ProxyProtocol,
+				},
+			},
+		},
+		{
+			name:     "private",
+			strategy: operatorv1.PrivateStrategyType,
+			expectedStrategy: operatorv1.EndpointPublishingStrategy{
+				Type:    operatorv1.PrivateStrategyType,
+				Private: &operatorv1.PrivateStrategy{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customdomain := &customdomainv1alpha1.CustomDomain{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: tt.name,
+				},
+				Spec: customdomainv1alpha1.CustomDomainSpec{
+					Domain: "apps.foo.com",
+					Certificate: corev1.SecretReference{
+						Name:      userSecretName,
+						Namespace: userNamespace,
+					},
+					EndpointPublishingStrategy: tt.strategy,
+					HostNetwork:                tt.hostNetwork,
+					NodePort:                   tt.nodePort,
+				},
+			}
+
+			// The infrastructure object has no platform status, as on bare-metal or None platform clusters
+			objs := newTestClusterObjects(clusterDomain, nil)
+			objs = append(objs, newTestSecret(userSecretName, userNamespace), customdomain)
+			cl := NewTestMock(t, objs...)
+			r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: tt.name}}
+
+			res, err := r.Reconcile(context.TODO(), req)
+			if err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			if res != (reconcile.Result{}) {
+				t.Errorf("reconcile requeued waiting for a DNSRecord: (%v)", res)
+			}
+
+			ingress := &operatorv1.IngressController{}
+			if err := cl.Get(context.TODO(), types.NamespacedName{Name: tt.name, Namespace: ingressOperatorNamespace}, ingress); err != nil {
+				t.Fatalf("get ingress: (%v)", err)
+			}
+			if !reflect.DeepEqual(*ingress.Spec.EndpointPublishingStrategy, tt.expectedStrategy) {
+				t.Errorf("endpoint publishing strategy mismatch: expected (%+v), got (%+v)", tt.expectedStrategy, *ingress.Spec.EndpointPublishingStrategy)
+			}
+
+			if err := cl.Get(context.TODO(), req.NamespacedName, customdomain); err != nil {
+				t.Fatalf("get custom domain: (%v)", err)
+			}
+			if customdomain.Status.State != customdomainv1alpha1.CustomDomainStateReady {
+				t.Errorf("Status.State does not equal (%s)", string(customdomainv1alpha1.CustomDomainStateReady))
+			}
+			ingressDomain := tt.name + "." + clusterDomain
+			if customdomain.Status.DNSRecord != "*."+ingressDomain {
+				t.Errorf("Status.DNSRecord mismatch: (%s)", customdomain.Status.DNSRecord)
+			}
+			if !strings.HasSuffix(customdomain.Status.Endpoint, "."+ingressDomain) {
+				t.Errorf("Status.Endpoint mismatch: (%s)", customdomain.Status.Endpoint)
+			}
+
+			// The strategy of an existing ingresscontroller cannot be changed
+			customdomain.Spec.EndpointPublishingStrategy = operatorv1.LoadBalancerServiceStrategyType
+			if err := cl.Update(context.TODO(), customdomain); err != nil {
+				t.Fatalf("update custom domain: (%v)", err)
+			}
+			if _, err := r.Reconcile(context.TODO(), req); err == nil {
+				t.Error("Expected error when modifying Spec.EndpointPublishingStrategy")
+			}
+			if err := cl.Get(context.TODO(), req.NamespacedName, customdomain); err != nil {
+				t.Fatalf("get custom domain: (%v)", err)
+			}
+			if FindCustomDomainCondition(customdomain.Status.Conditions, customdomainv1alpha1.CustomDomainConditionInvalidEndpointPublishingStrategy) == nil {
+				t.Errorf("expected condition (%s)", customdomainv1alpha1.CustomDomainConditionInvalidEndpointPublishingStrategy)
+			}
+		})
+	}
+}
+
+// TestCustomDomainProxyProtocol checks that PROXY protocol is only enabled where the
+// platform, load balancer type and endpoint publishing strategy support it.
+func TestCustomDomainProxyProtocol(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain  = "cluster1.x8s0.s1.openshiftapps.com"
+		userNamespace  = "my-project"
+		userSecretName = "my-secret"
+	)
+
+	tests := []struct {
+		name             string
+		platform         configv1.PlatformType
+		spec             customdomainv1alpha1.CustomDomainSpec
+		expectError      bool
+		expectIgnored    bool
+		expectedStrategy *operatorv1.EndpointPublishingStrategy
+	}{
+		{
+			name:     "aws-classic",
+			platform: configv1.AWSPlatformType,
+			spec: customdomainv1alpha1.CustomDomainSpec{
+				LoadBalancerType: operatorv1.AWSClassicLoadBalancer,
+			},
+			expectIgnored: true,
+			expectedStrategy: &operatorv1.EndpointPublishingStrategy{
+				Type: operatorv1.LoadBalancerServiceStrategyType,
+				LoadBalancer: &operatorv1.LoadBalancerStrategy{
+					Scope: operatorv1.ExternalLoadBalancer,
+					ProviderParameters: &operatorv1.ProviderLoadBalancerParameters{
+						Type: operatorv1.AWSLoadBalancerProvider,
+						AWS: &operatorv1.AWSLoadBalancerParameters{
+							Type: operatorv1.AWSClassicLoadBalancer,
+							ClassicLoadBalancerParameters: &operatorv1.AWSClassicLoadBalancerParameters{
+								ConnectionIdleTimeout: IngressControllerELBIdleTimeout,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:     "aws-nlb",
+			platform: configv1.AWSPlatformType,
+			spec: customdomainv1alpha1.CustomDomainSpec{
+				LoadBalancerType: operatorv1.AWSNetworkLoadBalancer,
+			},
+			expectError: true,
+		},
+		{
+			name:        "gcp",
+			platform:    configv1.GCPPlatformType,
+			expectError: true,
+		},
+		{
+			name:     "hostnetwork",
+			platform: configv1.BareMetalPlatformType,
+			spec: customdomainv1alpha1.CustomDomainSpec{
+				EndpointPublishingStrategy: operatorv1.HostNetworkStrategyType,
+				HostNetwork: &operatorv1.HostNetworkStrategy{
+					HTTPPort:  8080,
+					HTTPSPort: 8443,
+				},
+			},
+			expectedStrategy: &operatorv1.EndpointPublishingStrategy{
+				Type: operatorv1.HostNetworkStrategyType,
+				HostNetwork: &operatorv1.HostNetworkStrategy{
+					Protocol:  operatorv1.ProxyProtocol,
+					HTTPPort:  8080,
+					HTTPSPort: 8443,
+				},
+			},
+		},
+		{
+			name:     "hostnetwork-tcp",
+			platform: configv1.BareMetalPlatformType,
+			spec: customdomainv1alpha1.CustomDomainSpec{
+				EndpointPublishingStrategy: operatorv1.HostNetworkStrategyType,
+				HostNetwork: &operatorv1.HostNetworkStrategy{
+					Protocol: operatorv1.TCPProtocol,
+				},
+			},
+			expectError: true,
+		},
+		{
+			name:     "nodeport",
+			platform: configv1.NonePlatformType,
+			spec: customdomainv1alpha1.CustomDomainSpec{
+				EndpointPublishingStrategy: operatorv1.NodePortServiceStrategyType,
+			},
+			expectedStrategy: &operatorv1.EndpointPublishingStrategy{
+				Type: operatorv1.NodePortServiceStrategyType,
+				NodePort: &operatorv1.NodePortStrategy{
+					Protocol: operatorv1.ProxyProtocol,
+				},
+			},
+		},
+		{
+			name:     "private",
+			platform: configv1.NonePlatformType,
+			spec: customdomainv1alpha1.CustomDomainSpec{
+				EndpointPublishingStrategy: operatorv1.PrivateStrategyType,
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customdomain := &customdomainv1alpha1.CustomDomain{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: tt.name,
+				},
+				Spec: tt.spec,
+			}
+			customdomain.Spec.Domain = "apps.foo.com"
+			customdomain.Spec.Certificate = corev1.SecretReference{Name: userSecretName, Namespace: userNamespace}
+			customdomain.Spec.ProxyProtocol = true
+
+			objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: tt.platform})
+			objs = append(objs, newTestSecret(userSecretName, userNamespace), customdomain)
+			cl := NewTestMock(t, objs...)
+			r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: tt.name}}
+
+			_, err := r.Reconcile(context.TODO(), req)
+			if err := cl.Get(context.TODO(), req.NamespacedName, customdomain); err != nil {
+				t.Fatalf("get custom domain: (%v)", err)
+			}
+			invalidProxyProtocol := FindCustomDomainCondition(customdomain.Status.Conditions, customdomainv1alpha1.CustomDomainConditionInvalidProxyProtocol)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected an error for an unsupported PROXY protocol configuration")
+				}
+				if invalidProxyProtocol == nil {
+					t.Errorf("expected condition (%s)", customdomainv1alpha1.CustomDomainConditionInvalidProxyProtocol)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			if invalidProxyProtocol != nil {
+				t.Errorf("unexpected condition (%s): (%s)", invalidProxyProtocol.Type, invalidProxyProtocol.Message)
+			}
+			ignored := FindCustomDomainCondition(customdomain.Status.Conditions, customdomainv1alpha1.CustomDomainConditionProxyProtocolIgnored)
+			if tt.expectIgnored != (ignored != nil && ignored.Status == corev1.ConditionTrue) {
+				t.Errorf("expected the %s condition to be %t, got %+v", customdomainv1alpha1.CustomDomainConditionProxyProtocolIgnored, tt.expectIgnored, ignored)
+			}
+
+			ingress := &operatorv1.IngressController{}
+			if err := cl.Get(context.TODO(), types.NamespacedName{Name: tt.name, Namespace: ingressOperatorNamespace}, ingress); err != nil {
+				t.Fatalf("get ingress: (%v)", err)
+			}
+			if !reflect.DeepEqual(ingress.Spec.EndpointPublishingStrategy, tt.expectedStrategy) {
+				t.Errorf("endpoint publishing strategy mismatch: expected (%+v), got (%+v)", tt.expectedStrategy, ingress.Spec.EndpointPublishingStrategy)
+			}
+		})
+	}
+}
+
+// TestCustomDomainDeletionPolicy tests what happens to the ingresscontroller and certificate of a deleted CustomDomain
+func TestCustomDomainDeletionPolicy(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain  = "cluster1.x8s0.s1.openshiftapps.com"
+		userNamespace  = "my-project"
+		userSecretName = "my-secret"
+	)
+
+	tests := []struct {
+		name          string
+		policy        customdomainv1alpha1.DeletionPolicyType
+		expectDeleted bool
+		expectLabeled bool
+	}{
+		{
+			name:          "unset",
+			expectDeleted: true,
+		},
+		{
+			name:          "delete",
+			policy:        customdomainv1alpha1.DeletionPolicyDelete,
+			expectDeleted: true,
+		},
+		{
+			name:   "retain",
+			policy: customdomainv1alpha1.DeletionPolicyRetain,
+		},
+		{
+			name:          "orphan",
+			policy:        customdomainv1alpha1.DeletionPolicyOrphan,
+			expectLabeled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customdomain := &customdomainv1alpha1.CustomDomain{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: tt.name,
+				},
+				Spec: customdomainv1alpha1.CustomDomainSpec{
+					Domain: "apps.foo.com",
+					Scope:  "External",
+					Certificate: corev1.SecretReference{
+						Name:      userSecretName,
+						Namespace: userNamespace,
+					},
+					DeletionPolicy: tt.policy,
+				},
+			}
+			objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+			objs = append(objs, newTestSecret(userSecretName, userNamespace), customdomain, newTestDNSRecord(tt.name, clusterDomain))
+			cl := NewTestMock(t, objs...)
+			r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: tt.name}}
+			if _, err := r.Reconcile(context.TODO(), req); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+
+			if err := cl.Get(context.TODO(), req.NamespacedName, customdomain); err != nil {
+				t.Fatalf("get custom domain: (%v)", err)
+			}
+			if err := cl.Delete(context.TODO(), customdomain); err != nil {
+				t.Fatalf("delete custom domain: (%v)", err)
+			}
+			if _, err := r.Reconcile(context.TODO(), req); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			err := cl.Get(context.TODO(), req.NamespacedName, customdomain)
+			if !kerr.IsNotFound(err) {
+				t.Errorf("custom domain was not deleted: (%v)", err)
+			}
+
+			ingress := &operatorv1.IngressController{}
+			ingressErr := cl.Get(context.TODO(), types.NamespacedName{Name: tt.name, Namespace: ingressOperatorNamespace}, ingress)
+			ingressSecret := &corev1.Secret{}
+			secretErr := cl.Get(context.TODO(), types.NamespacedName{Name: tt.name, Namespace: ingressNamespace}, ingressSecret)
+			if tt.expectDeleted {
+				if !kerr.IsNotFound(ingressErr) {
+					t.Errorf("ingresscontroller was not deleted: (%v)", ingressErr)
+				}
+				if !kerr.IsNotFound(secretErr) {
+					t.Errorf("ingress secret was not deleted: (%v)", secretErr)
+				}
+				return
+			}
+			if ingressErr != nil {
+				t.Fatalf("get ingress: (%v)", ingressErr)
+			}
+			if secretErr != nil {
+				t.Fatalf("get ingress secret: (%v)", secretErr)
+			}
+			if _, ok := ingress.Labels[managedLabelName]; ok != tt.expectLabeled {
+				t.Errorf("ingresscontroller label mismatch: expected (%v), got (%v)", tt.expectLabeled, ok)
+			}
+			if _, ok := ingressSecret.Labels[managedLabelName]; ok != tt.expectLabeled {
+				t.Errorf("ingress secret label mismatch: expected (%v), got (%v)", tt.expectLabeled, ok)
+			}
+			if ingress.Annotations[deletionPolicyAnnotationName] != string(tt.policy) || ingressSecret.Annotations[deletionPolicyAnnotationName] != string(tt.policy) {
+				t.Errorf("expected the resources to be marked with the %s deletion policy, got (%v) and (%v)", tt.policy, ingress.Annotations, ingressSecret.Annotations)
+			}
+
+			// the resources left in place are not deleted by the orphaned resource sweeper
+			sweeper := &OrphanSweeper{Client: cl, Recorder: record.NewFakeRecorder(10), Interval: time.Hour, Mode: OrphanSweeperModeDelete}
+			if err := sweeper.Sweep(context.TODO(), logf.Log); err != nil {
+				t.Fatalf("sweep: (%v)", err)
+			}
+			if err := cl.Get(context.TODO(), types.NamespacedName{Name: tt.name, Namespace: ingressOperatorNamespace}, ingress); err != nil {
+				t.Errorf("ingresscontroller was swept: (%v)", err)
+			}
+			if err := cl.Get(context.TODO(), types.NamespacedName{Name: tt.name, Namespace: ingressNamespace}, ingressSecret); err != nil {
+				t.Errorf("ingress secret was swept: (%v)", err)
+			}
+
+			// a recreated CustomDomain manages the resources again, so the deletion policy marker is removed
+			recreated := &customdomainv1alpha1.CustomDomain{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: tt.name,
+				},
+				Spec: customdomainv1alpha1.CustomDomainSpec{
+					Domain: "apps.foo.com",
+					Scope:  "External",
+					Certificate: corev1.SecretReference{
+						Name:      userSecretName,
+						Namespace: userNamespace,
+					},
+					AdoptExisting: true,
+				},
+			}
+			if err := cl.Create(context.TODO(), recreated); err != nil {
+				t.Fatalf("create custom domain: (%v)", err)
+			}
+			if _, err := r.Reconcile(context.TODO(), req); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			if err := cl.Get(context.TODO(), types.NamespacedName{Name: tt.name, Namespace: ingressOperatorNamespace}, ingress); err != nil {
+				t.Fatalf("get ingress: (%v)", err)
+			}
+			if err := cl.Get(context.TODO(), types.NamespacedName{Name: tt.name, Namespace: ingressNamespace}, ingressSecret); err != nil {
+				t.Fatalf("get ingress secret: (%v)", err)
+			}
+			if _, ok := ingress.Labels[managedLabelName]; !ok {
+				t.Errorf("expected the ingresscontroller to be managed again, got labels (%v)", ingress.Labels)
+			}
+			if _, ok := ingress.Annotations[deletionPolicyAnnotationName]; ok {
+				t.Errorf("expected the ingresscontroller deletion policy marker to be removed, got (%v)", ingress.Annotations)
+			}
+			if _, ok := ingressSecret.Annotations[deletionPolicyAnnotationName]; ok {
+				t.Errorf("expected the ingress secret deletion policy marker to be removed, got (%v)", ingressSecret.Annotations)
+			}
+		})
+	}
+}
+
+// newTestClusterObjects returns the cluster scoped objects read by the reconciler
+func newTestClusterObjects(clusterDomain string, platformStatus *configv1.PlatformStatus) []client.Object {
+	return []client.Object{
+		&configv1.ClusterVersion{
+			ObjectMeta: metav1.ObjectMeta{Name: "version"},
+			Status: configv1.ClusterVersionStatus{
+				History: []configv1.UpdateHistory{{Version: "4.12.0"}},
+			},
+		},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: config.OperatorNamespace}},
+		&configv1.DNS{
+			ObjectMeta: metav1.ObjectMeta{Name: dnsConfigName},
+			Spec:       configv1.DNSSpec{BaseDomain: clusterDomain},
+		},
+		&configv1.Infrastructure{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Status:     configv1.InfrastructureStatus{PlatformStatus: platformStatus},
+		},
+	}
+}
+
+// newTestSecret returns a secret of type kubernetes.io/tls
+func newTestSecret(name, namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte("DEADBEEF"),
+			corev1.TLSPrivateKeyKey: []byte("DEADBEEF"),
+		},
+		Type: corev1.SecretTypeTLS,
+	}
 }
 
 // UpdatePlatformStatus gets the infrastructure object "cluster",
@@ -1033,9 +1532,18 @@ func NewMock(obs ...client.Object) (client.Client, error) {
 		return nil, err
 	}
 
+	// Add Openshift route v1 scheme
+	if err := routev1.Install(s); err != nil {
+		return nil, err
+	}
+
 	if err := customdomainv1alpha1.AddToScheme(s); err != nil {
 		return nil, err
 	}
 
-	return fake.NewClientBuilder().WithStatusSubresource(obs...).WithScheme(s).WithObjects(obs...).Build(), nil
+	return fake.NewClientBuilder().WithStatusSubresource(obs...).WithScheme(s).WithObjects(obs...).
+		WithIndex(&customdomainv1alpha1.CustomDomain{}, customDomainDomainIndex, indexCustomDomainDomain).
+		WithIndex(&customdomainv1alpha1.CustomDomain{}, customDomainDomainSuffixesIndex, indexCustomDomainDomainSuffixes).
+		WithIndex(&routev1.Route{}, routeHostDomainsIndex, indexRouteHostDomains).
+		Build(), nil
 }
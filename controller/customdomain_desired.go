@@ -0,0 +1,196 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// The desired state of a CustomDomain is computed here, and shared by Reconcile, which applies it, and Plan, which
+// reports the difference with the live objects.
+
+// desiredIngress is the ingresscontroller serving a CustomDomain. Its domain is a subdomain of the base domain of the
+// cluster, such that the record is added to the zone of the cluster and the custom domain can point to it.
+type desiredIngress struct {
+	name       string
+	domain     string
+	baseDomain string
+	scope      string
+	strategy   operatorv1.EndpointPublishingStrategyType
+}
+
+// customDomainStrategy returns the endpoint publishing strategy of a CustomDomain, LoadBalancerService if it is not set
+func customDomainStrategy(instance *customdomainv1alpha1.CustomDomain) operatorv1.EndpointPublishingStrategyType {
+	if instance.Spec.EndpointPublishingStrategy == "" {
+		return ingressDefaultEndpointPublishingStrategy
+	}
+	return instance.Spec.EndpointPublishingStrategy
+}
+
+// desiredIngressFor returns the ingresscontroller serving the CustomDomain
+func (r *CustomDomainReconciler) desiredIngressFor(ctx context.Context, instance *customdomainv1alpha1.CustomDomain) (desiredIngress, error) {
+	dnsConfig := &configv1.DNS{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: dnsConfigName}, dnsConfig)
+	if err != nil {
+		return desiredIngress{}, err
+	}
+	name := ingressControllerName(instance)
+	return desiredIngress{
+		name:       name,
+		domain:     fmt.Sprintf("%s.%s", name, dnsConfig.Spec.BaseDomain),
+		baseDomain: dnsConfig.Spec.BaseDomain,
+		scope:      customDomainScope(instance),
+		strategy:   customDomainStrategy(instance),
+	}, nil
+}
+
+// desiredIngressSecret returns the copy of the user secret in the ingress namespace, which is served by the
+// ingresscontroller of the CustomDomain
+func desiredIngressSecret(instance *customdomainv1alpha1.CustomDomain, userSecret *corev1.Secret) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: ingressNamespace,
+			Labels:    labelsForOwnedResources(),
+		},
+		Data: userSecret.Data,
+		Type: userSecret.Type,
+	}
+}
+
+// updatedIngressSecret returns the existing ingress secret updated to the desired one, or nil if it is up to date. An
+// existing secret that is not managed by the operator is only taken over with the ingresscontroller serving it when
+// that ingresscontroller is adopted, otherwise the returned problem stops the reconcile.
+func updatedIngressSecret(live, desired *corev1.Secret, action ingressControllerAction) (*corev1.Secret, string) {
+	if _, ok := live.Labels[managedLabelName]; !ok && action != ingressControllerAdopt {
+		return nil, fmt.Sprintf("Secret %s/%s already exists and is not managed by the custom domains operator", live.Namespace, live.Name)
+	}
+	updated := live.DeepCopy()
+	if updated.Labels == nil {
+		updated.Labels = map[string]string{}
+	}
+	for k, v := range desired.Labels {
+		updated.Labels[k] = v
+	}
+	updated.Data = desired.Data
+	// a secret left in place by a deleted CustomDomain is managed again
+	cleared := clearDeletionPolicy(updated)
+	if !cleared && reflect.DeepEqual(updated.Labels, live.Labels) && len(diffSecretData(live.Data, desired.Data)) == 0 {
+		return nil, ""
+	}
+	return updated, ""
+}
+
+// ingressControllerAction is what the reconcile does to the ingresscontroller of a CustomDomain
+type ingressControllerAction string
+
+const (
+	ingressControllerCreate       ingressControllerAction = "Create"
+	ingressControllerAdopt        ingressControllerAction = "Adopt"
+	ingressControllerUpdate       ingressControllerAction = "Update"
+	ingressControllerMigrateScope ingressControllerAction = "MigrateScope"
+)
+
+// ingressControllerChange is the change the reconcile makes to the ingresscontroller of a CustomDomain
+type ingressControllerChange struct {
+	action ingressControllerAction
+	// live is the existing ingresscontroller, nil if it is created
+	live *operatorv1.IngressController
+	// desired is the ingresscontroller once changed, or the new ingresscontroller of a scope migration
+	desired *operatorv1.IngressController
+	// blocker is the problem that stops the reconcile before the ingresscontroller is changed, reported with the
+	// condition
+	blocker   string
+	condition customdomainv1alpha1.CustomDomainConditionType
+}
+
+// blocked returns a change that stops the reconcile with the problem
+func blocked(condition customdomainv1alpha1.CustomDomainConditionType, blocker string) *ingressControllerChange {
+	return &ingressControllerChange{blocker: blocker, condition: condition}
+}
+
+// desiredIngressController returns the change to the ingresscontroller of the CustomDomain. An ingresscontroller that
+// is not managed by the operator is only adopted if the CustomDomain asks for it and it is compatible, and the
+// endpoint publishing strategy and scope of an existing ingresscontroller are not changed in place.
+func (r *CustomDomainReconciler) desiredIngressController(ctx context.Context, instance *customdomainv1alpha1.CustomDomain, ingress desiredIngress, userSecret *corev1.Secret) (*ingressControllerChange, error) {
+	live := &operatorv1.IngressController{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: ingressOperatorNamespace, Name: ingress.name}, live)
+	if err != nil {
+		if !kerr.IsNotFound(err) {
+			return nil, err
+		}
+		desired, err := r.newIngressController(*instance, ingress.name, ingress.domain, ingress.scope, ingress.strategy, instance.Name)
+		if err != nil {
+			return nil, err
+		}
+		return &ingressControllerChange{action: ingressControllerCreate, desired: desired}, nil
+	}
+
+	change := &ingressControllerChange{action: ingressControllerUpdate, live: live, desired: live.DeepCopy()}
+	if _, ok := live.Labels[managedLabelName]; !ok {
+		if !instance.Spec.AdoptExisting {
+			return blocked(customdomainv1alpha1.CustomDomainConditionIngressControllerConflict,
+				fmt.Sprintf("IngressController %s already exists and is not managed by the custom domains operator (set adoptExisting to adopt it)", ingress.name)), nil
+		}
+		problems, err := r.ingressControllerAdoptionProblems(instance, live, ingress.domain, ingress.scope, ingress.strategy, userSecret, instance.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(problems) > 0 {
+			return blocked(customdomainv1alpha1.CustomDomainConditionIngressControllerConflict,
+				fmt.Sprintf("IngressController %s cannot be adopted: %s", ingress.name, strings.Join(problems, "; "))), nil
+		}
+		change.action = ingressControllerAdopt
+		labels := map[string]string{}
+		for k, v := range live.Labels {
+			labels[k] = v
+		}
+		for k, v := range labelsForOwnedResources() {
+			labels[k] = v
+		}
+		change.desired.Labels = labels
+	}
+	// an ingresscontroller left in place by a deleted CustomDomain is managed again
+	clearDeletionPolicy(change.desired)
+
+	// TODO: Check for scope change when the endpoint publishing strategy is not set
+	if strategy := live.Spec.EndpointPublishingStrategy; strategy != nil {
+		if strategy.Type != ingress.strategy {
+			return blocked(customdomainv1alpha1.CustomDomainConditionInvalidEndpointPublishingStrategy,
+				fmt.Sprintf("Invalid update to ingress endpoint publishing strategy (detected change from %s to %s)", strategy.Type, ingress.strategy)), nil
+		}
+		if strategy.LoadBalancer != nil && string(strategy.LoadBalancer.Scope) != ingress.scope {
+			if instance.Spec.ScopeChangePolicy != customdomainv1alpha1.ScopeChangePolicyRecreate {
+				return blocked(customdomainv1alpha1.CustomDomainConditionInvalidScope,
+					fmt.Sprintf("Invalid update to ingress scope (detected change from %s to %s)", strategy.LoadBalancer.Scope, ingress.scope)), nil
+			}
+			name := scopeMigrationIngressName(instance.Name, ingress.name, ingress.scope)
+			desired, err := r.newIngressController(*instance, name, fmt.Sprintf("%s.%s", name, ingress.baseDomain), ingress.scope, ingress.strategy, instance.Name)
+			if err != nil {
+				return nil, err
+			}
+			return &ingressControllerChange{action: ingressControllerMigrateScope, live: live, desired: desired}, nil
+		}
+	}
+
+	if err := r.updateIngressControllerSpec(*instance, change.desired, ingress.scope, ingress.strategy); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// desiredEndpoint returns the endpoint of the CustomDomain under the ingress domain, and true if the status must be
+// changed to it. The endpoint is kept once it is set, unless the host is set in the spec.
+func (r *CustomDomainReconciler) desiredEndpoint(instance *customdomainv1alpha1.CustomDomain, ingressDomain string) (string, bool) {
+	endpoint := fmt.Sprintf("%s.%s", r.endpointHostFor(instance), ingressDomain)
+	return endpoint, len(instance.Status.Endpoint) == 0 || (instance.Spec.EndpointHost != "" && instance.Status.Endpoint != endpoint)
+}
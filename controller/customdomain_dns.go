@@ -0,0 +1,165 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// defaultDomainVerificationInterval is how often an unverified custom domain is checked again
+	defaultDomainVerificationInterval = 5 * time.Minute
+	// dnsLookupTimeout bounds the lookups of a single verification
+	dnsLookupTimeout = 10 * time.Second
+	// verificationHostLength is the length of the random host looked up under the custom domain
+	verificationHostLength = 12
+)
+
+// DNSResolver looks up DNS records. It is satisfied by *net.Resolver, and can be replaced by a stand-in in tests.
+type DNSResolver interface {
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// NewDNSResolver returns a resolver that sends its queries to the given address, or the system resolver if it is empty
+func NewDNSResolver(address string) *net.Resolver {
+	if address == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{}
+			return d.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// resolver returns the resolver of the reconciler, or the system resolver if none is set
+func (r *CustomDomainReconciler) resolver() DNSResolver {
+	if r.Resolver == nil {
+		return net.DefaultResolver
+	}
+	return r.Resolver
+}
+
+// dnsAnswer is what a host resolved to
+type dnsAnswer struct {
+	cname     string
+	addresses []string
+	err       error
+}
+
+func (a dnsAnswer) String() string {
+	if a.err != nil {
+		return fmt.Sprintf("error: %v", a.err)
+	}
+	return fmt.Sprintf("CNAME %s, addresses %v", a.cname, a.addresses)
+}
+
+// lookup resolves the canonical name and addresses of a host
+func lookup(ctx context.Context, resolver DNSResolver, host string) dnsAnswer {
+	answer := dnsAnswer{}
+	cname, err := resolver.LookupCNAME(ctx, host)
+	if err != nil {
+		answer.err = err
+		return answer
+	}
+	answer.cname = strings.TrimSuffix(cname, ".")
+	answer.addresses, answer.err = resolver.LookupHost(ctx, host)
+	return answer
+}
+
+// CheckDelegation checks that a custom domain is delegated to an endpoint, by resolving a random host under the custom
+// domain and comparing the answer with the endpoint's. It returns true if the custom domain is delegated, and a message
+// with the observed answers.
+func CheckDelegation(ctx context.Context, resolver DNSResolver, domain, endpoint string) (bool, string) {
+	host := fmt.Sprintf("%s.%s", randomHost(verificationHostLength), domain)
+	hostAnswer := lookup(ctx, resolver, host)
+	endpointAnswer := lookup(ctx, resolver, endpoint)
+
+	delegated := false
+	if hostAnswer.err == nil {
+		switch {
+		case hostAnswer.cname == endpoint:
+			delegated = true
+		case endpointAnswer.err == nil && hostAnswer.cname == endpointAnswer.cname && hostAnswer.cname != host:
+			delegated = true
+		case endpointAnswer.err == nil:
+			for _, address := range hostAnswer.addresses {
+				if contains(endpointAnswer.addresses, address) {
+					delegated = true
+					break
+				}
+			}
+		}
+	}
+
+	if delegated {
+		return true, fmt.Sprintf("%s is delegated to endpoint %s. %s resolved to %s", domain, endpoint, host, hostAnswer)
+	}
+	return false, fmt.Sprintf("%s does not resolve to endpoint %s (create a CNAME record for *.%s pointing to %s). %s resolved to %s; %s resolved to %s",
+		domain, endpoint, domain, endpoint, host, hostAnswer, endpoint, endpointAnswer)
+}
+
+// verifyDelegation checks that the custom domain is delegated to the CustomDomain's endpoint. It sets the
+// DomainDelegated condition with the observed answers, and returns true if the custom domain is delegated.
+func (r *CustomDomainReconciler) verifyDelegation(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) bool {
+	ctx, cancel := context.WithTimeout(context.TODO(), dnsLookupTimeout)
+	defer cancel()
+
+	delegated, message := CheckDelegation(ctx, r.resolver(), instance.Spec.Domain, instance.Status.Endpoint)
+	status := corev1.ConditionFalse
+	if delegated {
+		status = corev1.ConditionTrue
+	}
+	reqLogger.Info(message)
+	// The random host changes between checks, so the message is only updated along with the status
+	instance.Status.Conditions = setCustomDomainConditionStatus(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDomainDelegated, status, message, UpdateConditionNever)
+	return delegated
+}
+
+// setCustomDomainConditionStatus sets a condition that reports a result either way, so unlike SetCustomDomainCondition
+// it also adds the condition when it is false
+func setCustomDomainConditionStatus(
+	conditions []customdomainv1alpha1.CustomDomainCondition,
+	conditionType customdomainv1alpha1.CustomDomainConditionType,
+	status corev1.ConditionStatus,
+	message string,
+	updateConditionCheck UpdateConditionCheck,
+) []customdomainv1alpha1.CustomDomainCondition {
+	if FindCustomDomainCondition(conditions, conditionType) == nil {
+		now := metav1.Now()
+		return append(conditions, customdomainv1alpha1.CustomDomainCondition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             string(conditionType),
+			Message:            message,
+			LastTransitionTime: now,
+			LastProbeTime:      now,
+		})
+	}
+	return SetCustomDomainCondition(conditions, conditionType, status, message, updateConditionCheck)
+}
+
+// letters used by randomHost
+var hostLetters = []rune("abcdefghijklmnopqrstuvwxyz0123456789")
+
+// randomHost generates a host label that is not expected to exist in any zone
+func randomHost(n int) string {
+	b := make([]rune, n)
+	for i := range b {
+		// #nosec G404
+		b[i] = hostLetters[rand.Intn(len(hostLetters))]
+	}
+	return string(b)
+}
@@ -0,0 +1,185 @@
+package managed
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakeResolver is a DNS stand-in answering from static records. Wildcard records are keyed by "*.<domain>".
+type fakeResolver struct {
+	cnames    map[string]string
+	addresses map[string][]string
+	txts      map[string][]string
+}
+
+func (f *fakeResolver) record(host string) string {
+	if _, ok := f.cnames[host]; ok {
+		return host
+	}
+	if _, ok := f.addresses[host]; ok {
+		return host
+	}
+	if i := strings.Index(host, "."); i >= 0 {
+		return "*" + host[i:]
+	}
+	return host
+}
+
+func (f *fakeResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	name := f.record(host)
+	if cname, ok := f.cnames[name]; ok {
+		return cname + ".", nil
+	}
+	if _, ok := f.addresses[name]; ok {
+		return host + ".", nil
+	}
+	return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (f *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	name := f.record(host)
+	if cname, ok := f.cnames[name]; ok {
+		return f.LookupHost(ctx, cname)
+	}
+	if addresses, ok := f.addresses[name]; ok {
+		return addresses, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (f *fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if txts, ok := f.txts[name]; ok {
+		return txts, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+// TestCustomDomainDelegation tests the DomainDelegated condition against a DNS stand-in
+func TestCustomDomainDelegation(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain  = "cluster1.x8s0.s1.openshiftapps.com"
+		instanceName   = "test"
+		userNamespace  = "my-project"
+		userSecretName = "my-secret"
+		loadBalancer   = "abc123.elb.amazonaws.com"
+	)
+	endpoint := "router." + instanceName + "." + clusterDomain
+
+	tests := []struct {
+		name              string
+		resolver          *fakeResolver
+		expectedDelegated corev1.ConditionStatus
+	}{
+		{
+			name: "cname to endpoint",
+			resolver: &fakeResolver{
+				cnames:    map[string]string{"*.apps.foo.com": endpoint, endpoint: loadBalancer},
+				addresses: map[string][]string{loadBalancer: {"192.0.2.1"}},
+			},
+			expectedDelegated: corev1.ConditionTrue,
+		},
+		{
+			name: "same addresses as endpoint",
+			resolver: &fakeResolver{
+				cnames:    map[string]string{endpoint: loadBalancer},
+				addresses: map[string][]string{loadBalancer: {"192.0.2.1"}, "*.apps.foo.com": {"192.0.2.1"}},
+			},
+			expectedDelegated: corev1.ConditionTrue,
+		},
+		{
+			name: "other addresses",
+			resolver: &fakeResolver{
+				cnames:    map[string]string{endpoint: loadBalancer},
+				addresses: map[string][]string{loadBalancer: {"192.0.2.1"}, "*.apps.foo.com": {"198.51.100.1"}},
+			},
+			expectedDelegated: corev1.ConditionFalse,
+		},
+		{
+			name: "not found",
+			resolver: &fakeResolver{
+				cnames:    map[string]string{endpoint: loadBalancer},
+				addresses: map[string][]string{loadBalancer: {"192.0.2.1"}},
+			},
+			expectedDelegated: corev1.ConditionFalse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customdomain := &customdomainv1alpha1.CustomDomain{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName},
+				Spec: customdomainv1alpha1.CustomDomainSpec{
+					Domain: "apps.foo.com",
+					Scope:  "External",
+					Certificate: corev1.SecretReference{
+						Name:      userSecretName,
+						Namespace: userNamespace,
+					},
+					EndpointHost: "router",
+				},
+			}
+			objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+			objs = append(objs, newTestSecret(userSecretName, userNamespace), customdomain, newTestDNSRecord(instanceName, clusterDomain))
+			cl := NewTestMock(t, objs...)
+			r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Resolver: tt.resolver,
+				Config: newTestOperatorConfigStore(func(c *OperatorConfig) {
+					c.VerifyDelegation = true
+					c.DomainVerificationInterval = time.Minute
+				})}
+			res, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName}})
+			if err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			if res.Requeue != (tt.expectedDelegated == corev1.ConditionFalse) {
+				t.Errorf("unexpected requeue: (%v)", res.Requeue)
+			}
+			if res.Requeue && res.RequeueAfter != time.Minute {
+				t.Errorf("undelegated domain not checked again after the verification interval: (%s)", res.RequeueAfter)
+			}
+
+			if err := cl.Get(context.TODO(), types.NamespacedName{Name: instanceName}, customdomain); err != nil {
+				t.Fatalf("get custom domain: (%v)", err)
+			}
+			if customdomain.Status.State != customdomainv1alpha1.CustomDomainStateReady {
+				t.Errorf("Status.State does not equal (%s)", string(customdomainv1alpha1.CustomDomainStateReady))
+			}
+			condition := FindCustomDomainCondition(customdomain.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDomainDelegated)
+			if condition == nil {
+				t.Fatalf("condition %s was not set", customdomainv1alpha1.CustomDomainConditionDomainDelegated)
+			}
+			if condition.Status != tt.expectedDelegated {
+				t.Errorf("condition %s mismatch: expected (%s), got (%s): %s", condition.Type, tt.expectedDelegated, condition.Status, condition.Message)
+			}
+			if !strings.Contains(condition.Message, ".apps.foo.com resolved to") {
+				t.Errorf("condition message does not report the observed answers: (%s)", condition.Message)
+			}
+		})
+	}
+}
+
+// TestCustomDomainReconcilerDefaultResolver tests that reconcilers without a resolver use the system resolver
+func TestCustomDomainReconcilerDefaultResolver(t *testing.T) {
+	r := &CustomDomainReconciler{}
+	if r.resolver() != net.DefaultResolver {
+		t.Errorf("expected the system resolver, got (%v)", r.resolver())
+	}
+	resolver := &fakeResolver{}
+	r.Resolver = resolver
+	if r.resolver() != resolver {
+		t.Errorf("expected the reconciler's resolver, got (%v)", r.resolver())
+	}
+}
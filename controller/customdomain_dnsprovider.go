@@ -0,0 +1,221 @@
+package managed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// dnsProviderDefaultTTL is the TTL of published records if none is configured
+	dnsProviderDefaultTTL = 300
+	// dnsProviderTimeout bounds the calls to a DNS provider
+	dnsProviderTimeout = 30 * time.Second
+)
+
+// DNSRecord is a CNAME record published by a DNSProvider
+type DNSRecord struct {
+	Name   string
+	Target string
+	TTL    int64
+}
+
+// DNSProvider publishes the CNAME records of custom domains in a DNS zone
+type DNSProvider interface {
+	// UpsertCNAME creates the record, or replaces the record of the same name
+	UpsertCNAME(ctx context.Context, record DNSRecord) error
+	// DeleteCNAME deletes the record. Deleting a record that does not exist is not an error.
+	DeleteCNAME(ctx context.Context, record DNSRecord) error
+}
+
+// DNSProviderFactory returns the DNSProvider configured for a CustomDomain, with the content of its credentials secret
+type DNSProviderFactory func(config *customdomainv1alpha1.CustomDomainDNSProvider, credentials *corev1.Secret) (DNSProvider, error)
+
+// NewDNSProvider returns the DNSProvider for a DNS provider configuration. The File DNS provider is disabled.
+func NewDNSProvider(config *customdomainv1alpha1.CustomDomainDNSProvider, credentials *corev1.Secret) (DNSProvider, error) {
+	return NewDNSProviderFactory("")(config, credentials)
+}
+
+// NewDNSProviderFactory returns a DNSProviderFactory whose File DNS provider writes its files in a directory. The File
+// DNS provider is disabled if the directory is empty.
+func NewDNSProviderFactory(fileDir string) DNSProviderFactory {
+	return func(config *customdomainv1alpha1.CustomDomainDNSProvider, credentials *corev1.Secret) (DNSProvider, error) {
+		switch config.Type {
+		case customdomainv1alpha1.DNSProviderRoute53:
+			if config.Route53 == nil {
+				return nil, errors.New("route53 must be set for the Route53 DNS provider")
+			}
+			return newRoute53Provider(config.Route53, credentials)
+		case customdomainv1alpha1.DNSProviderRFC2136:
+			if config.RFC2136 == nil {
+				return nil, errors.New("rfc2136 must be set for the RFC2136 DNS provider")
+			}
+			return newRFC2136Provider(config.RFC2136, credentials)
+		case customdomainv1alpha1.DNSProviderFile:
+			if config.File == nil {
+				return nil, errors.New("file must be set for the File DNS provider")
+			}
+			if fileDir == "" {
+				return nil, errors.New("the File DNS provider is disabled, it is enabled by the --file-dns-provider-dir flag of the operator")
+			}
+			if !filepath.IsLocal(config.File.Path) {
+				return nil, fmt.Errorf("file path %s must be relative to the directory of the File DNS provider", config.File.Path)
+			}
+			return &fileProvider{path: filepath.Join(fileDir, config.File.Path)}, nil
+		}
+		return nil, fmt.Errorf("unsupported DNS provider type %s", config.Type)
+	}
+}
+
+// dnsProviderFor builds the DNSProvider of a CustomDomain
+func (r *CustomDomainReconciler) dnsProviderFor(instance *customdomainv1alpha1.CustomDomain) (DNSProvider, error) {
+	config := instance.Spec.DNSProvider
+	var credentials *corev1.Secret
+	if config.CredentialsSecret != nil {
+		credentials = &corev1.Secret{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{
+			Namespace: config.CredentialsSecret.Namespace,
+			Name:      config.CredentialsSecret.Name,
+		}, credentials)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get DNS provider credentials secret %s/%s: %w", config.CredentialsSecret.Namespace, config.CredentialsSecret.Name, err)
+		}
+	}
+	factory := r.DNSProviderFactory
+	if factory == nil {
+		factory = NewDNSProvider
+	}
+	return factory(config, credentials)
+}
+
+// desiredDNSRecord returns the wildcard CNAME record pointing the custom domain to the endpoint
+func desiredDNSRecord(instance *customdomainv1alpha1.CustomDomain) DNSRecord {
+	ttl := instance.Spec.DNSProvider.TTL
+	if ttl == 0 {
+		ttl = dnsProviderDefaultTTL
+	}
+	return DNSRecord{
+		Name:   fmt.Sprintf("*.%s", instance.Spec.Domain),
+		Target: instance.Status.Endpoint,
+		TTL:    ttl,
+	}
+}
+
+// dnsRecordDiff lists the differences between the record published for the CustomDomain and the record it should
+// publish, as "field: published -> desired". The record is up to date if there are none.
+func dnsRecordDiff(instance *customdomainv1alpha1.CustomDomain, record DNSRecord) []string {
+	published := instance.Status.PublishedDNSRecord
+	if published == nil {
+		published = &customdomainv1alpha1.CustomDomainPublishedDNSRecord{}
+	}
+	diff := []string{}
+	if published.Provider != instance.Spec.DNSProvider.Type {
+		diff = append(diff, fmt.Sprintf("provider: %s -> %s", planValue(string(published.Provider)), instance.Spec.DNSProvider.Type))
+	}
+	if published.Name != record.Name {
+		diff = append(diff, fmt.Sprintf("name: %s -> %s", planValue(published.Name), record.Name))
+	}
+	if published.Target != record.Target {
+		diff = append(diff, fmt.Sprintf("target: %s -> %s", planValue(published.Target), record.Target))
+	}
+	if published.TTL != record.TTL {
+		diff = append(diff, fmt.Sprintf("ttl: %d -> %d", published.TTL, record.TTL))
+	}
+	return diff
+}
+
+// publishDNSRecord publishes the custom domain's CNAME record with the DNS provider and records it in the status
+func (r *CustomDomainReconciler) publishDNSRecord(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) error {
+	if instance.Spec.DNSProvider == nil {
+		if instance.Status.PublishedDNSRecord != nil {
+			reqLogger.Info(fmt.Sprintf("DNS provider removed, no longer managing record %s", instance.Status.PublishedDNSRecord.Name))
+			instance.Status.PublishedDNSRecord = nil
+		}
+		return nil
+	}
+
+	record := desiredDNSRecord(instance)
+	if len(dnsRecordDiff(instance, record)) == 0 {
+		return nil
+	}
+
+	err := r.upsertDNSRecord(reqLogger, instance, record)
+	if err != nil {
+		message := fmt.Sprintf("Failed to publish CNAME record %s pointing to %s with the %s DNS provider: %v", record.Name, record.Target, instance.Spec.DNSProvider.Type, err)
+		reqLogger.Info(message)
+		instance.Status.Conditions = setCustomDomainConditionStatus(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDNSRecordPublished, corev1.ConditionFalse, message, UpdateConditionIfReasonOrMessageChange)
+		_ = r.statusUpdate(reqLogger, instance)
+		return err
+	}
+
+	now := metav1.Now()
+	instance.Status.PublishedDNSRecord = &customdomainv1alpha1.CustomDomainPublishedDNSRecord{
+		Provider:      instance.Spec.DNSProvider.Type,
+		Name:          record.Name,
+		Target:        record.Target,
+		TTL:           record.TTL,
+		PublishedTime: &now,
+	}
+	message := fmt.Sprintf("Published CNAME record %s pointing to %s with the %s DNS provider", record.Name, record.Target, instance.Spec.DNSProvider.Type)
+	reqLogger.Info(message)
+	instance.Status.Conditions = setCustomDomainConditionStatus(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDNSRecordPublished, corev1.ConditionTrue, message, UpdateConditionIfReasonOrMessageChange)
+	return nil
+}
+
+// upsertDNSRecord publishes a record, and deletes the previously published record if the custom domain changed
+func (r *CustomDomainReconciler) upsertDNSRecord(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain, record DNSRecord) error {
+	provider, err := r.dnsProviderFor(instance)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), dnsProviderTimeout)
+	defer cancel()
+
+	reqLogger.Info(fmt.Sprintf("Publishing CNAME record %s pointing to %s", record.Name, record.Target))
+	err = provider.UpsertCNAME(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	// A record published with another provider type can't be deleted, as its configuration is gone
+	published := instance.Status.PublishedDNSRecord
+	if published != nil && published.Provider == instance.Spec.DNSProvider.Type && published.Name != record.Name {
+		reqLogger.Info(fmt.Sprintf("Deleting previous CNAME record %s", published.Name))
+		err = provider.DeleteCNAME(ctx, DNSRecord{Name: published.Name, Target: published.Target, TTL: published.TTL})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteDNSRecord deletes the CNAME record published for a CustomDomain
+func (r *CustomDomainReconciler) deleteDNSRecord(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) error {
+	published := instance.Status.PublishedDNSRecord
+	if instance.Spec.DNSProvider == nil || published == nil || published.Provider != instance.Spec.DNSProvider.Type {
+		return nil
+	}
+	provider, err := r.dnsProviderFor(instance)
+	if err != nil {
+		// Don't block the deletion of the CustomDomain on credentials that are already gone
+		if kerr.IsNotFound(err) {
+			reqLogger.Info(fmt.Sprintf("Unable to delete CNAME record %s: %v", published.Name, err))
+			return nil
+		}
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), dnsProviderTimeout)
+	defer cancel()
+
+	reqLogger.Info(fmt.Sprintf("Deleting CNAME record %s", published.Name))
+	return provider.DeleteCNAME(ctx, DNSRecord{Name: published.Name, Target: published.Target, TTL: published.TTL})
+}
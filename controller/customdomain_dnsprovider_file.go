@@ -0,0 +1,55 @@
+package managed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// fileProviderLock serializes the updates of the files written by fileProviders
+var fileProviderLock sync.Mutex
+
+// fileProvider is a DNSProvider writing the records to a JSON file, mapping record names to records
+type fileProvider struct {
+	path string
+}
+
+// UpsertCNAME writes the record to the file
+func (p *fileProvider) UpsertCNAME(_ context.Context, record DNSRecord) error {
+	return p.update(func(records map[string]DNSRecord) {
+		records[record.Name] = record
+	})
+}
+
+// DeleteCNAME removes the record from the file
+func (p *fileProvider) DeleteCNAME(_ context.Context, record DNSRecord) error {
+	return p.update(func(records map[string]DNSRecord) {
+		delete(records, record.Name)
+	})
+}
+
+// update applies a change to the records in the file
+func (p *fileProvider) update(change func(map[string]DNSRecord)) error {
+	fileProviderLock.Lock()
+	defer fileProviderLock.Unlock()
+
+	records := map[string]DNSRecord{}
+	data, err := os.ReadFile(p.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &records)
+		if err != nil {
+			return err
+		}
+	}
+	change(records)
+	data, err = json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p.path, data, 0600)
+}
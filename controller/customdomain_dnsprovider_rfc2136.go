@@ -0,0 +1,106 @@
+package managed
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// tsigFudge is the number of seconds of clock skew allowed by the name server
+	tsigFudge = 300
+	// rfc2136DefaultTSIGAlgorithm is the TSIG algorithm if none is configured
+	rfc2136DefaultTSIGAlgorithm = "hmac-sha256"
+)
+
+// tsigAlgorithms maps the TSIG algorithm names of the API to their names in TSIG records
+var tsigAlgorithms = map[string]string{
+	"hmac-sha1":   dns.HmacSHA1,
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+// rfc2136Provider is a DNSProvider publishing records with RFC2136 dynamic updates, signed with TSIG (RFC8945)
+type rfc2136Provider struct {
+	nameserver    string
+	zone          string
+	tsigKeyName   string
+	tsigAlgorithm string
+	// tsigSecret is the base64 encoded TSIG secret
+	tsigSecret string
+}
+
+func newRFC2136Provider(config *customdomainv1alpha1.RFC2136DNSProvider, credentials *corev1.Secret) (*rfc2136Provider, error) {
+	algorithm := config.TSIGAlgorithm
+	if algorithm == "" {
+		algorithm = rfc2136DefaultTSIGAlgorithm
+	}
+	tsigAlgorithm, ok := tsigAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TSIG algorithm %s", algorithm)
+	}
+	p := &rfc2136Provider{
+		nameserver:    config.Nameserver,
+		zone:          dns.Fqdn(config.Zone),
+		tsigAlgorithm: tsigAlgorithm,
+	}
+	if config.TSIGKeyName == "" {
+		return p, nil
+	}
+
+	if credentials == nil {
+		return nil, errors.New("credentialsSecret must be set to sign RFC2136 updates")
+	}
+	secret := strings.TrimSpace(string(credentials.Data["tsig-secret"]))
+	if decoded, err := base64.StdEncoding.DecodeString(secret); err != nil || len(decoded) == 0 {
+		return nil, fmt.Errorf("secret %s/%s must contain a base64 encoded tsig-secret", credentials.Namespace, credentials.Name)
+	}
+	p.tsigKeyName = dns.CanonicalName(config.TSIGKeyName)
+	p.tsigSecret = secret
+	return p, nil
+}
+
+// UpsertCNAME replaces the records of the name with the CNAME record
+func (p *rfc2136Provider) UpsertCNAME(ctx context.Context, record DNSRecord) error {
+	return p.update(ctx, record, true)
+}
+
+// DeleteCNAME deletes the CNAME records of the name
+func (p *rfc2136Provider) DeleteCNAME(ctx context.Context, record DNSRecord) error {
+	return p.update(ctx, record, false)
+}
+
+// update sends an update message deleting the CNAME records of the name, and adding the record if add is true
+func (p *rfc2136Provider) update(ctx context.Context, record DNSRecord, add bool) error {
+	name := dns.Fqdn(record.Name)
+	msg := &dns.Msg{}
+	msg.SetUpdate(p.zone)
+	msg.RemoveRRset([]dns.RR{&dns.CNAME{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET}}})
+	if add {
+		msg.Insert([]dns.RR{&dns.CNAME{
+			Hdr:    dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: uint32(record.TTL)},
+			Target: dns.Fqdn(record.Target),
+		}})
+	}
+
+	client := &dns.Client{Net: "tcp"}
+	if p.tsigKeyName != "" {
+		msg.SetTsig(p.tsigKeyName, p.tsigAlgorithm, tsigFudge, time.Now().Unix())
+		client.TsigSecret = map[string]string{p.tsigKeyName: p.tsigSecret}
+	}
+	resp, _, err := client.ExchangeContext(ctx, msg, p.nameserver)
+	if err != nil {
+		return err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("name server %s rejected the update of %s: %s", p.nameserver, record.Name, dns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
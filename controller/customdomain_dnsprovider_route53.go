@@ -0,0 +1,85 @@
+package managed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awscredentials "github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// route53Provider is a DNSProvider publishing records in a Route53 hosted zone
+type route53Provider struct {
+	hostedZoneID string
+	client       route53iface.Route53API
+}
+
+func newRoute53Provider(config *customdomainv1alpha1.Route53DNSProvider, credentials *corev1.Secret) (*route53Provider, error) {
+	if credentials == nil {
+		return nil, errors.New("credentialsSecret must be set for the Route53 DNS provider")
+	}
+	accessKeyID := string(credentials.Data["aws_access_key_id"])
+	secretAccessKey := string(credentials.Data["aws_secret_access_key"])
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, fmt.Errorf("secret %s/%s must contain aws_access_key_id and aws_secret_access_key", credentials.Namespace, credentials.Name)
+	}
+	// Route53 is a global service, signed for us-east-1
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: awscredentials.NewStaticCredentials(accessKeyID, secretAccessKey, string(credentials.Data["aws_session_token"])),
+		Region:      aws.String(endpoints.UsEast1RegionID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &route53Provider{
+		hostedZoneID: strings.TrimPrefix(config.HostedZoneID, "/hostedzone/"),
+		client:       route53.New(sess),
+	}, nil
+}
+
+// UpsertCNAME creates or replaces the record
+func (p *route53Provider) UpsertCNAME(ctx context.Context, record DNSRecord) error {
+	return p.change(ctx, route53.ChangeActionUpsert, record)
+}
+
+// DeleteCNAME deletes the record
+func (p *route53Provider) DeleteCNAME(ctx context.Context, record DNSRecord) error {
+	err := p.change(ctx, route53.ChangeActionDelete, record)
+	// Route53 rejects the deletion of records that don't exist
+	var apiErr awserr.Error
+	if errors.As(err, &apiErr) && apiErr.Code() == route53.ErrCodeInvalidChangeBatch && strings.Contains(apiErr.Message(), "not found") {
+		return nil
+	}
+	return err
+}
+
+// change sends a ChangeResourceRecordSets request for a single record
+func (p *route53Provider) change(ctx context.Context, action string, record DNSRecord) error {
+	_, err := p.client.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(p.hostedZoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{{
+				Action: aws.String(action),
+				ResourceRecordSet: &route53.ResourceRecordSet{
+					Name:            aws.String(record.Name),
+					Type:            aws.String(route53.RRTypeCname),
+					TTL:             aws.Int64(record.TTL),
+					ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(record.Target)}},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("route53 %s of %s failed: %w", action, record.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+package managed
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/miekg/dns"
+	configv1 "github.com/openshift/api/config/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// readFileRecords returns the records written by a fileProvider
+func readFileRecords(t *testing.T, path string) map[string]DNSRecord {
+	records := map[string]DNSRecord{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read records: (%v)", err)
+	}
+	if err = json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("unmarshal records: (%v)", err)
+	}
+	return records
+}
+
+// TestCustomDomainDNSProvider tests publishing, updating and deleting the CNAME record with the file DNS provider
+func TestCustomDomainDNSProvider(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain  = "cluster1.x8s0.s1.openshiftapps.com"
+		instanceName   = "test"
+		userNamespace  = "my-project"
+		userSecretName = "my-secret"
+	)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "records.json")
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName}}
+
+	customdomain := &customdomainv1alpha1.CustomDomain{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: instanceName,
+		},
+		Spec: customdomainv1alpha1.CustomDomainSpec{
+			Domain: "apps.foo.com",
+			Scope:  "External",
+			Certificate: corev1.SecretReference{
+				Name:      userSecretName,
+				Namespace: userNamespace,
+			},
+			DNSProvider: &customdomainv1alpha1.CustomDomainDNSProvider{
+				Type: customdomainv1alpha1.DNSProviderFile,
+				TTL:  60,
+				File: &customdomainv1alpha1.FileDNSProvider{Path: "records.json"},
+			},
+		},
+	}
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, newTestSecret(userSecretName, userNamespace), customdomain, newTestDNSRecord(instanceName, clusterDomain))
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), DNSProviderFactory: NewDNSProviderFactory(dir)}
+
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if err := cl.Get(context.TODO(), req.NamespacedName, customdomain); err != nil {
+		t.Fatalf("get custom domain: (%v)", err)
+	}
+	record, ok := readFileRecords(t, path)["*.apps.foo.com"]
+	if !ok || record.Target != customdomain.Status.Endpoint || record.TTL != 60 {
+		t.Errorf("unexpected record %+v for endpoint %s", record, customdomain.Status.Endpoint)
+	}
+	published := customdomain.Status.PublishedDNSRecord
+	if published == nil || published.Name != "*.apps.foo.com" || published.Target != customdomain.Status.Endpoint {
+		t.Errorf("unexpected published record %+v", published)
+	}
+	if cond := FindCustomDomainCondition(customdomain.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDNSRecordPublished); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("expected DNSRecordPublished condition to be true, got %+v", cond)
+	}
+
+	// pinning the endpoint host updates the target of the record
+	customdomain.Spec.EndpointHost = "pinned"
+	if err := cl.Update(context.TODO(), customdomain); err != nil {
+		t.Fatalf("update custom domain: (%v)", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if err := cl.Get(context.TODO(), req.NamespacedName, customdomain); err != nil {
+		t.Fatalf("get custom domain: (%v)", err)
+	}
+	if target := readFileRecords(t, path)["*.apps.foo.com"].Target; target != customdomain.Status.Endpoint || !strings.HasPrefix(target, "pinned.") {
+		t.Errorf("expected record to point to the pinned endpoint, got %s", target)
+	}
+
+	// changing the custom domain replaces the record
+	changed := customdomain.DeepCopy()
+	changed.Spec.Domain = "apps.bar.com"
+	if err := r.publishDNSRecord(log, changed); err != nil {
+		t.Fatalf("publish record: (%v)", err)
+	}
+	records := readFileRecords(t, path)
+	if _, ok := records["*.apps.foo.com"]; ok {
+		t.Errorf("previous record was not deleted: %+v", records)
+	}
+	if _, ok := records["*.apps.bar.com"]; !ok {
+		t.Errorf("record of the new custom domain was not published: %+v", records)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove records: (%v)", err)
+	}
+	if err := r.publishDNSRecord(log, customdomain); err != nil {
+		t.Fatalf("publish record: (%v)", err)
+	}
+
+	// deleting the CustomDomain deletes the record
+	if err := cl.Delete(context.TODO(), customdomain); err != nil {
+		t.Fatalf("delete custom domain: (%v)", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if records := readFileRecords(t, path); len(records) != 0 {
+		t.Errorf("record was not deleted: %+v", records)
+	}
+}
+
+// TestFileProviderDirectory tests that the File DNS provider only writes in its directory
+func TestFileProviderDirectory(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name      string
+		factory   DNSProviderFactory
+		path      string
+		expectErr bool
+	}{
+		{name: "disabled", factory: NewDNSProvider, path: "records.json", expectErr: true},
+		{name: "in the directory", factory: NewDNSProviderFactory(dir), path: "records.json"},
+		{name: "absolute path", factory: NewDNSProviderFactory(dir), path: filepath.Join(dir, "records.json"), expectErr: true},
+		{name: "out of the directory", factory: NewDNSProviderFactory(dir), path: "../records.json", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := tt.factory(&customdomainv1alpha1.CustomDomainDNSProvider{
+				Type: customdomainv1alpha1.DNSProviderFile,
+				File: &customdomainv1alpha1.FileDNSProvider{Path: tt.path},
+			}, nil)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error: (%v)", err)
+			}
+			if err == nil && provider.(*fileProvider).path != filepath.Join(dir, tt.path) {
+				t.Errorf("unexpected path %s", provider.(*fileProvider).path)
+			}
+		})
+	}
+}
+
+// fakeRoute53 records the changes sent to the Route53 API, and rejects the deletion of records
+type fakeRoute53 struct {
+	route53iface.Route53API
+	inputs []*route53.ChangeResourceRecordSetsInput
+}
+
+func (f *fakeRoute53) ChangeResourceRecordSetsWithContext(_ aws.Context, input *route53.ChangeResourceRecordSetsInput, _ ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	f.inputs = append(f.inputs, input)
+	if aws.StringValue(input.ChangeBatch.Changes[0].Action) == route53.ChangeActionDelete {
+		return nil, awserr.New(route53.ErrCodeInvalidChangeBatch, "Tried to delete resource record set [name='\\052.apps.foo.com.', type='CNAME'] but it was not found", nil)
+	}
+	return &route53.ChangeResourceRecordSetsOutput{}, nil
+}
+
+// TestRoute53Provider tests the changes sent to the Route53 API
+func TestRoute53Provider(t *testing.T) {
+	provider, err := newRoute53Provider(&customdomainv1alpha1.Route53DNSProvider{HostedZoneID: "/hostedzone/Z123"}, &corev1.Secret{
+		Data: map[string][]byte{
+			"aws_access_key_id":     []byte("AKID"),
+			"aws_secret_access_key": []byte("secret"),
+			"aws_session_token":     []byte("token"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("new provider: (%v)", err)
+	}
+	fake := &fakeRoute53{}
+	provider.client = fake
+
+	record := DNSRecord{Name: "*.apps.foo.com", Target: "test.apps.example.com", TTL: 300}
+	if err = provider.UpsertCNAME(context.TODO(), record); err != nil {
+		t.Errorf("upsert: (%v)", err)
+	}
+	if err = provider.DeleteCNAME(context.TODO(), record); err != nil {
+		t.Errorf("deleting a missing record should succeed: (%v)", err)
+	}
+	actions := []string{}
+	for _, input := range fake.inputs {
+		change := input.ChangeBatch.Changes[0]
+		actions = append(actions, aws.StringValue(change.Action))
+		if aws.StringValue(input.HostedZoneId) != "Z123" {
+			t.Errorf("unexpected hosted zone %s", aws.StringValue(input.HostedZoneId))
+		}
+		set := change.ResourceRecordSet
+		if aws.StringValue(set.Name) != record.Name || aws.StringValue(set.Type) != route53.RRTypeCname || aws.Int64Value(set.TTL) != record.TTL ||
+			len(set.ResourceRecords) != 1 || aws.StringValue(set.ResourceRecords[0].Value) != record.Target {
+			t.Errorf("unexpected record set %s", set)
+		}
+	}
+	if strings.Join(actions, ",") != "UPSERT,DELETE" {
+		t.Errorf("unexpected actions %v", actions)
+	}
+
+	_, err = newRoute53Provider(&customdomainv1alpha1.Route53DNSProvider{HostedZoneID: "Z123"}, &corev1.Secret{})
+	if err == nil {
+		t.Error("expected an error for missing credentials")
+	}
+}
+
+// rfc2136Update is what the stand-in name server received
+type rfc2136Update struct {
+	msg *dns.Msg
+	// tsigErr is the result of the verification of the TSIG record
+	tsigErr error
+}
+
+// serveRFC2136 answers updates over TCP with the given RCode, and sends the received updates to the channel
+func serveRFC2136(t *testing.T, rcode int, tsigSecret map[string]string, updates chan<- rfc2136Update) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: (%v)", err)
+	}
+	server := &dns.Server{
+		Listener:   listener,
+		TsigSecret: tsigSecret,
+		// the default accepts queries and notifies only
+		MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, msg *dns.Msg) {
+			update := rfc2136Update{msg: msg}
+			resp := &dns.Msg{}
+			resp.SetRcode(msg, rcode)
+			if tsig := msg.IsTsig(); tsig != nil {
+				update.tsigErr = w.TsigStatus()
+				resp.SetTsig(tsig.Hdr.Name, tsig.Algorithm, tsigFudge, time.Now().Unix())
+			}
+			updates <- update
+			_ = w.WriteMsg(resp)
+		}),
+	}
+	go func() { _ = server.ActivateAndServe() }()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return listener.Addr().String()
+}
+
+// TestRFC2136Provider tests the update messages sent to the name server
+func TestRFC2136Provider(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString([]byte("secret"))
+	credentials := &corev1.Secret{
+		Data: map[string][]byte{"tsig-secret": []byte(secret)},
+	}
+	tsigSecret := map[string]string{"custom-domains.": secret}
+	record := DNSRecord{Name: "*.apps.foo.com", Target: "test.apps.example.com", TTL: 300}
+
+	updates := make(chan rfc2136Update, 1)
+	config := &customdomainv1alpha1.RFC2136DNSProvider{
+		Nameserver:  serveRFC2136(t, dns.RcodeSuccess, tsigSecret, updates),
+		Zone:        "foo.com",
+		TSIGKeyName: "custom-domains",
+	}
+	provider, err := newRFC2136Provider(config, credentials)
+	if err != nil {
+		t.Fatalf("new provider: (%v)", err)
+	}
+	if err = provider.UpsertCNAME(context.TODO(), record); err != nil {
+		t.Fatalf("upsert: (%v)", err)
+	}
+	update := <-updates
+	if update.msg.Opcode != dns.OpcodeUpdate || len(update.msg.Question) != 1 || update.msg.Question[0].Name != "foo.com." {
+		t.Errorf("unexpected update %s", update.msg)
+	}
+	if len(update.msg.Ns) != 2 || update.msg.Ns[0].Header().Class != dns.ClassANY || update.msg.Ns[1].Header().Class != dns.ClassINET {
+		t.Errorf("expected the deletion and addition of the CNAME record, got %v", update.msg.Ns)
+	}
+	if cname, ok := update.msg.Ns[len(update.msg.Ns)-1].(*dns.CNAME); !ok || cname.Target != "test.apps.example.com." || cname.Hdr.Name != "*.apps.foo.com." {
+		t.Errorf("unexpected record %v", update.msg.Ns[len(update.msg.Ns)-1])
+	}
+	if tsig := update.msg.IsTsig(); tsig == nil || tsig.Hdr.Name != "custom-domains." || update.tsigErr != nil {
+		t.Errorf("expected the update to be signed with key custom-domains., got (%v): (%v)", tsig, update.tsigErr)
+	}
+
+	config.Nameserver = serveRFC2136(t, dns.RcodeRefused, tsigSecret, updates)
+	provider, err = newRFC2136Provider(config, credentials)
+	if err != nil {
+		t.Fatalf("new provider: (%v)", err)
+	}
+	if err = provider.DeleteCNAME(context.TODO(), record); err == nil {
+		t.Error("expected a refused update to fail")
+	}
+	if update = <-updates; len(update.msg.Ns) != 1 {
+		t.Errorf("expected only the deletion of the CNAME record, got %v", update.msg.Ns)
+	}
+
+	config.TSIGAlgorithm = "hmac-md5"
+	if _, err = newRFC2136Provider(config, credentials); err == nil {
+		t.Error("expected an error for an unsupported algorithm")
+	}
+}
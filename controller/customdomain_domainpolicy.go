@@ -0,0 +1,129 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	"golang.org/x/net/publicsuffix"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DomainPolicy restricts the domains CustomDomains can register
+type DomainPolicy struct {
+	// AllowedDomainSuffixes lists the domains custom domains must be equal to or a subdomain of. Any domain if empty.
+	AllowedDomainSuffixes []string
+	// ForbiddenDomains lists the domain patterns custom domains must not be equal to or a subdomain of
+	ForbiddenDomains []string
+	// ForbidPublicSuffixes forbids custom domains that are public suffixes
+	ForbidPublicSuffixes bool
+	// ForbidClusterBaseDomain forbids custom domains under the base domain of the cluster
+	ForbidClusterBaseDomain bool
+}
+
+// normalizeDomain lowercases a domain and removes its trailing dot
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(domain, "."))
+}
+
+// domainHasAllowedSuffix returns true if the domain is equal to or a subdomain of one of the suffixes
+func domainHasAllowedSuffix(domain string, suffixes []string) bool {
+	domain = normalizeDomain(domain)
+	for _, suffix := range suffixes {
+		suffix = normalizeDomain(suffix)
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validDomainPattern returns true if every label of the pattern is a DNS label or "*"
+func validDomainPattern(pattern string) bool {
+	for _, label := range strings.Split(normalizeDomain(pattern), ".") {
+		if label != "*" && len(validation.IsDNS1123Label(label)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesDomainPattern returns true if the domain is equal to or a subdomain of a domain matched by the pattern,
+// where a "*" label matches any label
+func matchesDomainPattern(domain, pattern string) bool {
+	domainLabels := strings.Split(normalizeDomain(domain), ".")
+	patternLabels := strings.Split(normalizeDomain(pattern), ".")
+	if len(domainLabels) < len(patternLabels) {
+		return false
+	}
+	domainLabels = domainLabels[len(domainLabels)-len(patternLabels):]
+	for i, label := range patternLabels {
+		if label != "*" && label != domainLabels[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// domainPolicyViolation returns why the domain policy doesn't allow a domain, or an empty string if it does
+func domainPolicyViolation(policy DomainPolicy, domain, baseDomain string) string {
+	if policy.ForbidPublicSuffixes {
+		if suffix, _ := publicsuffix.PublicSuffix(normalizeDomain(domain)); suffix == normalizeDomain(domain) {
+			return fmt.Sprintf("domain %s is a public suffix", domain)
+		}
+	}
+	if policy.ForbidClusterBaseDomain && baseDomain != "" && domainHasAllowedSuffix(domain, []string{baseDomain}) {
+		return fmt.Sprintf("domain %s is under the base domain of the cluster %s", domain, baseDomain)
+	}
+	for _, pattern := range policy.ForbiddenDomains {
+		if matchesDomainPattern(domain, pattern) {
+			return fmt.Sprintf("domain %s is forbidden by %s", domain, pattern)
+		}
+	}
+	if len(policy.AllowedDomainSuffixes) > 0 && !domainHasAllowedSuffix(domain, policy.AllowedDomainSuffixes) {
+		return fmt.Sprintf("domain %s is not a subdomain of the allowed domains: %s", domain, strings.Join(policy.AllowedDomainSuffixes, ", "))
+	}
+	return ""
+}
+
+// clusterBaseDomain returns the base domain of the cluster, or an empty string if the DNS config doesn't exist
+func clusterBaseDomain(ctx context.Context, reader client.Reader) (string, error) {
+	dnsConfig := &configv1.DNS{}
+	err := reader.Get(ctx, types.NamespacedName{Name: dnsConfigName}, dnsConfig)
+	if err != nil {
+		if kerr.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return dnsConfig.Spec.BaseDomain, nil
+}
+
+// domainPolicyProblem returns why the domain policy doesn't allow the custom domain, or an empty string if it does
+func (r *CustomDomainReconciler) domainPolicyProblem(ctx context.Context, instance *customdomainv1alpha1.CustomDomain) (string, error) {
+	baseDomain, err := clusterBaseDomain(ctx, r.Client)
+	if err != nil {
+		return "", err
+	}
+	if violation := domainPolicyViolation(r.config().DomainPolicy, instance.Spec.Domain, baseDomain); violation != "" {
+		return fmt.Sprintf("Custom domain not allowed: %s", violation), nil
+	}
+	return "", nil
+}
+
+// checkDomainPolicy returns true if the domain policy doesn't allow the custom domain. Such CustomDomains stop being
+// reconciled and are reported with the DomainNotAllowed condition. Their existing resources are left untouched.
+func (r *CustomDomainReconciler) checkDomainPolicy(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) (bool, error) {
+	problem, err := r.domainPolicyProblem(context.TODO(), instance)
+	if err != nil {
+		return false, err
+	}
+	return r.reportBlocker(reqLogger, instance, customdomainv1alpha1.CustomDomainConditionDomainNotAllowed, problem,
+		"The custom domain is allowed by the domain policy")
+}
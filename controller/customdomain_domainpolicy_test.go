@@ -0,0 +1,127 @@
+package managed
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestDomainPolicyViolation(t *testing.T) {
+	const baseDomain = "cluster1.x8s0.s1.openshiftapps.com"
+	defaults := DefaultOperatorConfig().DomainPolicy
+	tests := []struct {
+		name          string
+		policy        DomainPolicy
+		domain        string
+		expectAllowed bool
+	}{
+		{name: "custom domain", policy: defaults, domain: "apps.foo.com", expectAllowed: true},
+		{name: "public suffix", policy: defaults, domain: "co.uk"},
+		{name: "private public suffix", policy: defaults, domain: "github.io"},
+		{name: "public suffixes allowed", policy: DomainPolicy{}, domain: "github.io", expectAllowed: true},
+		{name: "cluster base domain", policy: defaults, domain: baseDomain},
+		{name: "under cluster base domain", policy: defaults, domain: "apps." + baseDomain + "."},
+		{name: "cluster base domain allowed", policy: DomainPolicy{}, domain: "apps." + baseDomain, expectAllowed: true},
+		{
+			name:   "forbidden pattern",
+			policy: DomainPolicy{ForbiddenDomains: []string{"apps.*.openshiftapps.com"}},
+			domain: "team.apps.cluster2.openshiftapps.com",
+		},
+		{
+			name:          "forbidden pattern not matched",
+			policy:        DomainPolicy{ForbiddenDomains: []string{"apps.*.openshiftapps.com"}},
+			domain:        "apps.openshiftapps.com.foo.com",
+			expectAllowed: true,
+		},
+		{
+			name:          "allowed suffix",
+			policy:        DomainPolicy{AllowedDomainSuffixes: []string{"foo.com"}},
+			domain:        "Apps.Foo.com",
+			expectAllowed: true,
+		},
+		{
+			name:   "not an allowed suffix",
+			policy: DomainPolicy{AllowedDomainSuffixes: []string{"foo.com"}},
+			domain: "apps.barfoo.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violation := domainPolicyViolation(tt.policy, tt.domain, baseDomain)
+			if tt.expectAllowed != (violation == "") {
+				t.Errorf("expected allowed %v, got violation %q", tt.expectAllowed, violation)
+			}
+		})
+	}
+}
+
+// TestCustomDomainDomainPolicy tests that CustomDomains out of policy are reported and not reconciled
+func TestCustomDomainDomainPolicy(t *testing.T) {
+	const clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+	instance := newQuotaTestCustomDomain("tenant", "External", 0)
+	instance.Spec.Domain = "apps.foo.com"
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, newTestSecret("my-secret", "my-project"), instance)
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Config: newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.DomainPolicy.AllowedDomainSuffixes = []string{"example.com"}
+	})}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "tenant"}}
+	res, err := r.Reconcile(context.TODO(), req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if !res.Requeue {
+		t.Error("expected a CustomDomain out of policy to be checked again")
+	}
+	if err := cl.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+		t.Fatalf("get custom domain: (%v)", err)
+	}
+	cond := FindCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDomainNotAllowed)
+	if cond == nil || cond.Status != corev1.ConditionTrue || instance.Status.State != customdomainv1alpha1.CustomDomainStateNotReady {
+		t.Errorf("expected DomainNotAllowed condition and NotReady state, got %+v", instance.Status)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: "tenant", Namespace: ingressOperatorNamespace}, &operatorv1.IngressController{})
+	if !kerr.IsNotFound(err) {
+		t.Errorf("expected no ingresscontroller for a CustomDomain out of policy: (%v)", err)
+	}
+
+	// allowing the domain clears the condition
+	r.Config = newTestOperatorConfigStore(func(c *OperatorConfig) {})
+	notAllowed, err := r.checkDomainPolicy(log, instance)
+	if err != nil {
+		t.Fatalf("check domain policy: (%v)", err)
+	}
+	cond = FindCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDomainNotAllowed)
+	if notAllowed || cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Errorf("expected the custom domain to be allowed, got %+v", cond)
+	}
+}
+
+// TestCustomDomainValidatorDomainPolicy tests that custom domains out of policy are denied
+func TestCustomDomainValidatorDomainPolicy(t *testing.T) {
+	const clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+	cl := NewTestMock(t, newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})...)
+	v := &CustomDomainValidator{Reader: cl, Config: newTestOperatorConfigStore(func(c *OperatorConfig) {})}
+
+	instance := newQuotaTestCustomDomain("tenant", "External", 0)
+	if _, err := v.ValidateCreate(context.TODO(), instance); err != nil {
+		t.Errorf("expected an allowed domain to be admitted: (%v)", err)
+	}
+	moved := instance.DeepCopy()
+	moved.Spec.Domain = "apps." + clusterDomain
+	if _, err := v.ValidateCreate(context.TODO(), moved); err == nil {
+		t.Error("expected a domain under the cluster base domain to be denied")
+	}
+	if _, err := v.ValidateUpdate(context.TODO(), instance, moved); err == nil {
+		t.Error("expected moving to a domain under the cluster base domain to be denied")
+	}
+}
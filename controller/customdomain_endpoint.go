@@ -0,0 +1,61 @@
+package managed
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	routev1 "github.com/openshift/api/route/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// letters used by endpointHostFor
+var letters = []rune("abcdefghijklmnopqrstuvwxyz")
+
+// endpointHostFor returns the host label of a CustomDomain's endpoint. Unless it is set in the spec, it is derived from
+// the CustomDomain's name, so that the endpoint doesn't change when the CustomDomain is recreated or its status is lost.
+func (r *CustomDomainReconciler) endpointHostFor(instance *customdomainv1alpha1.CustomDomain) string {
+	if instance.Spec.EndpointHost != "" {
+		return instance.Spec.EndpointHost
+	}
+	sum := sha256.Sum256([]byte(instance.Name))
+	b := make([]rune, r.config().EndpointHostLength)
+	for i := range b {
+		b[i] = letters[int(sum[i])%len(letters)]
+	}
+	return string(b)
+}
+
+// reader returns the client used for lookups that should not be cached, such as routes
+func (r *CustomDomainReconciler) reader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// validateEndpoint checks that an endpoint is a valid DNS name that is not already used by a route
+func (r *CustomDomainReconciler) validateEndpoint(instance *customdomainv1alpha1.CustomDomain, endpoint string) error {
+	host := r.endpointHostFor(instance)
+	if errs := validation.IsDNS1123Label(host); len(errs) > 0 {
+		return fmt.Errorf("endpoint host %s is not a valid DNS label: %s", host, strings.Join(errs, ", "))
+	}
+	if errs := validation.IsDNS1123Subdomain(endpoint); len(errs) > 0 {
+		return fmt.Errorf("endpoint %s is not a valid DNS name: %s", endpoint, strings.Join(errs, ", "))
+	}
+
+	routeList := &routev1.RouteList{}
+	err := r.reader().List(context.TODO(), routeList)
+	if err != nil {
+		return err
+	}
+	for _, route := range routeList.Items {
+		if route.Spec.Host == endpoint {
+			return fmt.Errorf("endpoint %s is already used by route %s/%s", endpoint, route.Namespace, route.Name)
+		}
+	}
+	return nil
+}
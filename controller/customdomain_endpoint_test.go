@@ -0,0 +1,137 @@
+package managed
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TestCustomDomainEndpointHost tests that endpoints are derived from the CR name or pinned by spec.endpointHost
+func TestCustomDomainEndpointHost(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain  = "cluster1.x8s0.s1.openshiftapps.com"
+		instanceName   = "test"
+		userNamespace  = "my-project"
+		userSecretName = "my-secret"
+	)
+	ingressDomain := instanceName + "." + clusterDomain
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName}}
+
+	newCustomDomain := func(endpointHost string) *customdomainv1alpha1.CustomDomain {
+		return &customdomainv1alpha1.CustomDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName},
+			Spec: customdomainv1alpha1.CustomDomainSpec{
+				Domain: "apps.foo.com",
+				Scope:  "External",
+				Certificate: corev1.SecretReference{
+					Name:      userSecretName,
+					Namespace: userNamespace,
+				},
+				EndpointHost: endpointHost,
+			},
+		}
+	}
+	reconcileEndpoint := func(t *testing.T, objs ...client.Object) (client.Client, string, error) {
+		objs = append(objs, newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})...)
+		objs = append(objs, newTestSecret(userSecretName, userNamespace), newTestDNSRecord(instanceName, clusterDomain))
+		cl := NewTestMock(t, objs...)
+		r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+		_, err := r.Reconcile(context.TODO(), req)
+		customdomain := &customdomainv1alpha1.CustomDomain{}
+		if getErr := cl.Get(context.TODO(), req.NamespacedName, customdomain); getErr != nil {
+			t.Fatalf("get custom domain: (%v)", getErr)
+		}
+		return cl, customdomain.Status.Endpoint, err
+	}
+
+	t.Run("derived", func(t *testing.T) {
+		_, first, err := reconcileEndpoint(t, newCustomDomain(""))
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		// A recreated CustomDomain gets the same endpoint
+		_, second, err := reconcileEndpoint(t, newCustomDomain(""))
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if first == "" || first != second {
+			t.Errorf("endpoint is not deterministic: (%s) and (%s)", first, second)
+		}
+	})
+
+	t.Run("pinned", func(t *testing.T) {
+		cl, endpoint, err := reconcileEndpoint(t, newCustomDomain("router"))
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if endpoint != "router."+ingressDomain {
+			t.Errorf("Status.Endpoint mismatch: (%s)", endpoint)
+		}
+
+		// Changing the pinned host changes the endpoint
+		customdomain := &customdomainv1alpha1.CustomDomain{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, customdomain); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		customdomain.Spec.EndpointHost = "edge"
+		if err := cl.Update(context.TODO(), customdomain); err != nil {
+			t.Fatalf("update custom domain: (%v)", err)
+		}
+		r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+		if _, err := r.Reconcile(context.TODO(), req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if err := cl.Get(context.TODO(), req.NamespacedName, customdomain); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		if customdomain.Status.Endpoint != "edge."+ingressDomain {
+			t.Errorf("Status.Endpoint was not updated: (%s)", customdomain.Status.Endpoint)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cl, endpoint, err := reconcileEndpoint(t, newCustomDomain("Not_A_Label"))
+		if err == nil {
+			t.Fatal("reconcile did not return an error for an invalid endpoint host")
+		}
+		if endpoint != "" {
+			t.Errorf("Status.Endpoint was set: (%s)", endpoint)
+		}
+		assertEndpointHostCondition(t, cl)
+	})
+
+	t.Run("route collision", func(t *testing.T) {
+		route := &routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-route", Namespace: userNamespace},
+			Spec:       routev1.RouteSpec{Host: "router." + ingressDomain},
+		}
+		cl, _, err := reconcileEndpoint(t, newCustomDomain("router"), route)
+		if err == nil {
+			t.Fatal("reconcile did not return an error for an endpoint used by a route")
+		}
+		assertEndpointHostCondition(t, cl)
+	})
+}
+
+func assertEndpointHostCondition(t *testing.T, cl client.Client) {
+	t.Helper()
+	customdomain := &customdomainv1alpha1.CustomDomain{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "test"}, customdomain); err != nil {
+		t.Fatalf("get custom domain: (%v)", err)
+	}
+	if FindCustomDomainCondition(customdomain.Status.Conditions, customdomainv1alpha1.CustomDomainConditionInvalidEndpointHost) == nil {
+		t.Errorf("expected condition (%s)", customdomainv1alpha1.CustomDomainConditionInvalidEndpointHost)
+	}
+}
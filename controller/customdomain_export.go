@@ -0,0 +1,106 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CustomDomainExport holds the objects serving a CustomDomain once they are managed without the operator
+type CustomDomainExport struct {
+	// IngressController is the ingresscontroller of the CustomDomain, without the operator's management label
+	IngressController *operatorv1.IngressController
+	// Secret is the default certificate of the ingresscontroller in the openshift-ingress namespace
+	Secret *corev1.Secret
+	// Notes are what stops being done for the CustomDomain once the operator no longer manages it
+	Notes []string
+}
+
+// Export renders the ingresscontroller and certificate secret of a CustomDomain as the cluster ingress operator owns
+// them once the CustomDomain is deprecated, see returnIngressToClusterIngressOperator. The existing ingresscontroller
+// is exported as is, without the operator's management label, and the one Reconcile would create is rendered if it
+// doesn't exist yet. The certificate is taken from the user's TLS secret. Nothing is written.
+func (r *CustomDomainReconciler) Export(ctx context.Context, instance *customdomainv1alpha1.CustomDomain) (*CustomDomainExport, error) {
+	export := &CustomDomainExport{}
+
+	userSecret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{
+		Namespace: instance.Spec.Certificate.Namespace,
+		Name:      instance.Spec.Certificate.Name,
+	}, userSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	ingress, err := r.desiredIngressFor(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	live := &operatorv1.IngressController{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: ingressOperatorNamespace, Name: ingress.name}, live)
+	if err != nil {
+		if !kerr.IsNotFound(err) {
+			return nil, err
+		}
+		live, err = r.newIngressController(*instance, ingress.name, ingress.domain, ingress.scope, ingress.strategy, instance.Name)
+		if err != nil {
+			return nil, err
+		}
+		export.Notes = append(export.Notes, fmt.Sprintf("IngressController %s doesn't exist yet, it is rendered from the CustomDomain", ingress.name))
+	} else if _, ok := live.Labels[managedLabelName]; !ok {
+		export.Notes = append(export.Notes, fmt.Sprintf("IngressController %s is not managed by the custom domains operator", ingress.name))
+	} else {
+		// applying the export doesn't remove the label from the live objects, so the finalizer would still delete them
+		export.Notes = append(export.Notes, fmt.Sprintf("The %s label must be removed from IngressController %s and Secret %s/%s before the CustomDomain is deleted, "+
+			"or its finalizer deletes them: kubectl apply doesn't remove labels missing from the manifests. Setting the deletionPolicy of the CustomDomain to Retain removes it.",
+			managedLabelName, ingress.name, ingressNamespace, instance.Name))
+	}
+	if migration := instance.Status.ScopeMigration; migration != nil {
+		export.Notes = append(export.Notes, fmt.Sprintf("The scope migration from IngressController %s to %s is not finished, the previous IngressController is not exported",
+			migration.PreviousIngressController, migration.IngressController))
+	}
+
+	labels := map[string]string{}
+	for k, v := range live.Labels {
+		if k != managedLabelName {
+			labels[k] = v
+		}
+	}
+	if len(labels) == 0 {
+		labels = nil
+	}
+	export.IngressController = &operatorv1.IngressController{
+		TypeMeta: metav1.TypeMeta{APIVersion: operatorv1.GroupVersion.String(), Kind: "IngressController"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        live.Name,
+			Namespace:   live.Namespace,
+			Labels:      labels,
+			Annotations: live.Annotations,
+		},
+		Spec: *live.Spec.DeepCopy(),
+	}
+
+	secretName := instance.Name
+	if live.Spec.DefaultCertificate != nil {
+		secretName = live.Spec.DefaultCertificate.Name
+	}
+	export.Secret = &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: ingressNamespace},
+		Type:       userSecret.Type,
+		Data:       userSecret.Data,
+	}
+	export.Notes = append(export.Notes, fmt.Sprintf("Secret %s/%s is no longer copied from %s/%s, the renewed certificates must be written to it",
+		ingressNamespace, secretName, userSecret.Namespace, userSecret.Name))
+	if instance.Spec.DNSProvider != nil {
+		export.Notes = append(export.Notes, fmt.Sprintf("The CNAME record of *.%s is no longer published by the %s DNS provider, nor deleted with the CustomDomain",
+			instance.Spec.Domain, instance.Spec.DNSProvider.Type))
+	}
+	return export, nil
+}
@@ -0,0 +1,95 @@
+package managed
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TestCustomDomainExport tests that CustomDomains are exported as the ingresscontroller and secret the cluster ingress
+// operator owns once they are deprecated, before and after their ingresscontroller is created
+func TestCustomDomainExport(t *testing.T) {
+	const clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+	instance := newQuotaTestCustomDomain("test", "Internal", 0)
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, newTestSecret("my-secret", "my-project"), instance)
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Config: newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.VerifyDelegation = false
+	})}
+
+	checkExport := func(t *testing.T, export *CustomDomainExport) {
+		ingress := export.IngressController
+		if ingress.Name != "test" || ingress.Namespace != ingressOperatorNamespace || ingress.Kind != "IngressController" {
+			t.Errorf("expected the test ingresscontroller, got %+v", ingress.ObjectMeta)
+		}
+		if _, ok := ingress.Labels[managedLabelName]; ok {
+			t.Errorf("expected the management label to be removed, got %v", ingress.Labels)
+		}
+		if ingress.ResourceVersion != "" || ingress.Status.Domain != "" {
+			t.Errorf("expected no server fields, got %+v", ingress)
+		}
+		if ingress.Spec.Domain != "test."+clusterDomain || ingress.Spec.EndpointPublishingStrategy.LoadBalancer.Scope != operatorv1.InternalLoadBalancer {
+			t.Errorf("expected an internal ingresscontroller for the cluster domain, got %+v", ingress.Spec)
+		}
+		if ingress.Spec.DefaultCertificate == nil || ingress.Spec.DefaultCertificate.Name != export.Secret.Name {
+			t.Errorf("expected the exported secret to be the default certificate, got %+v", ingress.Spec.DefaultCertificate)
+		}
+		if export.Secret.Namespace != ingressNamespace || string(export.Secret.Data["tls.crt"]) != "DEADBEEF" || len(export.Secret.Labels) != 0 {
+			t.Errorf("expected an unlabeled copy of the TLS secret in %s, got %+v", ingressNamespace, export.Secret)
+		}
+	}
+
+	export, err := r.Export(context.TODO(), instance)
+	if err != nil {
+		t.Fatalf("export: (%v)", err)
+	}
+	checkExport(t, export)
+	if len(export.Notes) == 0 || !strings.Contains(export.Notes[0], "rendered from the CustomDomain") {
+		t.Errorf("expected a note on the rendered ingresscontroller, got %v", export.Notes)
+	}
+
+	if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}}); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	live := &operatorv1.IngressController{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "test", Namespace: ingressOperatorNamespace}, live); err != nil {
+		t.Fatalf("get ingresscontroller: (%v)", err)
+	}
+	live.Labels["team"] = "web"
+	live.Status.Domain = live.Spec.Domain
+	if err := cl.Update(context.TODO(), live); err != nil {
+		t.Fatalf("update ingresscontroller: (%v)", err)
+	}
+
+	export, err = r.Export(context.TODO(), instance)
+	if err != nil {
+		t.Fatalf("export: (%v)", err)
+	}
+	checkExport(t, export)
+	if export.IngressController.Labels["team"] != "web" {
+		t.Errorf("expected the other labels to be kept, got %v", export.IngressController.Labels)
+	}
+	if len(export.Notes) != 2 || !strings.Contains(export.Notes[0], "must be removed") || !strings.Contains(export.Notes[1], "no longer copied") {
+		t.Errorf("expected notes on the live management label and the secret copy, got %v", export.Notes)
+	}
+
+	// an ingresscontroller left in place without the label is exported as is
+	delete(live.Labels, managedLabelName)
+	if err := cl.Update(context.TODO(), live); err != nil {
+		t.Fatalf("update ingresscontroller: (%v)", err)
+	}
+	export, err = r.Export(context.TODO(), instance)
+	if err != nil {
+		t.Fatalf("export: (%v)", err)
+	}
+	checkExport(t, export)
+	if len(export.Notes) != 2 || !strings.Contains(export.Notes[0], "not managed") {
+		t.Errorf("expected a note on the unmanaged ingresscontroller, got %v", export.Notes)
+	}
+}
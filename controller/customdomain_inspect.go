@@ -0,0 +1,103 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatoringressv1 "github.com/openshift/api/operatoringress/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// routerDeploymentLabelName labels the router pods of an ingresscontroller with its name
+const routerDeploymentLabelName = "ingresscontroller.operator.openshift.io/deployment-ingresscontroller"
+
+// CustomDomainInspection gathers a CustomDomain and the objects serving it. Objects that don't exist are nil.
+type CustomDomainInspection struct {
+	CustomDomain *customdomainv1alpha1.CustomDomain
+	// UserSecret is the TLS secret referenced by the CustomDomain
+	UserSecret *corev1.Secret
+	// IngressSecret is the copy of the TLS secret used by the ingresscontroller
+	IngressSecret *corev1.Secret
+	// IngressController serves the custom domain
+	IngressController *operatorv1.IngressController
+	// DNSRecord is the wildcard record of the ingress domain, managed by the ingress operator
+	DNSRecord *operatoringressv1.DNSRecord
+	// RouterPods are the pods of the ingresscontroller
+	RouterPods []corev1.Pod
+}
+
+// IngressSecretInSync returns true if the copy of the TLS secret used by the ingresscontroller matches the user's
+func (i *CustomDomainInspection) IngressSecretInSync() bool {
+	if i.UserSecret == nil || i.IngressSecret == nil {
+		return false
+	}
+	return len(diffSecretData(i.IngressSecret.Data, i.UserSecret.Data)) == 0
+}
+
+// InspectCustomDomain returns a CustomDomain and the objects serving it
+func InspectCustomDomain(ctx context.Context, reader client.Reader, name string) (*CustomDomainInspection, error) {
+	instance := &customdomainv1alpha1.CustomDomain{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: name}, instance); err != nil {
+		return nil, err
+	}
+	inspection := &CustomDomainInspection{CustomDomain: instance}
+	ingressName := ingressControllerName(instance)
+
+	userSecret := &corev1.Secret{}
+	found, err := getIfExists(ctx, reader, types.NamespacedName{Namespace: instance.Spec.Certificate.Namespace, Name: instance.Spec.Certificate.Name}, userSecret)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		inspection.UserSecret = userSecret
+	}
+
+	ingressSecret := &corev1.Secret{}
+	found, err = getIfExists(ctx, reader, types.NamespacedName{Namespace: ingressNamespace, Name: instance.Name}, ingressSecret)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		inspection.IngressSecret = ingressSecret
+	}
+
+	customIngress := &operatorv1.IngressController{}
+	found, err = getIfExists(ctx, reader, types.NamespacedName{Namespace: ingressOperatorNamespace, Name: ingressName}, customIngress)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		inspection.IngressController = customIngress
+	}
+
+	dnsRecord := &operatoringressv1.DNSRecord{}
+	found, err = getIfExists(ctx, reader, types.NamespacedName{Namespace: ingressOperatorNamespace, Name: fmt.Sprintf("%s-wildcard", ingressName)}, dnsRecord)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		inspection.DNSRecord = dnsRecord
+	}
+
+	pods := &corev1.PodList{}
+	err = reader.List(ctx, pods, client.InNamespace(ingressNamespace), client.MatchingLabels{routerDeploymentLabelName: ingressName})
+	if err != nil {
+		return nil, err
+	}
+	inspection.RouterPods = pods.Items
+	return inspection, nil
+}
+
+// getIfExists gets an object, and returns false if it doesn't exist
+func getIfExists(ctx context.Context, reader client.Reader, key types.NamespacedName, obj client.Object) (bool, error) {
+	err := reader.Get(ctx, key, obj)
+	if kerr.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,55 @@
+package managed
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TestInspectCustomDomain tests that a CustomDomain is inspected along with the objects serving it
+func TestInspectCustomDomain(t *testing.T) {
+	const clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+	instance := newQuotaTestCustomDomain("test", "External", 0)
+	routerPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "router-test-abcde",
+		Namespace: ingressNamespace,
+		Labels:    map[string]string{routerDeploymentLabelName: "test"},
+	}}
+	otherPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "router-default-abcde",
+		Namespace: ingressNamespace,
+		Labels:    map[string]string{routerDeploymentLabelName: "default"},
+	}}
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, newTestSecret("my-secret", "my-project"), instance, routerPod, otherPod)
+	cl := NewTestMock(t, objs...)
+
+	inspection, err := InspectCustomDomain(context.TODO(), cl, "test")
+	if err != nil {
+		t.Fatalf("inspect: (%v)", err)
+	}
+	if inspection.UserSecret == nil || inspection.IngressSecret != nil || inspection.IngressController != nil || inspection.IngressSecretInSync() {
+		t.Errorf("expected only the user secret to exist before the CustomDomain is reconciled, got %+v", inspection)
+	}
+	if len(inspection.RouterPods) != 1 || inspection.RouterPods[0].Name != routerPod.Name {
+		t.Errorf("expected the router pods of the ingresscontroller, got %v", inspection.RouterPods)
+	}
+
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Config: newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.VerifyDelegation = false
+	})}
+	_, _ = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}})
+
+	inspection, err = InspectCustomDomain(context.TODO(), cl, "test")
+	if err != nil {
+		t.Fatalf("inspect: (%v)", err)
+	}
+	if inspection.IngressController == nil || !inspection.IngressSecretInSync() {
+		t.Errorf("expected the ingresscontroller and an ingress secret in sync, got %+v", inspection)
+	}
+}
@@ -0,0 +1,201 @@
+package managed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatoringressv1 "github.com/openshift/api/operatoringress/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ingressControllerName returns the name of the ingresscontroller serving the CustomDomain
+func ingressControllerName(instance *customdomainv1alpha1.CustomDomain) string {
+	if instance.Status.IngressController != "" {
+		return instance.Status.IngressController
+	}
+	return instance.Name
+}
+
+// scopeMigrationIngressName returns the name of the ingresscontroller a CustomDomain is migrated to. It alternates
+// between the CustomDomain's name and the name suffixed with the new scope, so repeated migrations don't stack suffixes.
+func scopeMigrationIngressName(instanceName, currentName, scope string) string {
+	if currentName != instanceName {
+		return instanceName
+	}
+	return fmt.Sprintf("%s-%s", instanceName, strings.ToLower(scope))
+}
+
+// migrateScope moves a CustomDomain to a new ingresscontroller with the requested scope. Each call advances the
+// migration as far as it can, and requeues while waiting for the ingress operator to provision the new ingresscontroller.
+func (r *CustomDomainReconciler) migrateScope(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain, previousIngress *operatorv1.IngressController, scope string, strategy operatorv1.EndpointPublishingStrategyType, baseDomain, secretName string) (ctrl.Result, error) {
+	migration := instance.Status.ScopeMigration
+	if migration == nil {
+		ingressName := scopeMigrationIngressName(instance.Name, previousIngress.Name, scope)
+
+		// Don't take over the ingresscontroller of another CustomDomain
+		if ingressName != instance.Name {
+			other := &customdomainv1alpha1.CustomDomain{}
+			err := r.Client.Get(context.TODO(), types.NamespacedName{Name: ingressName}, other)
+			if err == nil {
+				errStr := fmt.Sprintf("Unable to migrate to %s scope: ingresscontroller name %s is used by another CustomDomain", scope, ingressName)
+				SetCustomDomainStatus(
+					reqLogger,
+					instance,
+					errStr,
+					customdomainv1alpha1.CustomDomainConditionInvalidScope,
+					customdomainv1alpha1.CustomDomainStateNotReady)
+				_ = r.statusUpdate(reqLogger, instance)
+				return reconcile.Result{}, errors.New(errStr)
+			} else if !kerr.IsNotFound(err) {
+				return reconcile.Result{}, err
+			}
+		}
+
+		reqLogger.Info(fmt.Sprintf("Migrating ingresscontroller %s to %s scope using ingresscontroller %s", previousIngress.Name, scope, ingressName))
+		migration = &customdomainv1alpha1.CustomDomainScopeMigration{
+			Scope:                     scope,
+			PreviousIngressController: previousIngress.Name,
+			IngressController:         ingressName,
+			StartTime:                 metav1.Now(),
+		}
+		instance.Status.ScopeMigration = migration
+	}
+	ingressDomain := fmt.Sprintf("%s.%s", migration.IngressController, baseDomain)
+
+	// Stand up the ingresscontroller with the new scope next to the previous one
+	customIngress := &operatorv1.IngressController{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{
+		Namespace: ingressOperatorNamespace,
+		Name:      migration.IngressController,
+	}, customIngress)
+	if err != nil {
+		if !kerr.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		err = r.setScopeMigrationPhase(reqLogger, instance, customdomainv1alpha1.ScopeMigrationPhaseCreatingIngressController)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		customIngress, err = r.newIngressController(*instance, migration.IngressController, ingressDomain, scope, strategy, secretName)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		err = r.Client.Create(context.TODO(), customIngress)
+		if err != nil {
+			reqLogger.Error(err, fmt.Sprintf("Error creating ingresscontroller %s in %s namespace", customIngress.Name, ingressOperatorNamespace))
+			return reconcile.Result{}, err
+		}
+	}
+
+	// Wait for the ingress operator to create the DNS record of the new ingresscontroller
+	err = r.setScopeMigrationPhase(reqLogger, instance, customdomainv1alpha1.ScopeMigrationPhaseWaitingForDNSRecord)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	dnsRecord := &operatoringressv1.DNSRecord{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{
+		Namespace: ingressOperatorNamespace,
+		Name:      fmt.Sprintf("%s-wildcard", migration.IngressController),
+	}, dnsRecord)
+	if err != nil {
+		if kerr.IsNotFound(err) {
+			return reconcile.Result{Requeue: true, RequeueAfter: r.config().RequeueWait}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	// Wait for the load balancer of the new ingresscontroller
+	err = r.setScopeMigrationPhase(reqLogger, instance, customdomainv1alpha1.ScopeMigrationPhaseWaitingForLoadBalancer)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !isIngressControllerConditionTrue(customIngress, operatorv1.LoadBalancerReadyIngressConditionType) {
+		return reconcile.Result{Requeue: true, RequeueAfter: r.config().RequeueWait}, nil
+	}
+
+	// Switch the endpoint over to the new ingresscontroller
+	err = r.setScopeMigrationPhase(reqLogger, instance, customdomainv1alpha1.ScopeMigrationPhaseSwitchingEndpoint)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	instance.Status.IngressController = migration.IngressController
+	instance.Status.Scope = scope
+	instance.Status.DNSRecord = dnsRecord.Spec.DNSName
+	instance.Status.Endpoint = fmt.Sprintf("%s.%s", r.endpointHostFor(instance), ingressDomain)
+	reqLogger.Info(fmt.Sprintf("Switched endpoint to %s", instance.Status.Endpoint))
+
+	// Delete the previous ingresscontroller
+	err = r.setScopeMigrationPhase(reqLogger, instance, customdomainv1alpha1.ScopeMigrationPhaseDeletingPreviousIngressController)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	err = r.finishScopeMigration(reqLogger, instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// finishScopeMigration deletes whichever ingresscontroller of a scope migration is no longer serving the CustomDomain.
+// This completes a migration once the endpoint has been switched, or cancels it if the scope was changed back.
+func (r *CustomDomainReconciler) finishScopeMigration(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) error {
+	migration := instance.Status.ScopeMigration
+	message := fmt.Sprintf("Migrated from ingresscontroller %s to %s with %s scope", migration.PreviousIngressController, migration.IngressController, migration.Scope)
+	staleIngressName := migration.PreviousIngressController
+	if ingressControllerName(instance) != migration.IngressController {
+		message = fmt.Sprintf("Cancelled migration to %s scope", migration.Scope)
+		staleIngressName = migration.IngressController
+	}
+
+	err := r.deleteIngressController(reqLogger, staleIngressName)
+	if err != nil {
+		return err
+	}
+
+	reqLogger.Info(message)
+	instance.Status.ScopeMigration = nil
+	instance.Status.Conditions = SetCustomDomainCondition(
+		instance.Status.Conditions,
+		customdomainv1alpha1.CustomDomainConditionScopeMigrating,
+		corev1.ConditionFalse,
+		message,
+		UpdateConditionIfReasonOrMessageChange)
+	return r.statusUpdate(reqLogger, instance)
+}
+
+// setScopeMigrationPhase records the phase of a scope migration in the CustomDomain's status
+func (r *CustomDomainReconciler) setScopeMigrationPhase(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain, phase customdomainv1alpha1.CustomDomainScopeMigrationPhase) error {
+	migration := instance.Status.ScopeMigration
+	if migration.Phase == phase {
+		return nil
+	}
+	reqLogger.Info(fmt.Sprintf("Scope migration to ingresscontroller %s: %s", migration.IngressController, phase))
+	migration.Phase = phase
+	instance.Status.Conditions = SetCustomDomainCondition(
+		instance.Status.Conditions,
+		customdomainv1alpha1.CustomDomainConditionScopeMigrating,
+		corev1.ConditionTrue,
+		fmt.Sprintf("Migrating from ingresscontroller %s to %s with %s scope: %s", migration.PreviousIngressController, migration.IngressController, migration.Scope, phase),
+		UpdateConditionIfReasonOrMessageChange)
+	return r.statusUpdate(reqLogger, instance)
+}
+
+// isIngressControllerConditionTrue returns true if the ingresscontroller has the given condition set to true
+func isIngressControllerConditionTrue(ingress *operatorv1.IngressController, conditionType string) bool {
+	for _, condition := range ingress.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == operatorv1.ConditionTrue
+		}
+	}
+	return false
+}
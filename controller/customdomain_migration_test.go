@@ -0,0 +1,194 @@
+package managed
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatoringressv1 "github.com/openshift/api/operatoringress/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TestCustomDomainScopeMigration runs a CustomDomain through a migration from Internal to External scope,
+// and through a migration that is cancelled by reverting the scope.
+func TestCustomDomainScopeMigration(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain  = "cluster1.x8s0.s1.openshiftapps.com"
+		instanceName   = "test"
+		userNamespace  = "my-project"
+		userSecretName = "my-secret"
+	)
+
+	newReadyCustomDomain := func(t *testing.T) (client.Client, *CustomDomainReconciler) {
+		customdomain := &customdomainv1alpha1.CustomDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName},
+			Spec: customdomainv1alpha1.CustomDomainSpec{
+				Domain: "apps.foo.com",
+				Scope:  "Internal",
+				Certificate: corev1.SecretReference{
+					Name:      userSecretName,
+					Namespace: userNamespace,
+				},
+				ScopeChangePolicy: customdomainv1alpha1.ScopeChangePolicyRecreate,
+			},
+		}
+		objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+		objs = append(objs, newTestSecret(userSecretName, userNamespace), customdomain, newTestDNSRecord(instanceName, clusterDomain))
+		cl := NewTestMock(t, objs...)
+		r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+		if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName}}); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		return cl, r
+	}
+
+	getCustomDomain := func(t *testing.T, cl client.Client) *customdomainv1alpha1.CustomDomain {
+		customdomain := &customdomainv1alpha1.CustomDomain{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: instanceName}, customdomain); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		return customdomain
+	}
+
+	setScope := func(t *testing.T, cl client.Client, scope string) {
+		customdomain := getCustomDomain(t, cl)
+		customdomain.Spec.Scope = scope
+		if err := cl.Update(context.TODO(), customdomain); err != nil {
+			t.Fatalf("update custom domain: (%v)", err)
+		}
+	}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName}}
+	migratedIngressName := instanceName + "-external"
+
+	t.Run("recreate", func(t *testing.T) {
+		cl, r := newReadyCustomDomain(t)
+		previousEndpoint := getCustomDomain(t, cl).Status.Endpoint
+		setScope(t, cl, "External")
+
+		// The new ingresscontroller is created, and the migration waits for its DNS record
+		res, err := r.Reconcile(context.TODO(), req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if !res.Requeue {
+			t.Error("reconcile did not requeue while waiting for the DNS record")
+		}
+		customdomain := getCustomDomain(t, cl)
+		if customdomain.Status.ScopeMigration == nil || customdomain.Status.ScopeMigration.Phase != customdomainv1alpha1.ScopeMigrationPhaseWaitingForDNSRecord {
+			t.Fatalf("unexpected scope migration status: (%+v)", customdomain.Status.ScopeMigration)
+		}
+		ingress := &operatorv1.IngressController{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: migratedIngressName, Namespace: ingressOperatorNamespace}, ingress); err != nil {
+			t.Fatalf("get ingress: (%v)", err)
+		}
+		if ingress.Spec.EndpointPublishingStrategy.LoadBalancer.Scope != operatorv1.ExternalLoadBalancer {
+			t.Errorf("ingress scope mismatch: (%v)", ingress.Spec.EndpointPublishingStrategy.LoadBalancer.Scope)
+		}
+
+		// The migration waits for the load balancer once the DNS record exists
+		if err := cl.Create(context.TODO(), newTestDNSRecord(migratedIngressName, clusterDomain)); err != nil {
+			t.Fatalf("create dnsrecord: (%v)", err)
+		}
+		if _, err := r.Reconcile(context.TODO(), req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		customdomain = getCustomDomain(t, cl)
+		if customdomain.Status.ScopeMigration.Phase != customdomainv1alpha1.ScopeMigrationPhaseWaitingForLoadBalancer {
+			t.Fatalf("unexpected scope migration phase: (%s)", customdomain.Status.ScopeMigration.Phase)
+		}
+
+		// The endpoint is switched and the previous ingresscontroller deleted once the load balancer is ready
+		ingress.Status.Conditions = []operatorv1.OperatorCondition{
+			{Type: operatorv1.LoadBalancerReadyIngressConditionType, Status: operatorv1.ConditionTrue},
+		}
+		if err := cl.Update(context.TODO(), ingress); err != nil {
+			t.Fatalf("update ingress: (%v)", err)
+		}
+		if _, err := r.Reconcile(context.TODO(), req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		customdomain = getCustomDomain(t, cl)
+		if customdomain.Status.ScopeMigration != nil {
+			t.Errorf("scope migration was not completed: (%+v)", customdomain.Status.ScopeMigration)
+		}
+		if customdomain.Status.IngressController != migratedIngressName {
+			t.Errorf("Status.IngressController mismatch: (%s)", customdomain.Status.IngressController)
+		}
+		if customdomain.Status.Endpoint == previousEndpoint || !strings.HasSuffix(customdomain.Status.Endpoint, "."+migratedIngressName+"."+clusterDomain) {
+			t.Errorf("Status.Endpoint was not switched: (%s)", customdomain.Status.Endpoint)
+		}
+		if condition := FindCustomDomainCondition(customdomain.Status.Conditions, customdomainv1alpha1.CustomDomainConditionScopeMigrating); condition == nil || condition.Status != corev1.ConditionFalse {
+			t.Errorf("unexpected %s condition: (%+v)", customdomainv1alpha1.CustomDomainConditionScopeMigrating, condition)
+		}
+		err = cl.Get(context.TODO(), types.NamespacedName{Name: instanceName, Namespace: ingressOperatorNamespace}, &operatorv1.IngressController{})
+		if !kerr.IsNotFound(err) {
+			t.Errorf("previous ingresscontroller %s was not deleted: (%v)", instanceName, err)
+		}
+
+		// Later reconciles use the new ingresscontroller
+		res, err = r.Reconcile(context.TODO(), req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if res != (reconcile.Result{}) {
+			t.Error("reconcile did not return an empty Result")
+		}
+		if getCustomDomain(t, cl).Status.State != customdomainv1alpha1.CustomDomainStateReady {
+			t.Errorf("Status.State does not equal (%s)", string(customdomainv1alpha1.CustomDomainStateReady))
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		cl, r := newReadyCustomDomain(t)
+		setScope(t, cl, "External")
+		if _, err := r.Reconcile(context.TODO(), req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		// Reverting the scope removes the new ingresscontroller and keeps the previous one
+		setScope(t, cl, "Internal")
+		if _, err := r.Reconcile(context.TODO(), req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		customdomain := getCustomDomain(t, cl)
+		if customdomain.Status.ScopeMigration != nil {
+			t.Errorf("scope migration was not cancelled: (%+v)", customdomain.Status.ScopeMigration)
+		}
+		if customdomain.Status.IngressController != instanceName {
+			t.Errorf("Status.IngressController mismatch: (%s)", customdomain.Status.IngressController)
+		}
+		err := cl.Get(context.TODO(), types.NamespacedName{Name: migratedIngressName, Namespace: ingressOperatorNamespace}, &operatorv1.IngressController{})
+		if !kerr.IsNotFound(err) {
+			t.Errorf("ingresscontroller %s was not deleted: (%v)", migratedIngressName, err)
+		}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: instanceName, Namespace: ingressOperatorNamespace}, &operatorv1.IngressController{}); err != nil {
+			t.Errorf("get ingress: (%v)", err)
+		}
+	})
+}
+
+// newTestDNSRecord returns the wildcard DNSRecord the ingress operator creates for an ingresscontroller
+func newTestDNSRecord(ingressName, clusterDomain string) *operatoringressv1.DNSRecord {
+	return &operatoringressv1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ingressName + "-wildcard",
+			Namespace: ingressOperatorNamespace,
+		},
+		Spec: operatoringressv1.DNSRecordSpec{
+			DNSName: "*." + ingressName + "." + clusterDomain,
+		},
+	}
+}
@@ -0,0 +1,114 @@
+package managed
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// OwnershipVerificationMode determines how the ownership of custom domains is verified
+type OwnershipVerificationMode string
+
+const (
+	// OwnershipVerificationDisabled does not verify the ownership of custom domains
+	OwnershipVerificationDisabled OwnershipVerificationMode = "disabled"
+
+	// OwnershipVerificationWarn reports unverified custom domains in their conditions, but still serves them
+	OwnershipVerificationWarn OwnershipVerificationMode = "warn"
+
+	// OwnershipVerificationBlock does not serve custom domains until their ownership is verified
+	OwnershipVerificationBlock OwnershipVerificationMode = "block"
+)
+
+const (
+	// ownershipChallengePrefix is the label under the custom domain holding the ownership challenge TXT record
+	ownershipChallengePrefix = "_custom-domains-challenge"
+	// ownershipTokenBytes is the number of random bytes in an ownership token
+	ownershipTokenBytes = 16
+)
+
+// newOwnershipToken returns a random token for the ownership challenge
+func newOwnershipToken() (string, error) {
+	b := make([]byte, ownershipTokenBytes)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// verifyOwnership publishes an ownership challenge in the CustomDomain's status, and checks whether its TXT record
+// exists. It returns a non-nil result if the reconcile must stop because the ownership is not verified in block mode.
+func (r *CustomDomainReconciler) verifyOwnership(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) (*ctrl.Result, error) {
+	if !r.isOwnershipVerificationEnabled() {
+		return nil, nil
+	}
+
+	// A new challenge is issued when the custom domain changes
+	recordName := fmt.Sprintf("%s.%s", ownershipChallengePrefix, instance.Spec.Domain)
+	challenge := instance.Status.OwnershipChallenge
+	if challenge == nil || challenge.RecordName != recordName {
+		token, err := newOwnershipToken()
+		if err != nil {
+			return nil, err
+		}
+		reqLogger.Info(fmt.Sprintf("Issuing ownership challenge %s for %s", recordName, instance.Spec.Domain))
+		challenge = &customdomainv1alpha1.CustomDomainOwnershipChallenge{
+			RecordName: recordName,
+			Token:      token,
+		}
+		instance.Status.OwnershipChallenge = challenge
+	}
+	if challenge.VerifiedTime != nil {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), dnsLookupTimeout)
+	defer cancel()
+	records, err := r.resolver().LookupTXT(ctx, recordName)
+	if err == nil && sets.New(records...).Has(challenge.Token) {
+		now := metav1.Now()
+		challenge.VerifiedTime = &now
+		message := fmt.Sprintf("Ownership of %s verified with TXT record %s", instance.Spec.Domain, recordName)
+		reqLogger.Info(message)
+		instance.Status.Conditions = setCustomDomainConditionStatus(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDomainOwnershipVerified, corev1.ConditionTrue, message, UpdateConditionIfReasonOrMessageChange)
+		return nil, r.statusUpdate(reqLogger, instance)
+	}
+
+	answer := fmt.Sprintf("%v", records)
+	if err != nil {
+		answer = fmt.Sprintf("error: %v", err)
+	}
+	message := fmt.Sprintf("Ownership of %s is not verified: create a TXT record %s with the value %s (found %s)", instance.Spec.Domain, recordName, challenge.Token, answer)
+	reqLogger.Info(message)
+	instance.Status.Conditions = setCustomDomainConditionStatus(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDomainOwnershipVerified, corev1.ConditionFalse, message, UpdateConditionIfReasonOrMessageChange)
+	if r.config().OwnershipVerification == OwnershipVerificationBlock {
+		instance.Status.State = customdomainv1alpha1.CustomDomainStateNotReady
+		err = r.statusUpdate(reqLogger, instance)
+		if err != nil {
+			return nil, err
+		}
+		return &reconcile.Result{Requeue: true, RequeueAfter: r.config().DomainVerificationInterval}, nil
+	}
+	return nil, r.statusUpdate(reqLogger, instance)
+}
+
+// isOwnershipVerificationEnabled returns true if the operator verifies the ownership of custom domains
+func (r *CustomDomainReconciler) isOwnershipVerificationEnabled() bool {
+	mode := r.config().OwnershipVerification
+	return mode != "" && mode != OwnershipVerificationDisabled
+}
+
+// isOwnershipPending returns true if the CustomDomain has an ownership challenge that is not verified yet
+func (r *CustomDomainReconciler) isOwnershipPending(instance *customdomainv1alpha1.CustomDomain) bool {
+	return r.isOwnershipVerificationEnabled() && instance.Status.OwnershipChallenge != nil && instance.Status.OwnershipChallenge.VerifiedTime == nil
+}
@@ -0,0 +1,119 @@
+package managed
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TestCustomDomainOwnershipVerification tests the TXT ownership challenge in warn and block mode
+func TestCustomDomainOwnershipVerification(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain  = "cluster1.x8s0.s1.openshiftapps.com"
+		instanceName   = "test"
+		userNamespace  = "my-project"
+		userSecretName = "my-secret"
+		recordName     = "_custom-domains-challenge.apps.foo.com"
+	)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName}}
+
+	tests := []struct {
+		name          string
+		mode          OwnershipVerificationMode
+		expectBlocked bool
+	}{
+		{
+			name: "warn",
+			mode: OwnershipVerificationWarn,
+		},
+		{
+			name:          "block",
+			mode:          OwnershipVerificationBlock,
+			expectBlocked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customdomain := &customdomainv1alpha1.CustomDomain{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName},
+				Spec: customdomainv1alpha1.CustomDomainSpec{
+					Domain: "apps.foo.com",
+					Scope:  "External",
+					Certificate: corev1.SecretReference{
+						Name:      userSecretName,
+						Namespace: userNamespace,
+					},
+				},
+			}
+			objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+			objs = append(objs, newTestSecret(userSecretName, userNamespace), customdomain, newTestDNSRecord(instanceName, clusterDomain))
+			cl := NewTestMock(t, objs...)
+			resolver := &fakeResolver{txts: map[string][]string{}}
+			config := newTestOperatorConfigStore(func(c *OperatorConfig) {
+				c.VerifyDelegation = false
+				c.OwnershipVerification = tt.mode
+			})
+			r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Resolver: resolver, Config: config}
+
+			// The challenge is published, and the domain is not verified without its TXT record
+			res, err := r.Reconcile(context.TODO(), req)
+			if err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			if !res.Requeue {
+				t.Error("reconcile did not requeue while the ownership is not verified")
+			}
+			if err := cl.Get(context.TODO(), req.NamespacedName, customdomain); err != nil {
+				t.Fatalf("get custom domain: (%v)", err)
+			}
+			challenge := customdomain.Status.OwnershipChallenge
+			if challenge == nil || challenge.RecordName != recordName || challenge.Token == "" || challenge.VerifiedTime != nil {
+				t.Fatalf("unexpected ownership challenge: (%+v)", challenge)
+			}
+			condition := FindCustomDomainCondition(customdomain.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDomainOwnershipVerified)
+			if condition == nil || condition.Status != corev1.ConditionFalse {
+				t.Errorf("unexpected %s condition: (%+v)", customdomainv1alpha1.CustomDomainConditionDomainOwnershipVerified, condition)
+			}
+			err = cl.Get(context.TODO(), types.NamespacedName{Name: instanceName, Namespace: ingressOperatorNamespace}, &operatorv1.IngressController{})
+			if tt.expectBlocked != kerr.IsNotFound(err) {
+				t.Errorf("ingresscontroller creation mismatch: blocked (%v), got (%v)", tt.expectBlocked, err)
+			}
+
+			// The domain is verified and served once the TXT record resolves
+			resolver.txts[recordName] = []string{"unrelated", challenge.Token}
+			res, err = r.Reconcile(context.TODO(), req)
+			if err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			if res != (reconcile.Result{}) {
+				t.Error("reconcile did not return an empty Result")
+			}
+			if err := cl.Get(context.TODO(), req.NamespacedName, customdomain); err != nil {
+				t.Fatalf("get custom domain: (%v)", err)
+			}
+			if customdomain.Status.OwnershipChallenge.VerifiedTime == nil {
+				t.Error("ownership was not verified")
+			}
+			condition = FindCustomDomainCondition(customdomain.Status.Conditions, customdomainv1alpha1.CustomDomainConditionDomainOwnershipVerified)
+			if condition == nil || condition.Status != corev1.ConditionTrue {
+				t.Errorf("unexpected %s condition: (%+v)", customdomainv1alpha1.CustomDomainConditionDomainOwnershipVerified, condition)
+			}
+			if customdomain.Status.State != customdomainv1alpha1.CustomDomainStateReady {
+				t.Errorf("Status.State does not equal (%s)", string(customdomainv1alpha1.CustomDomainStateReady))
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package managed
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// pausedAnnotation pauses the reconciliation of a CustomDomain when set to "true"
+const pausedAnnotation = "customdomains.managed.openshift.io/paused"
+
+// pauseReason returns why the reconciliation of the CustomDomain is paused, or an empty string if it isn't
+func (r *CustomDomainReconciler) pauseReason(instance *customdomainv1alpha1.CustomDomain) string {
+	if instance.Annotations[pausedAnnotation] == "true" {
+		return fmt.Sprintf("Reconciliation is paused by the %s annotation", pausedAnnotation)
+	}
+	if r.config().Paused {
+		return "Reconciliation of all CustomDomains is paused by the operator configuration"
+	}
+	return ""
+}
+
+// checkPaused returns true if the reconciliation of the CustomDomain is paused. Paused CustomDomains, and the
+// resources they own, are left untouched, only the Paused condition is updated. It must not be called for
+// CustomDomains being deleted, which are finalized even when paused so they don't hang in Terminating.
+func (r *CustomDomainReconciler) checkPaused(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) (bool, error) {
+	reason := r.pauseReason(instance)
+	cond := FindCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionPaused)
+	if reason == "" {
+		if cond == nil || cond.Status == corev1.ConditionFalse {
+			return false, nil
+		}
+		reqLogger.Info("Reconciliation resumed")
+		instance.Status.Conditions = SetCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionPaused,
+			corev1.ConditionFalse, "Reconciliation is not paused", UpdateConditionIfReasonOrMessageChange)
+		return false, r.statusUpdate(reqLogger, instance)
+	}
+
+	reqLogger.Info(reason)
+	if cond != nil && cond.Status == corev1.ConditionTrue && cond.Message == reason {
+		return true, nil
+	}
+	instance.Status.Conditions = setCustomDomainConditionStatus(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionPaused,
+		corev1.ConditionTrue, reason, UpdateConditionIfReasonOrMessageChange)
+	return true, r.statusUpdate(reqLogger, instance)
+}
@@ -0,0 +1,108 @@
+package managed
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TestCustomDomainPaused tests that paused CustomDomains are left untouched until they are resumed
+func TestCustomDomainPaused(t *testing.T) {
+	const clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+	instance := newQuotaTestCustomDomain("test", "External", 0)
+	instance.Annotations = map[string]string{pausedAnnotation: "true"}
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, newTestSecret("my-secret", "my-project"), instance)
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Config: newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.VerifyDelegation = false
+	})}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}}
+
+	reconcilePaused := func(t *testing.T, expectPaused bool) *customdomainv1alpha1.CustomDomain {
+		if _, err := r.Reconcile(context.TODO(), req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		instance := &customdomainv1alpha1.CustomDomain{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		cond := FindCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionPaused)
+		if cond == nil || (cond.Status == corev1.ConditionTrue) != expectPaused {
+			t.Errorf("expected Paused condition %v, got %+v", expectPaused, cond)
+		}
+		err := cl.Get(context.TODO(), types.NamespacedName{Name: "test", Namespace: ingressOperatorNamespace}, &operatorv1.IngressController{})
+		if expectPaused != kerr.IsNotFound(err) {
+			t.Errorf("expected ingresscontroller to exist %v: (%v)", !expectPaused, err)
+		}
+		return instance
+	}
+
+	// paused by the annotation
+	instance = reconcilePaused(t, true)
+	if len(instance.Finalizers) != 0 {
+		t.Errorf("expected a paused CustomDomain to be left untouched, got finalizers %v", instance.Finalizers)
+	}
+
+	// paused by the operator configuration
+	instance.Annotations = nil
+	if err := cl.Update(context.TODO(), instance); err != nil {
+		t.Fatalf("update custom domain: (%v)", err)
+	}
+	r.Config = newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.VerifyDelegation = false
+		c.Paused = true
+	})
+	reconcilePaused(t, true)
+
+	// resumed
+	r.Config = newTestOperatorConfigStore(func(c *OperatorConfig) { c.VerifyDelegation = false })
+	reconcilePaused(t, false)
+}
+
+// TestCustomDomainPausedDeletion tests that paused CustomDomains are still finalized when they are deleted
+func TestCustomDomainPausedDeletion(t *testing.T) {
+	const clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+	instance := newQuotaTestCustomDomain("test", "External", 0)
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, newTestSecret("my-secret", "my-project"), instance)
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Config: newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.VerifyDelegation = false
+	})}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}}
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// paused by the operator configuration, then deleted
+	r.Config = newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.VerifyDelegation = false
+		c.Paused = true
+	})
+	if err := cl.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+		t.Fatalf("get custom domain: (%v)", err)
+	}
+	if err := cl.Delete(context.TODO(), instance); err != nil {
+		t.Fatalf("delete custom domain: (%v)", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	err := cl.Get(context.TODO(), req.NamespacedName, &customdomainv1alpha1.CustomDomain{})
+	if !kerr.IsNotFound(err) {
+		t.Errorf("expected the paused custom domain to be finalized: (%v)", err)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: "test", Namespace: ingressOperatorNamespace}, &operatorv1.IngressController{})
+	if !kerr.IsNotFound(err) {
+		t.Errorf("expected the ingresscontroller to be deleted: (%v)", err)
+	}
+}
@@ -0,0 +1,425 @@
+package managed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/go-logr/logr"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// planAnnotation makes the operator compute the plan of a CustomDomain instead of reconciling it when set to "true"
+const planAnnotation = "customdomains.managed.openshift.io/plan"
+
+// planNone is shown in diffs for fields that are not set
+const planNone = "<none>"
+
+// isPlanRequested returns true if the CustomDomain asks for a plan instead of being reconciled
+func isPlanRequested(instance *customdomainv1alpha1.CustomDomain) bool {
+	return instance.Annotations[planAnnotation] == "true"
+}
+
+// publishPlan computes the plan of the CustomDomain and publishes it in the status. Nothing else is written.
+func (r *CustomDomainReconciler) publishPlan(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) error {
+	plan, err := r.Plan(context.TODO(), reqLogger, instance)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(instance.Status.Plan, plan) {
+		return nil
+	}
+	reqLogger.Info(fmt.Sprintf("Planned %d changes with %d blockers", len(plan.Changes), len(plan.Blockers)))
+	instance.Status.Plan = plan
+	return r.statusUpdate(reqLogger, instance)
+}
+
+// Plan returns the changes a reconcile of the CustomDomain would make, without making them. The checks that stop the
+// reconcile are reported as blockers, and no changes are listed while the CustomDomain is blocked. The desired state
+// is computed by the same functions as the reconcile.
+func (r *CustomDomainReconciler) Plan(ctx context.Context, reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) (*customdomainv1alpha1.CustomDomainPlan, error) {
+	plan := &customdomainv1alpha1.CustomDomainPlan{ObservedGeneration: instance.Generation}
+
+	blockers, err := r.planBlockers(ctx, reqLogger, instance)
+	if err != nil {
+		return nil, err
+	}
+	if len(blockers) > 0 {
+		plan.Blockers = blockers
+		return plan, nil
+	}
+
+	if !contains(instance.GetFinalizers(), customDomainFinalizer) {
+		plan.Changes = append(plan.Changes, customdomainv1alpha1.CustomDomainPlannedChange{
+			Action: customdomainv1alpha1.CustomDomainPlannedUpdate,
+			Kind:   "CustomDomain",
+			Name:   instance.Name,
+			Diff:   []string{fmt.Sprintf("metadata.finalizers: add %s", customDomainFinalizer)},
+		})
+	}
+
+	userSecret := &corev1.Secret{}
+	err = r.Client.Get(ctx, types.NamespacedName{
+		Namespace: instance.Spec.Certificate.Namespace,
+		Name:      instance.Spec.Certificate.Name,
+	}, userSecret)
+	if err != nil {
+		if kerr.IsNotFound(err) {
+			plan.Blockers = append(plan.Blockers, fmt.Sprintf("TLS Secret (%s) Not Found", instance.Spec.Certificate.Name))
+			return plan, nil
+		}
+		return nil, err
+	}
+	// the ingresscontroller is decided first, as it decides whether an unmanaged ingress secret is taken over
+	ingress, err := r.desiredIngressFor(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	change, err := r.desiredIngressController(ctx, instance, ingress, userSecret)
+	if err != nil {
+		return nil, err
+	}
+	if change.blocker != "" {
+		plan.Blockers = []string{change.blocker}
+		return plan, nil
+	}
+	changes, blockers, err := r.planSecrets(ctx, instance, userSecret, change.action)
+	if err != nil {
+		return nil, err
+	}
+	if len(blockers) > 0 {
+		plan.Blockers = blockers
+		return plan, nil
+	}
+	plan.Changes = append(plan.Changes, changes...)
+	changes, err = planIngressController(ingress, change)
+	if err != nil {
+		return nil, err
+	}
+	plan.Changes = append(plan.Changes, changes...)
+
+	// the endpoint and the DNS record are planned on a copy, so the status of the CustomDomain is left untouched
+	planned := instance.DeepCopy()
+	if endpoint, changed := r.desiredEndpoint(planned, ingress.domain); changed {
+		if err := r.validateEndpoint(planned, endpoint); err != nil {
+			plan.Blockers = append(plan.Blockers, fmt.Sprintf("Invalid endpoint host: %v", err))
+			return plan, nil
+		}
+		plan.Changes = append(plan.Changes, customdomainv1alpha1.CustomDomainPlannedChange{
+			Action: customdomainv1alpha1.CustomDomainPlannedUpdate,
+			Kind:   "CustomDomain",
+			Name:   instance.Name,
+			Diff:   []string{fmt.Sprintf("status.endpoint: %s -> %s", planValue(planned.Status.Endpoint), endpoint)},
+			Note:   "The CNAME record of the custom domain must point to the new endpoint",
+		})
+		planned.Status.Endpoint = endpoint
+	}
+	if change := planDNSRecord(planned); change != nil {
+		plan.Changes = append(plan.Changes, *change)
+	}
+	return plan, nil
+}
+
+// planBlockers returns the problems that stop the reconcile before any object is changed
+func (r *CustomDomainReconciler) planBlockers(ctx context.Context, reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) ([]string, error) {
+	if instance.GetDeletionTimestamp() != nil {
+		return []string{"The CustomDomain is being deleted"}, nil
+	}
+	if reason := r.pauseReason(instance); reason != "" {
+		return []string{reason}, nil
+	}
+
+	clusterVersion, err := r.GetClusterVersion(r.Client)
+	if err != nil {
+		return nil, err
+	}
+	usingNewManagedIngress, err := IsUsingNewManagedIngressFeature(r.Client, reqLogger)
+	if err != nil {
+		return nil, err
+	}
+	if IsVersionGreaterOrEqualThan(clusterVersion, "4.13") && usingNewManagedIngress {
+		return []string{"The cluster uses the managed ingress feature, the ingresscontroller is returned to the cluster ingress operator"}, nil
+	}
+
+	blockers := []string{}
+	if CustomDomainNameProblem(r.config(), instance.Name) != "" {
+		blockers = append(blockers, fmt.Sprintf("Invalid CR name (%s)", instance.Name))
+	}
+	for _, problemOf := range []func(context.Context, *customdomainv1alpha1.CustomDomain) (string, error){
+		r.domainPolicyProblem,
+		r.domainConflictProblem,
+		r.quotaProblem,
+	} {
+		problem, err := problemOf(ctx, instance)
+		if err != nil {
+			return nil, err
+		}
+		if problem != "" {
+			blockers = append(blockers, problem)
+		}
+	}
+
+	if instance.Spec.ProxyProtocol {
+		if _, err := r.validateProxyProtocol(*instance, customDomainStrategy(instance)); err != nil {
+			blockers = append(blockers, err.Error())
+		}
+	}
+	return blockers, nil
+}
+
+// planSecrets returns the changes to the user's secret, to the secrets it used before its certificate was changed, and
+// to its copy in the openshift-ingress namespace, and the problems that stop the reconcile before they are made. Only
+// the names of the changed keys are reported, never their contents.
+func (r *CustomDomainReconciler) planSecrets(ctx context.Context, instance *customdomainv1alpha1.CustomDomain, userSecret *corev1.Secret, action ingressControllerAction) ([]customdomainv1alpha1.CustomDomainPlannedChange, []string, error) {
+	changes := []customdomainv1alpha1.CustomDomainPlannedChange{}
+
+	claimed := userSecret.DeepCopy()
+	if claimUserSecret(claimed, instance.Name) {
+		diff, err := diffObjects("metadata", userSecret.ObjectMeta, claimed.ObjectMeta)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, customdomainv1alpha1.CustomDomainPlannedChange{
+			Action:    customdomainv1alpha1.CustomDomainPlannedUpdate,
+			Kind:      "Secret",
+			Namespace: userSecret.Namespace,
+			Name:      userSecret.Name,
+			Diff:      diff,
+		})
+	}
+
+	previousSecrets, err := r.previousUserSecrets(ctx, instance)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, previous := range previousSecrets {
+		released := previous.DeepCopy()
+		if !unclaimUserSecret(released, instance.Name) {
+			continue
+		}
+		diff, err := diffObjects("metadata", previous.ObjectMeta, released.ObjectMeta)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, customdomainv1alpha1.CustomDomainPlannedChange{
+			Action:    customdomainv1alpha1.CustomDomainPlannedUpdate,
+			Kind:      "Secret",
+			Namespace: previous.Namespace,
+			Name:      previous.Name,
+			Diff:      diff,
+			Note:      "The CustomDomain no longer uses the secret",
+		})
+	}
+
+	desired := desiredIngressSecret(instance, userSecret)
+	ingressSecret := &corev1.Secret{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, ingressSecret)
+	if err != nil {
+		if !kerr.IsNotFound(err) {
+			return nil, nil, err
+		}
+		changes = append(changes, customdomainv1alpha1.CustomDomainPlannedChange{
+			Action:    customdomainv1alpha1.CustomDomainPlannedCreate,
+			Kind:      "Secret",
+			Namespace: desired.Namespace,
+			Name:      desired.Name,
+			Diff:      diffSecretData(nil, desired.Data),
+			Note:      fmt.Sprintf("Copy of the TLS secret %s/%s", userSecret.Namespace, userSecret.Name),
+		})
+		return changes, nil, nil
+	}
+	updated, problem := updatedIngressSecret(ingressSecret, desired, action)
+	if problem != "" {
+		return nil, []string{problem}, nil
+	}
+	if updated != nil {
+		diff, err := diffObjects("metadata.labels", ingressSecret.Labels, updated.Labels)
+		if err != nil {
+			return nil, nil, err
+		}
+		annotationsDiff, err := diffObjects("metadata.annotations", ingressSecret.Annotations, updated.Annotations)
+		if err != nil {
+			return nil, nil, err
+		}
+		diff = append(diff, annotationsDiff...)
+		changes = append(changes, customdomainv1alpha1.CustomDomainPlannedChange{
+			Action:    customdomainv1alpha1.CustomDomainPlannedUpdate,
+			Kind:      "Secret",
+			Namespace: desired.Namespace,
+			Name:      desired.Name,
+			Diff:      append(diff, diffSecretData(ingressSecret.Data, updated.Data)...),
+			Note:      fmt.Sprintf("Copy of the TLS secret %s/%s", userSecret.Namespace, userSecret.Name),
+		})
+	}
+	return changes, nil, nil
+}
+
+// planIngressController returns the changes to the ingresscontroller of the CustomDomain
+func planIngressController(ingress desiredIngress, change *ingressControllerChange) ([]customdomainv1alpha1.CustomDomainPlannedChange, error) {
+	var err error
+	planned := customdomainv1alpha1.CustomDomainPlannedChange{
+		Action:    customdomainv1alpha1.CustomDomainPlannedCreate,
+		Kind:      "IngressController",
+		Namespace: ingressOperatorNamespace,
+		Name:      change.desired.Name,
+	}
+	switch change.action {
+	case ingressControllerCreate, ingressControllerMigrateScope:
+		planned.Diff, err = diffObjects("spec", operatorv1.IngressControllerSpec{}, change.desired.Spec)
+		if err != nil {
+			return nil, err
+		}
+		if change.action == ingressControllerMigrateScope {
+			planned.Note = fmt.Sprintf("Scope change from %s to %s: ingresscontroller %s is deleted once the new one is available, which changes the endpoint",
+				change.live.Spec.EndpointPublishingStrategy.LoadBalancer.Scope, ingress.scope, change.live.Name)
+		}
+	default:
+		planned.Action = customdomainv1alpha1.CustomDomainPlannedUpdate
+		if change.action == ingressControllerAdopt {
+			planned.Action = customdomainv1alpha1.CustomDomainPlannedAdopt
+		}
+		planned.Diff, err = diffObjects("metadata.labels", change.live.Labels, change.desired.Labels)
+		if err != nil {
+			return nil, err
+		}
+		annotationsDiff, err := diffObjects("metadata.annotations", change.live.Annotations, change.desired.Annotations)
+		if err != nil {
+			return nil, err
+		}
+		planned.Diff = append(planned.Diff, annotationsDiff...)
+		specDiff, err := diffObjects("spec", change.live.Spec, change.desired.Spec)
+		if err != nil {
+			return nil, err
+		}
+		planned.Diff = append(planned.Diff, specDiff...)
+		if len(planned.Diff) == 0 {
+			return nil, nil
+		}
+		if change.live.Spec.Domain != ingress.domain {
+			planned.Note = fmt.Sprintf("The domain of an existing ingresscontroller is not updated to %s", ingress.domain)
+		}
+	}
+	return []customdomainv1alpha1.CustomDomainPlannedChange{planned}, nil
+}
+
+// planDNSRecord returns the change to the CNAME record published with the DNS provider, or nil if it is up to date
+func planDNSRecord(instance *customdomainv1alpha1.CustomDomain) *customdomainv1alpha1.CustomDomainPlannedChange {
+	if instance.Spec.DNSProvider == nil {
+		return nil
+	}
+	record := desiredDNSRecord(instance)
+	diff := dnsRecordDiff(instance, record)
+	if len(diff) == 0 {
+		return nil
+	}
+	return &customdomainv1alpha1.CustomDomainPlannedChange{
+		Action: customdomainv1alpha1.CustomDomainPlannedPublish,
+		Kind:   "DNSRecord",
+		Name:   record.Name,
+		Diff:   diff,
+	}
+}
+
+// diffSecretData lists the keys of a secret that are added, removed or changed
+func diffSecretData(live, desired map[string][]byte) []string {
+	diff := []string{}
+	for _, key := range sortedKeys(live, desired) {
+		liveValue, inLive := live[key]
+		desiredValue, inDesired := desired[key]
+		switch {
+		case !inLive:
+			diff = append(diff, fmt.Sprintf("data.%s: added", key))
+		case !inDesired:
+			diff = append(diff, fmt.Sprintf("data.%s: removed", key))
+		case !reflect.DeepEqual(liveValue, desiredValue):
+			diff = append(diff, fmt.Sprintf("data.%s: changed", key))
+		}
+	}
+	return diff
+}
+
+// sortedKeys returns the keys of the maps, sorted
+func sortedKeys[V any](maps ...map[string]V) []string {
+	keys := []string{}
+	for _, m := range maps {
+		for key := range m {
+			if !contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffObjects lists the fields that differ between two objects as "path: live -> desired", using their JSON form.
+// Lists are compared as a whole.
+func diffObjects(path string, live, desired interface{}) ([]string, error) {
+	liveValue, err := toJSONValue(live)
+	if err != nil {
+		return nil, err
+	}
+	desiredValue, err := toJSONValue(desired)
+	if err != nil {
+		return nil, err
+	}
+	diff := []string{}
+	diffJSONValues(path, liveValue, desiredValue, &diff)
+	return diff, nil
+}
+
+// toJSONValue returns the JSON form of an object as maps, lists and scalars
+func toJSONValue(obj interface{}) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+// diffJSONValues appends the differences between two JSON values to the diff
+func diffJSONValues(path string, live, desired interface{}, diff *[]string) {
+	liveMap, liveIsMap := live.(map[string]interface{})
+	desiredMap, desiredIsMap := desired.(map[string]interface{})
+	if (liveIsMap || live == nil) && (desiredIsMap || desired == nil) && (liveIsMap || desiredIsMap) {
+		for _, key := range sortedKeys(liveMap, desiredMap) {
+			diffJSONValues(path+"."+key, liveMap[key], desiredMap[key], diff)
+		}
+		return
+	}
+	if reflect.DeepEqual(live, desired) {
+		return
+	}
+	*diff = append(*diff, fmt.Sprintf("%s: %s -> %s", path, formatJSONValue(live), formatJSONValue(desired)))
+}
+
+// formatJSONValue returns the compact JSON form of a value, or <none> if it is not set
+func formatJSONValue(value interface{}) string {
+	if value == nil {
+		return planNone
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// planValue returns the value, or <none> if it is empty
+func planValue(value string) string {
+	if value == "" {
+		return planNone
+	}
+	return value
+}
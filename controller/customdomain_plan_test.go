@@ -0,0 +1,213 @@
+package managed
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// findPlannedChange returns the planned change of an object, or nil if there is none
+func findPlannedChange(plan *customdomainv1alpha1.CustomDomainPlan, kind, name string) *customdomainv1alpha1.CustomDomainPlannedChange {
+	for i := range plan.Changes {
+		if plan.Changes[i].Kind == kind && plan.Changes[i].Name == name {
+			return &plan.Changes[i]
+		}
+	}
+	return nil
+}
+
+// TestCustomDomainPlan tests that CustomDomains annotated for a plan publish the changes in their status without
+// making them
+func TestCustomDomainPlan(t *testing.T) {
+	const clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+	instance := newQuotaTestCustomDomain("test", "External", 0)
+	instance.Annotations = map[string]string{planAnnotation: "true"}
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, newTestSecret("my-secret", "my-project"), instance)
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Config: newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.VerifyDelegation = false
+	})}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}}
+
+	reconcilePlan := func(t *testing.T) *customdomainv1alpha1.CustomDomain {
+		_, _ = r.Reconcile(context.TODO(), req)
+		instance := &customdomainv1alpha1.CustomDomain{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		return instance
+	}
+
+	instance = reconcilePlan(t)
+	plan := instance.Status.Plan
+	if plan == nil || len(plan.Blockers) != 0 {
+		t.Fatalf("expected a plan without blockers, got %+v", plan)
+	}
+	if c := findPlannedChange(plan, "IngressController", "test"); c == nil || c.Action != customdomainv1alpha1.CustomDomainPlannedCreate {
+		t.Errorf("expected the ingresscontroller to be created, got %+v", plan.Changes)
+	}
+	secret := findPlannedChange(plan, "Secret", "test")
+	if secret == nil || secret.Action != customdomainv1alpha1.CustomDomainPlannedCreate {
+		t.Fatalf("expected the ingress secret to be created, got %+v", plan.Changes)
+	}
+	for _, line := range secret.Diff {
+		if strings.Contains(line, "DEADBEEF") {
+			t.Errorf("expected the secret contents not to be in the plan, got %s", line)
+		}
+	}
+	if c := findPlannedChange(plan, "CustomDomain", "test"); c == nil {
+		t.Errorf("expected the CustomDomain to be updated, got %+v", plan.Changes)
+	}
+
+	// nothing is written but the plan
+	if len(instance.Finalizers) != 0 || instance.Status.Endpoint != "" {
+		t.Errorf("expected the CustomDomain to be left untouched, got %+v", instance)
+	}
+	err := cl.Get(context.TODO(), types.NamespacedName{Name: "test", Namespace: ingressOperatorNamespace}, &operatorv1.IngressController{})
+	if !kerr.IsNotFound(err) {
+		t.Errorf("expected no ingresscontroller: (%v)", err)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: "test", Namespace: ingressNamespace}, &corev1.Secret{})
+	if !kerr.IsNotFound(err) {
+		t.Errorf("expected no ingress secret: (%v)", err)
+	}
+
+	// the plan is dropped once the CustomDomain is reconciled
+	instance.Annotations = nil
+	if err := cl.Update(context.TODO(), instance); err != nil {
+		t.Fatalf("update custom domain: (%v)", err)
+	}
+	instance = reconcilePlan(t)
+	if instance.Status.Plan != nil {
+		t.Errorf("expected the plan to be dropped, got %+v", instance.Status.Plan)
+	}
+
+	// changes to the operator configuration are planned as updates of the ingresscontroller
+	instance.Annotations = map[string]string{planAnnotation: "true"}
+	if err := cl.Update(context.TODO(), instance); err != nil {
+		t.Fatalf("update custom domain: (%v)", err)
+	}
+	r.Config = newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.VerifyDelegation = false
+		c.ELBIdleTimeout = time.Minute
+		// the node placement only applies to new ingresscontrollers
+		c.NodePlacement = &operatorv1.NodePlacement{NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"node-role.kubernetes.io/worker": ""}}}
+	})
+	instance = reconcilePlan(t)
+	plan = instance.Status.Plan
+	c := findPlannedChange(plan, "IngressController", "test")
+	if c == nil || c.Action != customdomainv1alpha1.CustomDomainPlannedUpdate || len(c.Diff) != 1 ||
+		!strings.Contains(c.Diff[0], ".connectionIdleTimeout") {
+		t.Errorf("expected only the idle timeout of the ingresscontroller to be updated, got %+v", plan)
+	}
+	if findPlannedChange(plan, "Secret", "test") != nil {
+		t.Errorf("expected the ingress secret to be up to date, got %+v", plan.Changes)
+	}
+}
+
+// TestCustomDomainPlanBlockers tests that the checks stopping the reconcile are planned as blockers
+func TestCustomDomainPlanBlockers(t *testing.T) {
+	const clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+	older := newQuotaTestCustomDomain("older", "External", 10)
+	instance := newQuotaTestCustomDomain("test", "External", 0)
+	instance.Spec.Domain = older.Spec.Domain
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, older, instance)
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Config: newTestOperatorConfigStore(func(c *OperatorConfig) {})}
+
+	plan, err := r.Plan(context.TODO(), log, instance)
+	if err != nil {
+		t.Fatalf("plan: (%v)", err)
+	}
+	if len(plan.Blockers) != 1 || !strings.HasPrefix(plan.Blockers[0], "Domain conflict") || len(plan.Changes) != 0 {
+		t.Errorf("expected a domain conflict blocker, got %+v", plan)
+	}
+
+	plan, err = r.Plan(context.TODO(), log, older)
+	if err != nil {
+		t.Fatalf("plan: (%v)", err)
+	}
+	if len(plan.Blockers) != 1 || !strings.Contains(plan.Blockers[0], "Not Found") {
+		t.Errorf("expected a missing secret blocker, got %+v", plan)
+	}
+}
+
+func TestDiffObjects(t *testing.T) {
+	live := map[string]interface{}{"a": "x", "b": map[string]interface{}{"c": 1, "d": []string{"y"}}}
+	desired := map[string]interface{}{"a": "x", "b": map[string]interface{}{"c": 2, "d": []string{"y", "z"}}, "e": true}
+	diff, err := diffObjects("spec", live, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"spec.b.c: 1 -> 2",
+		`spec.b.d: ["y"] -> ["y","z"]`,
+		"spec.e: <none> -> true",
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %v, got %v", expected, diff)
+	}
+}
+
+// TestCustomDomainPlanMatchesReconcile tests that the plan of a reconciled CustomDomain is empty, and that the plan of
+// a certificate change lists the changes the reconcile then makes
+func TestCustomDomainPlanMatchesReconcile(t *testing.T) {
+	const clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+	instance := newQuotaTestCustomDomain("test", "External", 0)
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, newTestSecret("my-secret", "my-project"), newTestSecret("new-secret", "my-project"), instance,
+		newTestDNSRecord("test", clusterDomain))
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Config: newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.VerifyDelegation = false
+	})}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}}
+
+	reconcileAndPlan := func(t *testing.T) *customdomainv1alpha1.CustomDomainPlan {
+		if _, err := r.Reconcile(context.TODO(), req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if err := cl.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		plan, err := r.Plan(context.TODO(), log, instance)
+		if err != nil {
+			t.Fatalf("plan: (%v)", err)
+		}
+		return plan
+	}
+
+	if plan := reconcileAndPlan(t); len(plan.Changes) != 0 || len(plan.Blockers) != 0 {
+		t.Errorf("expected an empty plan once reconciled, got %+v", plan)
+	}
+
+	instance.Spec.Certificate.Name = "new-secret"
+	if err := cl.Update(context.TODO(), instance); err != nil {
+		t.Fatalf("update custom domain: (%v)", err)
+	}
+	plan, err := r.Plan(context.TODO(), log, instance)
+	if err != nil {
+		t.Fatalf("plan: (%v)", err)
+	}
+	for _, name := range []string{"my-secret", "new-secret"} {
+		if c := findPlannedChange(plan, "Secret", name); c == nil || c.Action != customdomainv1alpha1.CustomDomainPlannedUpdate {
+			t.Errorf("expected secret %s to be updated, got %+v", name, plan.Changes)
+		}
+	}
+	if plan := reconcileAndPlan(t); len(plan.Changes) != 0 || len(plan.Blockers) != 0 {
+		t.Errorf("expected an empty plan once reconciled, got %+v", plan)
+	}
+}
@@ -0,0 +1,153 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// quotaScopeAll is the scope label of the metrics counting all CustomDomains
+const quotaScopeAll = "all"
+
+var (
+	customDomainsCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "custom_domains_operator_customdomains",
+		Help: "Number of CustomDomains, overall and per scope",
+	}, []string{"scope"})
+
+	customDomainsQuota = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "custom_domains_operator_customdomains_quota",
+		Help: "Maximum number of CustomDomains, overall and per scope. Not reported for unlimited scopes.",
+	}, []string{"scope"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(customDomainsCount, customDomainsQuota)
+}
+
+// quotaLimitNegative returns true if a quota limit is set to a negative number
+func quotaLimitNegative(limit *int32) bool {
+	return limit != nil && *limit < 0
+}
+
+// customDomainScope returns the scope of a CustomDomain, External if it is not set
+func customDomainScope(instance *customdomainv1alpha1.CustomDomain) string {
+	if instance.Spec.Scope == "" {
+		return ingressDefaultScope
+	}
+	return instance.Spec.Scope
+}
+
+// scopeQuota returns the limit of a scope, or nil if it is unlimited
+func scopeQuota(quota customdomainv1alpha1.CustomDomainQuota, scope string) *int32 {
+	switch scope {
+	case "External":
+		return quota.MaxExternal
+	case "Internal":
+		return quota.MaxInternal
+	}
+	return nil
+}
+
+// listCountedCustomDomains returns the CustomDomains counted in the quota, which excludes those being deleted,
+// from the oldest to the most recent
+func listCountedCustomDomains(ctx context.Context, reader client.Reader) ([]customdomainv1alpha1.CustomDomain, error) {
+	customDomains := &customdomainv1alpha1.CustomDomainList{}
+	err := reader.List(ctx, customDomains)
+	if err != nil {
+		return nil, err
+	}
+	counted := []customdomainv1alpha1.CustomDomain{}
+	for _, instance := range customDomains.Items {
+		if instance.DeletionTimestamp == nil {
+			counted = append(counted, instance)
+		}
+	}
+	sort.Slice(counted, func(i, j int) bool {
+		if !counted[i].CreationTimestamp.Equal(&counted[j].CreationTimestamp) {
+			return counted[i].CreationTimestamp.Before(&counted[j].CreationTimestamp)
+		}
+		return counted[i].Name < counted[j].Name
+	})
+	return counted, nil
+}
+
+// quotaViolation returns why a CustomDomain of the scope can't be added to the given number of CustomDomains, or an
+// empty string if it fits in the quota
+func quotaViolation(quota customdomainv1alpha1.CustomDomainQuota, scope string, total, scoped int) string {
+	if quota.MaxCustomDomains != nil && total >= int(*quota.MaxCustomDomains) {
+		return fmt.Sprintf("the cluster is limited to %d CustomDomains", *quota.MaxCustomDomains)
+	}
+	if limit := scopeQuota(quota, scope); limit != nil && scoped >= int(*limit) {
+		return fmt.Sprintf("the cluster is limited to %d CustomDomains with the %s scope", *limit, scope)
+	}
+	return ""
+}
+
+// reportQuotaMetrics sets the metrics of the number of CustomDomains and their quota
+func reportQuotaMetrics(quota customdomainv1alpha1.CustomDomainQuota, customDomains []customdomainv1alpha1.CustomDomain) {
+	counts := map[string]int{quotaScopeAll: len(customDomains), "External": 0, "Internal": 0}
+	for i := range customDomains {
+		counts[customDomainScope(&customDomains[i])]++
+	}
+	limits := map[string]*int32{quotaScopeAll: quota.MaxCustomDomains, "External": quota.MaxExternal, "Internal": quota.MaxInternal}
+	for scope, count := range counts {
+		customDomainsCount.WithLabelValues(scope).Set(float64(count))
+		if limits[scope] != nil {
+			customDomainsQuota.WithLabelValues(scope).Set(float64(*limits[scope]))
+		} else {
+			customDomainsQuota.DeleteLabelValues(scope)
+		}
+	}
+}
+
+// customDomainQuotaViolation returns why the CustomDomain doesn't fit in the quota, or an empty string if it does. The
+// counted CustomDomains are sorted from the oldest to the most recent, and only the older ones count against it.
+func customDomainQuotaViolation(quota customdomainv1alpha1.CustomDomainQuota, customDomains []customdomainv1alpha1.CustomDomain, instance *customdomainv1alpha1.CustomDomain) string {
+	scope := customDomainScope(instance)
+	total, scoped := 0, 0
+	for i := range customDomains {
+		if customDomains[i].Name == instance.Name {
+			return quotaViolation(quota, scope, total, scoped)
+		}
+		total++
+		if customDomainScope(&customDomains[i]) == scope {
+			scoped++
+		}
+	}
+	return ""
+}
+
+// quotaProblem returns why the CustomDomain doesn't fit in the quota, or an empty string if it does. The quota
+// metrics are reported from the CustomDomains that are counted.
+func (r *CustomDomainReconciler) quotaProblem(ctx context.Context, instance *customdomainv1alpha1.CustomDomain) (string, error) {
+	quota := r.config().Quota
+	customDomains, err := listCountedCustomDomains(ctx, r.Client)
+	if err != nil {
+		return "", err
+	}
+	reportQuotaMetrics(quota, customDomains)
+
+	if violation := customDomainQuotaViolation(quota, customDomains, instance); violation != "" {
+		return fmt.Sprintf("CustomDomain quota exceeded: %s", violation), nil
+	}
+	return "", nil
+}
+
+// checkQuota returns true if the CustomDomain is over the quota. The oldest CustomDomains fit in the quota, so if the
+// quota is lowered, the most recent CustomDomains stop being reconciled and are reported with the QuotaExceeded
+// condition. Their existing resources are left untouched.
+func (r *CustomDomainReconciler) checkQuota(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) (bool, error) {
+	problem, err := r.quotaProblem(context.TODO(), instance)
+	if err != nil {
+		return false, err
+	}
+	return r.reportBlocker(reqLogger, instance, customdomainv1alpha1.CustomDomainConditionQuotaExceeded, problem,
+		"The CustomDomain is within the quota")
+}
@@ -0,0 +1,173 @@
+package managed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// newQuotaTestCustomDomain returns a CustomDomain created the given number of minutes ago
+func newQuotaTestCustomDomain(name, scope string, age int) *customdomainv1alpha1.CustomDomain {
+	return &customdomainv1alpha1.CustomDomain{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Duration(age) * time.Minute)),
+		},
+		Spec: customdomainv1alpha1.CustomDomainSpec{
+			Domain: name + ".foo.com",
+			Scope:  scope,
+			Certificate: corev1.SecretReference{
+				Name:      "my-secret",
+				Namespace: "my-project",
+			},
+		},
+	}
+}
+
+// TestCustomDomainQuota tests that the most recent CustomDomains over the quota are reported and not reconciled
+func TestCustomDomainQuota(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs,
+		newTestSecret("my-secret", "my-project"),
+		newQuotaTestCustomDomain("oldest", "External", 30),
+		newQuotaTestCustomDomain("older", "", 20),
+		newQuotaTestCustomDomain("internal", "Internal", 15),
+		newQuotaTestCustomDomain("newest", "External", 10),
+	)
+	cl := NewTestMock(t, objs...)
+	config := newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.Quota = customdomainv1alpha1.CustomDomainQuota{MaxCustomDomains: pointer.Int32(3), MaxInternal: pointer.Int32(0)}
+	})
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme(), Config: config}
+
+	tests := map[string]bool{
+		"oldest":   false,
+		"older":    false,
+		"internal": true,
+		"newest":   true,
+	}
+	for name, expectExceeded := range tests {
+		instance := &customdomainv1alpha1.CustomDomain{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: name}, instance); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		exceeded, err := r.checkQuota(log, instance)
+		if err != nil {
+			t.Fatalf("check quota: (%v)", err)
+		}
+		if exceeded != expectExceeded {
+			t.Errorf("%s: expected quota exceeded %v, got %v", name, expectExceeded, exceeded)
+		}
+	}
+
+	// a CustomDomain over the quota is not reconciled any further
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "newest"}}
+	res, err := r.Reconcile(context.TODO(), req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if !res.Requeue {
+		t.Error("expected a CustomDomain over the quota to be checked again")
+	}
+	instance := &customdomainv1alpha1.CustomDomain{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+		t.Fatalf("get custom domain: (%v)", err)
+	}
+	cond := FindCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionQuotaExceeded)
+	if cond == nil || cond.Status != corev1.ConditionTrue || instance.Status.State != customdomainv1alpha1.CustomDomainStateNotReady {
+		t.Errorf("expected QuotaExceeded condition and NotReady state, got %+v", instance.Status)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: "newest", Namespace: ingressOperatorNamespace}, &operatorv1.IngressController{})
+	if !kerr.IsNotFound(err) {
+		t.Errorf("expected no ingresscontroller for a CustomDomain over the quota: (%v)", err)
+	}
+
+	// raising the quota lets the CustomDomain through
+	r.Config = newTestOperatorConfigStore(func(c *OperatorConfig) {})
+	exceeded, err := r.checkQuota(log, instance)
+	if err != nil {
+		t.Fatalf("check quota: (%v)", err)
+	}
+	cond = FindCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionQuotaExceeded)
+	if exceeded || cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Errorf("expected the CustomDomain to be within the quota, got %+v", cond)
+	}
+}
+
+// TestCustomDomainValidatorQuota tests that the creation of CustomDomains over the quota is denied
+func TestCustomDomainValidatorQuota(t *testing.T) {
+	existing := []client.Object{
+		newQuotaTestCustomDomain("external", "External", 10),
+		newQuotaTestCustomDomain("internal", "Internal", 10),
+	}
+	tests := []struct {
+		name        string
+		quota       customdomainv1alpha1.CustomDomainQuota
+		scope       string
+		expectError bool
+	}{
+		{
+			name: "unlimited",
+		},
+		{
+			name:  "within quota",
+			quota: customdomainv1alpha1.CustomDomainQuota{MaxCustomDomains: pointer.Int32(3), MaxExternal: pointer.Int32(2)},
+		},
+		{
+			name:        "overall quota exceeded",
+			quota:       customdomainv1alpha1.CustomDomainQuota{MaxCustomDomains: pointer.Int32(2)},
+			expectError: true,
+		},
+		{
+			name:        "scope quota exceeded",
+			quota:       customdomainv1alpha1.CustomDomainQuota{MaxInternal: pointer.Int32(1)},
+			scope:       "Internal",
+			expectError: true,
+		},
+		{
+			name:  "other scope quota exceeded",
+			quota: customdomainv1alpha1.CustomDomainQuota{MaxInternal: pointer.Int32(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := NewTestMock(t, existing...)
+			v := &CustomDomainValidator{Reader: cl, Config: newTestOperatorConfigStore(func(c *OperatorConfig) { c.Quota = tt.quota })}
+			_, err := v.ValidateCreate(context.TODO(), newQuotaTestCustomDomain("new", tt.scope, 0))
+			if tt.expectError != (err != nil) {
+				t.Errorf("expected error %v, got (%v)", tt.expectError, err)
+			}
+		})
+	}
+
+	// moving a CustomDomain to a scope over the quota is denied
+	cl := NewTestMock(t, existing...)
+	v := &CustomDomainValidator{Reader: cl, Config: newTestOperatorConfigStore(func(c *OperatorConfig) {
+		c.Quota = customdomainv1alpha1.CustomDomainQuota{MaxInternal: pointer.Int32(1)}
+	})}
+	oldInstance := existing[0].(*customdomainv1alpha1.CustomDomain)
+	instance := oldInstance.DeepCopy()
+	instance.Spec.Scope = "Internal"
+	if _, err := v.ValidateUpdate(context.TODO(), oldInstance, instance); err == nil {
+		t.Error("expected moving to a scope over the quota to be denied")
+	}
+	if _, err := v.ValidateUpdate(context.TODO(), oldInstance, oldInstance); err != nil {
+		t.Errorf("expected an update within the scope to be allowed: (%v)", err)
+	}
+}
@@ -0,0 +1,340 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// routeMigrationLabelName is set on the routes created by a RouteMigration, to the name of the RouteMigration
+	routeMigrationLabelName = "customdomains.managed.openshift.io/route-migration"
+
+	// defaultRouteMigrationGracePeriod is how long old routes are kept after the new routes are admitted
+	defaultRouteMigrationGracePeriod = 24 * time.Hour
+)
+
+// RouteMigrationReconciler creates copies of routes under the domain of a CustomDomain, waits for the ingresscontroller
+// of the CustomDomain to admit them, and deletes the old routes after a grace period if asked to
+type RouteMigrationReconciler struct {
+	Client client.Client
+	// APIReader reads routes directly from the apiserver, so the operator doesn't have to cache every route in the
+	// cluster. Defaults to Client.
+	APIReader client.Reader
+	// Config holds the operator configuration. Defaults to DefaultOperatorConfig.
+	Config *OperatorConfigStore
+}
+
+//+kubebuilder:rbac:groups=managed.openshift.io,resources=routemigrations,verbs=get;list;watch
+//+kubebuilder:rbac:groups=managed.openshift.io,resources=routemigrations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;create;delete
+//+kubebuilder:rbac:groups=route.openshift.io,resources=routes/custom-host,verbs=create
+
+// reader returns the client used to read routes
+func (r *RouteMigrationReconciler) reader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// Reconcile moves the routes of a RouteMigration one step further onto the CustomDomain
+func (r *RouteMigrationReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("RouteMigration.Namespace", request.Namespace, "RouteMigration.Name", request.Name)
+
+	migration := &customdomainv1alpha1.RouteMigration{}
+	err := r.Client.Get(ctx, request.NamespacedName, migration)
+	if err != nil {
+		if kerr.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if migration.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	// routes are not watched, so the migration is checked again until it completes
+	config := operatorConfigOrDefault(r.Config)
+	requeue := reconcile.Result{Requeue: true, RequeueAfter: config.RequeueWait}
+	if config.Paused {
+		reqLogger.Info("Reconciliation is paused by the operator configuration")
+		return requeue, nil
+	}
+	setPhase := func(phase customdomainv1alpha1.RouteMigrationPhase, message string) error {
+		if phase == customdomainv1alpha1.RouteMigrationPhaseFailed {
+			reqLogger.Info(fmt.Sprintf("RouteMigration failed: %s", message))
+		}
+		migration.Status.Phase = phase
+		migration.Status.Message = message
+		return r.Client.Status().Update(ctx, migration)
+	}
+
+	instance := &customdomainv1alpha1.CustomDomain{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: migration.Spec.CustomDomain}, instance)
+	if err != nil {
+		if !kerr.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		return requeue, setPhase(customdomainv1alpha1.RouteMigrationPhasePending, fmt.Sprintf("CustomDomain %s not found", migration.Spec.CustomDomain))
+	}
+	if instance.Status.State != customdomainv1alpha1.CustomDomainStateReady {
+		return requeue, setPhase(customdomainv1alpha1.RouteMigrationPhasePending, fmt.Sprintf("CustomDomain %s is not ready", instance.Name))
+	}
+	problem, err := r.namespaceProblem(ctx, migration, instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if problem != "" {
+		return requeue, setPhase(customdomainv1alpha1.RouteMigrationPhaseFailed, problem)
+	}
+
+	sources, problem, err := r.sourceRoutes(ctx, migration, instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if problem != "" {
+		return requeue, setPhase(customdomainv1alpha1.RouteMigrationPhaseFailed, problem)
+	}
+
+	previous := map[string]customdomainv1alpha1.MigratedRoute{}
+	for _, migrated := range migration.Status.Routes {
+		previous[migrated.Source] = migrated
+	}
+	routerNames := []string{ingressControllerName(instance)}
+	statuses := []customdomainv1alpha1.MigratedRoute{}
+	for i := range sources {
+		target, problem, err := r.ensureTargetRoute(ctx, migration, instance, &sources[i])
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if problem != "" {
+			return requeue, setPhase(customdomainv1alpha1.RouteMigrationPhaseFailed, problem)
+		}
+		migrated := previous[sources[i].Name]
+		migrated.Source, migrated.Target, migrated.Host = sources[i].Name, target.Name, target.Spec.Host
+		if migrated.AdmittedTime == nil && isRouteAdmittedBy(target, routerNames) {
+			reqLogger.Info(fmt.Sprintf("Route %s is admitted by %s", target.Name, routerNames[0]))
+			now := metav1.Now()
+			migrated.AdmittedTime = &now
+		}
+		delete(previous, sources[i].Name)
+		statuses = append(statuses, migrated)
+	}
+	// the routes whose old route is already deleted are not sources anymore
+	for _, migrated := range previous {
+		if migrated.SourceDeleted {
+			statuses = append(statuses, migrated)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Source < statuses[j].Source })
+	if len(statuses) == 0 {
+		return requeue, setPhase(customdomainv1alpha1.RouteMigrationPhaseFailed, "No routes to migrate")
+	}
+
+	gracePeriod := defaultRouteMigrationGracePeriod
+	if migration.Spec.GracePeriod != nil {
+		gracePeriod = migration.Spec.GracePeriod.Duration
+	}
+	admitted, remaining := 0, []time.Duration{}
+	for i := range statuses {
+		migrated := &statuses[i]
+		if migrated.AdmittedTime == nil {
+			continue
+		}
+		admitted++
+		if migration.Spec.OldRoutePolicy != customdomainv1alpha1.OldRoutePolicyDelete || migrated.SourceDeleted {
+			continue
+		}
+		if wait := time.Until(migrated.AdmittedTime.Add(gracePeriod)); wait > 0 {
+			remaining = append(remaining, wait)
+			continue
+		}
+		reqLogger.Info(fmt.Sprintf("Deleting route %s migrated to %s", migrated.Source, migrated.Target))
+		err := r.Client.Delete(ctx, &routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: migrated.Source, Namespace: migration.Namespace}})
+		if err != nil && !kerr.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		migrated.SourceDeleted = true
+	}
+	migration.Status.Routes = statuses
+
+	if admitted < len(statuses) {
+		return requeue, setPhase(customdomainv1alpha1.RouteMigrationPhaseMigrating,
+			fmt.Sprintf("%d of %d route(s) admitted by the ingresscontroller of CustomDomain %s", admitted, len(statuses), instance.Name))
+	}
+	if len(remaining) > 0 {
+		sort.Slice(remaining, func(i, j int) bool { return remaining[i] < remaining[j] })
+		return reconcile.Result{Requeue: true, RequeueAfter: remaining[0]}, setPhase(customdomainv1alpha1.RouteMigrationPhaseAdmitted,
+			fmt.Sprintf("All routes are admitted, the old routes are deleted after the grace period of %s", gracePeriod))
+	}
+	return reconcile.Result{}, setPhase(customdomainv1alpha1.RouteMigrationPhaseCompleted, "All routes are migrated")
+}
+
+// namespaceProblem returns why the CustomDomain doesn't serve the routes of the namespace of the RouteMigration, or an
+// empty string if it does. Project admins can create RouteMigrations, so a CustomDomain must grant their namespace
+// explicitly with its namespaceSelector: one without a namespaceSelector serves every namespace but accepts none.
+func (r *RouteMigrationReconciler) namespaceProblem(ctx context.Context, migration *customdomainv1alpha1.RouteMigration, instance *customdomainv1alpha1.CustomDomain) (string, error) {
+	if instance.Spec.NamespaceSelector == nil {
+		return fmt.Sprintf("CustomDomain %s has no namespaceSelector, routes are only migrated onto CustomDomains that select their namespace",
+			instance.Name), nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(instance.Spec.NamespaceSelector)
+	if err != nil {
+		return fmt.Sprintf("invalid namespaceSelector of CustomDomain %s: %v", instance.Name, err), nil
+	}
+	namespace := &corev1.Namespace{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: migration.Namespace}, namespace)
+	if err != nil {
+		return "", err
+	}
+	if !selector.Matches(labels.Set(namespace.Labels)) {
+		return fmt.Sprintf("namespace %s does not match the namespaceSelector of CustomDomain %s, label it with %s",
+			migration.Namespace, instance.Name, metav1.FormatLabelSelector(instance.Spec.NamespaceSelector)), nil
+	}
+	return "", nil
+}
+
+// sourceRoutes returns the routes to migrate, leaving out the routes created by migrations and the routes already
+// under the custom domain. It returns a problem if a listed route doesn't exist and wasn't deleted by the migration.
+func (r *RouteMigrationReconciler) sourceRoutes(ctx context.Context, migration *customdomainv1alpha1.RouteMigration, instance *customdomainv1alpha1.CustomDomain) ([]routev1.Route, string, error) {
+	routeList := &routev1.RouteList{}
+	err := r.reader().List(ctx, routeList, client.InNamespace(migration.Namespace))
+	if err != nil {
+		return nil, "", err
+	}
+	var selector labels.Selector
+	if migration.Spec.RouteSelector != nil {
+		selector, err = metav1.LabelSelectorAsSelector(migration.Spec.RouteSelector)
+		if err != nil {
+			return nil, fmt.Sprintf("invalid routeSelector: %v", err), nil
+		}
+	}
+
+	found := map[string]bool{}
+	sources := []routev1.Route{}
+	for _, route := range routeList.Items {
+		found[route.Name] = true
+		if !contains(migration.Spec.Routes, route.Name) && (selector == nil || !selector.Matches(labels.Set(route.Labels))) {
+			continue
+		}
+		if route.Labels[routeMigrationLabelName] != "" || (route.Spec.Host != "" && domainHasAllowedSuffix(route.Spec.Host, []string{instance.Spec.Domain})) {
+			continue
+		}
+		sources = append(sources, route)
+	}
+
+	for _, name := range migration.Spec.Routes {
+		if found[name] {
+			continue
+		}
+		deleted := false
+		for _, migrated := range migration.Status.Routes {
+			deleted = deleted || (migrated.Source == name && migrated.SourceDeleted)
+		}
+		if !deleted {
+			return nil, fmt.Sprintf("route %s not found", name), nil
+		}
+	}
+	return sources, "", nil
+}
+
+// targetRouteHost returns the host of the copy of a route under the custom domain, which keeps the first label of
+// the host of the route
+func targetRouteHost(source *routev1.Route, domain string) string {
+	label := fmt.Sprintf("%s-%s", source.Name, source.Namespace)
+	if source.Spec.Host != "" {
+		label = strings.SplitN(source.Spec.Host, ".", 2)[0]
+	}
+	return fmt.Sprintf("%s.%s", label, normalizeDomain(domain))
+}
+
+// newTargetRoute returns the copy of a route under the custom domain, labeled to be selected by the routeSelector of
+// the CustomDomain. The route's own certificate is left out, as it doesn't match the new host, so the default
+// certificate of the ingresscontroller is used.
+func newTargetRoute(migration *customdomainv1alpha1.RouteMigration, instance *customdomainv1alpha1.CustomDomain, source *routev1.Route) *routev1.Route {
+	target := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%s", source.Name, instance.Name),
+			Namespace:   source.Namespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+		Spec: *source.Spec.DeepCopy(),
+	}
+	for k, v := range source.Labels {
+		target.Labels[k] = v
+	}
+	for k, v := range source.Annotations {
+		target.Annotations[k] = v
+	}
+	if instance.Spec.RouteSelector != nil {
+		for k, v := range instance.Spec.RouteSelector.MatchLabels {
+			target.Labels[k] = v
+		}
+	}
+	target.Labels[routeMigrationLabelName] = migration.Name
+
+	target.Spec.Host = targetRouteHost(source, instance.Spec.Domain)
+	target.Spec.Subdomain = ""
+	if target.Spec.TLS != nil && target.Spec.TLS.Termination != routev1.TLSTerminationPassthrough {
+		target.Spec.TLS.Certificate = ""
+		target.Spec.TLS.Key = ""
+		target.Spec.TLS.CACertificate = ""
+	}
+	return target
+}
+
+// ensureTargetRoute creates the copy of a route under the custom domain if it doesn't exist. It returns a problem if
+// the copy can't be selected by the CustomDomain or its name is taken.
+func (r *RouteMigrationReconciler) ensureTargetRoute(ctx context.Context, migration *customdomainv1alpha1.RouteMigration, instance *customdomainv1alpha1.CustomDomain, source *routev1.Route) (*routev1.Route, string, error) {
+	target := newTargetRoute(migration, instance, source)
+	if errs := validation.IsDNS1123Subdomain(target.Spec.Host); len(errs) > 0 {
+		return nil, fmt.Sprintf("host %s of the copy of route %s is invalid: %s", target.Spec.Host, source.Name, strings.Join(errs, ", ")), nil
+	}
+	if instance.Spec.RouteSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(instance.Spec.RouteSelector)
+		if err != nil {
+			return nil, fmt.Sprintf("invalid routeSelector of CustomDomain %s: %v", instance.Name, err), nil
+		}
+		if !selector.Matches(labels.Set(target.Labels)) {
+			return nil, fmt.Sprintf("the copy of route %s can't be labeled to match the routeSelector of CustomDomain %s: %s",
+				source.Name, instance.Name, metav1.FormatLabelSelector(instance.Spec.RouteSelector)), nil
+		}
+	}
+
+	existing := &routev1.Route{}
+	err := r.reader().Get(ctx, client.ObjectKeyFromObject(target), existing)
+	if err == nil {
+		if existing.Labels[routeMigrationLabelName] != migration.Name {
+			return nil, fmt.Sprintf("route %s already exists and was not created by this migration", existing.Name), nil
+		}
+		return existing, "", nil
+	}
+	if !kerr.IsNotFound(err) {
+		return nil, "", err
+	}
+	log.Info(fmt.Sprintf("Creating route %s/%s with host %s", target.Namespace, target.Name, target.Spec.Host))
+	return target, "", r.Client.Create(ctx, target)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RouteMigrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&customdomainv1alpha1.RouteMigration{}).
+		Complete(r)
+}
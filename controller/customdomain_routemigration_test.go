@@ -0,0 +1,147 @@
+package managed
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// newRouteMigrationTestObjects returns a ready CustomDomain selecting routes by label and serving the my-project
+// namespace, a route on the default apps domain, and a RouteMigration moving it onto the CustomDomain
+func newRouteMigrationTestObjects() (*customdomainv1alpha1.CustomDomain, *routev1.Route, *customdomainv1alpha1.RouteMigration) {
+	instance := newQuotaTestCustomDomain("test", "External", 0)
+	instance.Spec.Domain = "apps.foo.com"
+	instance.Spec.RouteSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"type": "public"}}
+	instance.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabelName: "my-project"}}
+	instance.Status.State = customdomainv1alpha1.CustomDomainStateReady
+
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "my-project", Labels: map[string]string{"app": "frontend"}},
+		Spec: routev1.RouteSpec{
+			Host: "frontend-my-project.apps.cluster1.x8s0.s1.openshiftapps.com",
+			To:   routev1.RouteTargetReference{Kind: "Service", Name: "frontend"},
+			TLS: &routev1.TLSConfig{
+				Termination: routev1.TLSTerminationEdge,
+				Certificate: "DEADBEEF",
+				Key:         "DEADBEEF",
+			},
+		},
+	}
+
+	migration := &customdomainv1alpha1.RouteMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "my-project"},
+		Spec: customdomainv1alpha1.RouteMigrationSpec{
+			CustomDomain:   "test",
+			Routes:         []string{"frontend"},
+			OldRoutePolicy: customdomainv1alpha1.OldRoutePolicyDelete,
+			GracePeriod:    &metav1.Duration{},
+		},
+	}
+	return instance, route, migration
+}
+
+// reconcileTestRouteMigration reconciles a RouteMigration and returns it
+func reconcileTestRouteMigration(t *testing.T, r *RouteMigrationReconciler, migration *customdomainv1alpha1.RouteMigration) *customdomainv1alpha1.RouteMigration {
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: migration.Name, Namespace: migration.Namespace}}
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	migration = &customdomainv1alpha1.RouteMigration{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, migration); err != nil {
+		t.Fatalf("get route migration: (%v)", err)
+	}
+	return migration
+}
+
+// TestRouteMigration tests that routes are copied under the custom domain, and the old routes are deleted once the
+// copies are admitted
+func TestRouteMigration(t *testing.T) {
+	instance, route, migration := newRouteMigrationTestObjects()
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-project", Labels: map[string]string{namespaceNameLabelName: "my-project"}}}
+	cl := NewTestMock(t, instance, route, migration, namespace)
+	r := &RouteMigrationReconciler{Client: cl}
+
+	migration = reconcileTestRouteMigration(t, r, migration)
+	if migration.Status.Phase != customdomainv1alpha1.RouteMigrationPhaseMigrating || len(migration.Status.Routes) != 1 {
+		t.Fatalf("expected the migration to wait for admission, got %+v", migration.Status)
+	}
+	target := &routev1.Route{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "frontend-test", Namespace: "my-project"}, target); err != nil {
+		t.Fatalf("get new route: (%v)", err)
+	}
+	if target.Spec.Host != "frontend-my-project.apps.foo.com" || target.Labels["type"] != "public" || target.Labels[routeMigrationLabelName] != "frontend" {
+		t.Errorf("unexpected new route host %s and labels %v", target.Spec.Host, target.Labels)
+	}
+	if target.Spec.TLS.Certificate != "" || target.Spec.TLS.Termination != routev1.TLSTerminationEdge {
+		t.Errorf("expected the new route to use the certificate of the ingresscontroller, got %+v", target.Spec.TLS)
+	}
+
+	// the ingresscontroller of the CustomDomain admits the new route
+	target.Status.Ingress = []routev1.RouteIngress{{
+		RouterName: "test",
+		Conditions: []routev1.RouteIngressCondition{{Type: routev1.RouteAdmitted, Status: corev1.ConditionTrue}},
+	}}
+	if err := cl.Update(context.TODO(), target); err != nil {
+		t.Fatalf("update new route: (%v)", err)
+	}
+	migration = reconcileTestRouteMigration(t, r, migration)
+	if migration.Status.Phase != customdomainv1alpha1.RouteMigrationPhaseCompleted || !migration.Status.Routes[0].SourceDeleted {
+		t.Errorf("expected the migration to be completed, got %+v", migration.Status)
+	}
+	err := cl.Get(context.TODO(), types.NamespacedName{Name: "frontend", Namespace: "my-project"}, &routev1.Route{})
+	if !kerr.IsNotFound(err) {
+		t.Errorf("expected the old route to be deleted: (%v)", err)
+	}
+
+	// the migration stays completed once the old route is gone
+	migration = reconcileTestRouteMigration(t, r, migration)
+	if migration.Status.Phase != customdomainv1alpha1.RouteMigrationPhaseCompleted {
+		t.Errorf("expected the migration to stay completed, got %+v", migration.Status)
+	}
+}
+
+// TestRouteMigrationNamespaceNotSelected tests that routes are not migrated onto a CustomDomain that doesn't serve
+// their namespace
+func TestRouteMigrationNamespaceNotSelected(t *testing.T) {
+	instance, route, migration := newRouteMigrationTestObjects()
+	instance.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"kind": "core"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-project"}}
+	cl := NewTestMock(t, instance, route, migration, namespace)
+	r := &RouteMigrationReconciler{Client: cl}
+
+	migration = reconcileTestRouteMigration(t, r, migration)
+	if migration.Status.Phase != customdomainv1alpha1.RouteMigrationPhaseFailed {
+		t.Errorf("expected the migration to fail, got %+v", migration.Status)
+	}
+	err := cl.Get(context.TODO(), types.NamespacedName{Name: "frontend-test", Namespace: "my-project"}, &routev1.Route{})
+	if !kerr.IsNotFound(err) {
+		t.Errorf("expected no new route: (%v)", err)
+	}
+}
+
+// TestRouteMigrationNamespaceNotGranted tests that routes are not migrated onto a CustomDomain without a
+// namespaceSelector, as it doesn't grant any namespace
+func TestRouteMigrationNamespaceNotGranted(t *testing.T) {
+	instance, route, migration := newRouteMigrationTestObjects()
+	instance.Spec.NamespaceSelector = nil
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-project", Labels: map[string]string{namespaceNameLabelName: "my-project"}}}
+	cl := NewTestMock(t, instance, route, migration, namespace)
+	r := &RouteMigrationReconciler{Client: cl}
+
+	migration = reconcileTestRouteMigration(t, r, migration)
+	if migration.Status.Phase != customdomainv1alpha1.RouteMigrationPhaseFailed || !strings.Contains(migration.Status.Message, "namespaceSelector") {
+		t.Errorf("expected the migration to fail, got %+v", migration.Status)
+	}
+	err := cl.Get(context.TODO(), types.NamespacedName{Name: "frontend-test", Namespace: "my-project"}, &routev1.Route{})
+	if !kerr.IsNotFound(err) {
+		t.Errorf("expected no new route: (%v)", err)
+	}
+}
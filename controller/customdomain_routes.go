@@ -0,0 +1,128 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch
+
+// routeHostDomainsIndex indexes routes by their host and each of its parent domains, to find the routes under a
+// custom domain in the cache
+const routeHostDomainsIndex = "spec.host.suffixes"
+
+// indexRouteHostDomains is the indexer of routeHostDomainsIndex
+func indexRouteHostDomains(obj client.Object) []string {
+	route, ok := obj.(*routev1.Route)
+	if !ok || route.Spec.Host == "" {
+		return nil
+	}
+	return append([]string{normalizeDomain(route.Spec.Host)}, parentDomains(route.Spec.Host)...)
+}
+
+// routeAdmittingRouters returns the routers that admitted the route, sorted by name
+func routeAdmittingRouters(route *routev1.Route) []string {
+	routers := []string{}
+	for _, ingress := range route.Status.Ingress {
+		for _, condition := range ingress.Conditions {
+			if condition.Type == routev1.RouteAdmitted && condition.Status == corev1.ConditionTrue && !contains(routers, ingress.RouterName) {
+				routers = append(routers, ingress.RouterName)
+			}
+		}
+	}
+	sort.Strings(routers)
+	return routers
+}
+
+// analyzeRoutes counts the routes whose host is under the custom domain by the routers that admitted them, and lists
+// the first misconfigured ones. routerNames are the routers of the CustomDomain.
+func analyzeRoutes(routes []routev1.Route, domain string, routerNames []string) *customdomainv1alpha1.CustomDomainRouteAnalysis {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Namespace != routes[j].Namespace {
+			return routes[i].Namespace < routes[j].Namespace
+		}
+		return routes[i].Name < routes[j].Name
+	})
+
+	analysis := &customdomainv1alpha1.CustomDomainRouteAnalysis{}
+	for i := range routes {
+		route := &routes[i]
+		if route.Spec.Host == "" || !domainHasAllowedSuffix(route.Spec.Host, []string{domain}) {
+			continue
+		}
+		analysis.Routes++
+
+		routers := routeAdmittingRouters(route)
+		own, others := 0, 0
+		for _, router := range routers {
+			if contains(routerNames, router) {
+				own++
+			} else {
+				others++
+			}
+		}
+
+		var problem customdomainv1alpha1.CustomDomainRouteProblemType
+		switch {
+		case own > 0 && others == 0:
+			analysis.Admitted++
+			continue
+		case own > 0:
+			analysis.DoubleAdmitted++
+			problem = customdomainv1alpha1.CustomDomainRouteDoubleAdmitted
+		case others > 0:
+			analysis.WrongShard++
+			problem = customdomainv1alpha1.CustomDomainRouteWrongShard
+		default:
+			analysis.Unadmitted++
+			problem = customdomainv1alpha1.CustomDomainRouteUnadmitted
+		}
+		if len(analysis.Problems) < maxListedRoutes {
+			analysis.Problems = append(analysis.Problems, customdomainv1alpha1.CustomDomainRouteProblem{
+				Route:              fmt.Sprintf("%s/%s", route.Namespace, route.Name),
+				Host:               route.Spec.Host,
+				Problem:            problem,
+				IngressControllers: routers,
+			})
+		}
+	}
+	return analysis
+}
+
+// analyzeCustomDomainRoutes reports the analysis of the routes under the custom domain in the status of the
+// CustomDomain, and sets the RoutesMisconfigured condition. Failing to list routes keeps the previous analysis. The
+// routes are listed from the cache with routeHostDomainsIndex, as every CustomDomain is analyzed on each reconcile.
+func (r *CustomDomainReconciler) analyzeCustomDomainRoutes(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) {
+	routeList := &routev1.RouteList{}
+	err := r.Client.List(context.TODO(), routeList, client.MatchingFields{routeHostDomainsIndex: normalizeDomain(instance.Spec.Domain)})
+	if err != nil {
+		reqLogger.Error(err, "Failed to list routes, keeping the previous route analysis")
+		return
+	}
+
+	routerNames := []string{ingressControllerName(instance)}
+	if instance.Status.ScopeMigration != nil {
+		routerNames = append(routerNames, instance.Status.ScopeMigration.PreviousIngressController, instance.Status.ScopeMigration.IngressController)
+	}
+	analysis := analyzeRoutes(routeList.Items, instance.Spec.Domain, routerNames)
+	instance.Status.RouteAnalysis = analysis
+
+	misconfigured := analysis.Unadmitted + analysis.DoubleAdmitted + analysis.WrongShard
+	if misconfigured == 0 {
+		instance.Status.Conditions = setCustomDomainConditionStatus(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionRoutesMisconfigured,
+			corev1.ConditionFalse, fmt.Sprintf("All %d route(s) under the custom domain are admitted by its ingresscontroller only", analysis.Routes), UpdateConditionIfReasonOrMessageChange)
+		return
+	}
+	message := fmt.Sprintf("%d of %d route(s) under the custom domain are misconfigured: %d unadmitted, %d also admitted by another ingresscontroller, %d only admitted by another ingresscontroller",
+		misconfigured, analysis.Routes, analysis.Unadmitted, analysis.DoubleAdmitted, analysis.WrongShard)
+	reqLogger.Info(message)
+	instance.Status.Conditions = setCustomDomainConditionStatus(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionRoutesMisconfigured,
+		corev1.ConditionTrue, message, UpdateConditionIfReasonOrMessageChange)
+}
@@ -0,0 +1,77 @@
+package managed
+
+import (
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newAnalysisTestRoute returns a route with the given host, and the admission status of each router
+func newAnalysisTestRoute(name, host string, routers map[string]corev1.ConditionStatus) *routev1.Route {
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "my-project"},
+		Spec:       routev1.RouteSpec{Host: host},
+	}
+	for routerName, admitted := range routers {
+		route.Status.Ingress = append(route.Status.Ingress, routev1.RouteIngress{
+			RouterName: routerName,
+			Conditions: []routev1.RouteIngressCondition{{Type: routev1.RouteAdmitted, Status: admitted}},
+		})
+	}
+	return route
+}
+
+// TestCustomDomainRouteAnalysis tests that the routes under the custom domain are counted by the routers that
+// admitted them, and that misconfigured routes are reported
+func TestCustomDomainRouteAnalysis(t *testing.T) {
+	instance := newQuotaTestCustomDomain("test", "External", 0)
+	instance.Spec.Domain = "apps.foo.com"
+	cl := NewTestMock(t,
+		instance,
+		newAnalysisTestRoute("served", "served.apps.foo.com", map[string]corev1.ConditionStatus{"test": corev1.ConditionTrue}),
+		newAnalysisTestRoute("double", "double.apps.foo.com", map[string]corev1.ConditionStatus{"test": corev1.ConditionTrue, "default": corev1.ConditionTrue}),
+		newAnalysisTestRoute("wrong-shard", "wrong.apps.foo.com", map[string]corev1.ConditionStatus{"test": corev1.ConditionFalse, "default": corev1.ConditionTrue}),
+		newAnalysisTestRoute("unadmitted", "unadmitted.apps.foo.com", map[string]corev1.ConditionStatus{"test": corev1.ConditionFalse}),
+		newAnalysisTestRoute("other-domain", "app.bar.com", map[string]corev1.ConditionStatus{"default": corev1.ConditionTrue}),
+		newAnalysisTestRoute("lookalike", "app.myapps.foo.com", map[string]corev1.ConditionStatus{"default": corev1.ConditionTrue}),
+		newAnalysisTestRoute("parent", "foo.com", map[string]corev1.ConditionStatus{"default": corev1.ConditionTrue}),
+	)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+
+	r.analyzeCustomDomainRoutes(log, instance)
+	analysis := instance.Status.RouteAnalysis
+	if analysis == nil {
+		t.Fatal("expected a route analysis")
+	}
+	if analysis.Routes != 4 || analysis.Admitted != 1 || analysis.DoubleAdmitted != 1 || analysis.WrongShard != 1 || analysis.Unadmitted != 1 {
+		t.Errorf("unexpected route counts %+v", analysis)
+	}
+	expected := map[string]customdomainv1alpha1.CustomDomainRouteProblemType{
+		"my-project/double":      customdomainv1alpha1.CustomDomainRouteDoubleAdmitted,
+		"my-project/wrong-shard": customdomainv1alpha1.CustomDomainRouteWrongShard,
+		"my-project/unadmitted":  customdomainv1alpha1.CustomDomainRouteUnadmitted,
+	}
+	if len(analysis.Problems) != len(expected) {
+		t.Errorf("expected %d problems, got %+v", len(expected), analysis.Problems)
+	}
+	for _, problem := range analysis.Problems {
+		if expected[problem.Route] != problem.Problem {
+			t.Errorf("%s: expected problem %s, got %s", problem.Route, expected[problem.Route], problem.Problem)
+		}
+	}
+	cond := FindCustomDomainCondition(instance.Status.Conditions, customdomainv1alpha1.CustomDomainConditionRoutesMisconfigured)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("expected RoutesMisconfigured condition, got %+v", cond)
+	}
+
+	// routes only admitted by the ingresscontroller of the CustomDomain are not misconfigured
+	analysis = analyzeRoutes([]routev1.Route{
+		*newAnalysisTestRoute("served", "served.apps.foo.com", map[string]corev1.ConditionStatus{"test": corev1.ConditionTrue}),
+	}, instance.Spec.Domain, []string{"test"})
+	if analysis.Routes != 1 || analysis.Admitted != 1 || len(analysis.Problems) != 0 {
+		t.Errorf("unexpected route analysis %+v", analysis)
+	}
+}
@@ -0,0 +1,158 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// secretCustomDomains returns the names of the CustomDomains using a user secret, as recorded by the operator
+func secretCustomDomains(secret *corev1.Secret) []string {
+	names := []string{}
+	if annotation := secret.Annotations[customDomainsAnnotationName]; annotation != "" {
+		names = strings.Split(annotation, ",")
+	}
+	if label := secret.Labels[managedLabelName]; label != "" && !contains(names, label) {
+		names = append(names, label)
+	}
+	return names
+}
+
+// claimUserSecret labels and annotates the user secret of a CustomDomain, so that changes to it are reconciled.
+// A secret can be shared by several CustomDomains, which are all listed in its annotation.
+// Returns true if the secret was changed.
+func claimUserSecret(secret *corev1.Secret, instanceName string) bool {
+	changed := false
+	if _, ok := secret.Labels[managedLabelName]; !ok {
+		if secret.Labels == nil {
+			secret.Labels = make(map[string]string)
+		}
+		secret.Labels[managedLabelName] = instanceName
+		changed = true
+	}
+	names := secretCustomDomains(secret)
+	if !contains(names, instanceName) {
+		names = append(names, instanceName)
+	}
+	sort.Strings(names)
+	annotation := strings.Join(names, ",")
+	if secret.Annotations[customDomainsAnnotationName] != annotation {
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		secret.Annotations[customDomainsAnnotationName] = annotation
+		changed = true
+	}
+	return changed
+}
+
+// releaseUserSecret removes a CustomDomain from its user secret. Once no CustomDomain uses the secret anymore,
+// the label and annotation added by the operator are removed, restoring the secret to its state before it was managed.
+func (r *CustomDomainReconciler) releaseUserSecret(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) error {
+	userSecret := &corev1.Secret{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{
+		Namespace: instance.Spec.Certificate.Namespace,
+		Name:      instance.Spec.Certificate.Name,
+	}, userSecret)
+	if err != nil {
+		if !kerr.IsNotFound(err) {
+			reqLogger.Error(err, fmt.Sprintf("Error fetching secret %s in %s namespace", instance.Spec.Certificate.Name, instance.Spec.Certificate.Namespace))
+			return err
+		}
+		reqLogger.Info(fmt.Sprintf("Secret %s was not found, skipping.", instance.Spec.Certificate.Name))
+		return nil
+	}
+	return r.releaseSecret(reqLogger, userSecret, instance.Name)
+}
+
+// previousUserSecrets returns the user secrets that still list a CustomDomain but are no longer referenced by it,
+// after its certificate was changed to another secret
+func (r *CustomDomainReconciler) previousUserSecrets(ctx context.Context, instance *customdomainv1alpha1.CustomDomain) ([]corev1.Secret, error) {
+	secrets := &corev1.SecretList{}
+	err := r.Client.List(ctx, secrets, client.HasLabels{managedLabelName})
+	if err != nil {
+		return nil, err
+	}
+	previous := []corev1.Secret{}
+	for _, secret := range secrets.Items {
+		// the certificates copied to the ingress namespace are not user secrets
+		if secret.Namespace == ingressNamespace {
+			continue
+		}
+		if secret.Namespace == instance.Spec.Certificate.Namespace && secret.Name == instance.Spec.Certificate.Name {
+			continue
+		}
+		if contains(secretCustomDomains(&secret), instance.Name) {
+			previous = append(previous, secret)
+		}
+	}
+	return previous, nil
+}
+
+// releasePreviousUserSecrets removes a CustomDomain from the user secrets it no longer references, after its
+// certificate was changed to another secret
+func (r *CustomDomainReconciler) releasePreviousUserSecrets(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) error {
+	secrets, err := r.previousUserSecrets(context.TODO(), instance)
+	if err != nil {
+		reqLogger.Error(err, "Error listing the secrets managed by the custom domains operator")
+		return err
+	}
+	for i := range secrets {
+		if err := r.releaseSecret(reqLogger, &secrets[i], instance.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unclaimUserSecret removes a CustomDomain from the label and annotation of a user secret. Once no CustomDomain uses
+// the secret anymore, the label and annotation are removed. Returns true if the secret was changed.
+func unclaimUserSecret(secret *corev1.Secret, instanceName string) bool {
+	names := secretCustomDomains(secret)
+	if !contains(names, instanceName) {
+		return false
+	}
+	names = remove(names, instanceName)
+
+	if len(names) == 0 {
+		delete(secret.Labels, managedLabelName)
+		delete(secret.Annotations, customDomainsAnnotationName)
+		return true
+	}
+	sort.Strings(names)
+	if secret.Labels[managedLabelName] == instanceName {
+		secret.Labels[managedLabelName] = names[0]
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[customDomainsAnnotationName] = strings.Join(names, ",")
+	return true
+}
+
+// releaseSecret removes a CustomDomain from the label and annotation of a user secret
+func (r *CustomDomainReconciler) releaseSecret(reqLogger logr.Logger, userSecret *corev1.Secret, instanceName string) error {
+	if !unclaimUserSecret(userSecret, instanceName) {
+		reqLogger.Info(fmt.Sprintf("Secret %s is not used by customdomain %s, skipping.", userSecret.Name, instanceName))
+		return nil
+	}
+	if names := secretCustomDomains(userSecret); len(names) == 0 {
+		reqLogger.Info(fmt.Sprintf("Removed custom domain label and annotation from secret %s", userSecret.Name))
+	} else {
+		reqLogger.Info(fmt.Sprintf("Secret %s is still used by customdomains %v", userSecret.Name, names))
+	}
+	err := r.Client.Update(context.TODO(), userSecret)
+	if err != nil {
+		reqLogger.Error(err, fmt.Sprintf("Error updating secret %s in %s namespace", userSecret.Name, userSecret.Namespace))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package managed
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TestCustomDomainSharedSecretRelease tests that a user secret shared by two CustomDomains is only restored
+// to its original state once both CustomDomains are deleted
+func TestCustomDomainSharedSecretRelease(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain  = "cluster1.x8s0.s1.openshiftapps.com"
+		userNamespace  = "my-project"
+		userSecretName = "my-secret"
+	)
+
+	userSecret := newTestSecret(userSecretName, userNamespace)
+	userSecret.Labels = map[string]string{"app": "my-app"}
+	userSecret.Annotations = map[string]string{"owner": "me"}
+	originalLabels := map[string]string{"app": "my-app"}
+	originalAnnotations := map[string]string{"owner": "me"}
+
+	newCustomDomain := func(name string) *customdomainv1alpha1.CustomDomain {
+		return &customdomainv1alpha1.CustomDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: customdomainv1alpha1.CustomDomainSpec{
+				Domain: name + ".foo.com",
+				Scope:  "External",
+				Certificate: corev1.SecretReference{
+					Name:      userSecretName,
+					Namespace: userNamespace,
+				},
+			},
+		}
+	}
+
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, userSecret, newCustomDomain("first"), newCustomDomain("second"),
+		newTestDNSRecord("first", clusterDomain), newTestDNSRecord("second", clusterDomain))
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+
+	getUserSecret := func(t *testing.T) *corev1.Secret {
+		secret := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: userSecretName, Namespace: userNamespace}, secret); err != nil {
+			t.Fatalf("get user secret: (%v)", err)
+		}
+		return secret
+	}
+	deleteCustomDomain := func(t *testing.T, name string) {
+		customdomain := &customdomainv1alpha1.CustomDomain{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: name}, customdomain); err != nil {
+			t.Fatalf("get custom domain: (%v)", err)
+		}
+		if err := cl.Delete(context.TODO(), customdomain); err != nil {
+			t.Fatalf("delete custom domain: (%v)", err)
+		}
+		if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: name}}); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+	}
+
+	for _, name := range []string{"first", "second"} {
+		if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: name}}); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+	}
+	if names := secretCustomDomains(getUserSecret(t)); !reflect.DeepEqual(names, []string{"first", "second"}) {
+		t.Fatalf("user secret does not list both custom domains: (%v)", names)
+	}
+
+	// The secret is handed over to the remaining CustomDomain
+	deleteCustomDomain(t, "first")
+	secret := getUserSecret(t)
+	if secret.Labels[managedLabelName] != "second" {
+		t.Errorf("user secret label was not handed over: (%v)", secret.Labels)
+	}
+	if names := secretCustomDomains(secret); !reflect.DeepEqual(names, []string{"second"}) {
+		t.Errorf("user secret still lists the deleted custom domain: (%v)", names)
+	}
+
+	// The secret is restored once no CustomDomain uses it
+	deleteCustomDomain(t, "second")
+	secret = getUserSecret(t)
+	if !reflect.DeepEqual(secret.Labels, originalLabels) {
+		t.Errorf("user secret labels were not restored: expected (%v), got (%v)", originalLabels, secret.Labels)
+	}
+	if !reflect.DeepEqual(secret.Annotations, originalAnnotations) {
+		t.Errorf("user secret annotations were not restored: expected (%v), got (%v)", originalAnnotations, secret.Annotations)
+	}
+}
+
+// TestCustomDomainCertificateChangeReleasesSecret tests that the previous user secret of a CustomDomain is restored
+// to its original state once the CustomDomain's certificate references another secret
+func TestCustomDomainCertificateChangeReleasesSecret(t *testing.T) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	const (
+		clusterDomain = "cluster1.x8s0.s1.openshiftapps.com"
+		userNamespace = "my-project"
+	)
+
+	previousSecret := newTestSecret("previous", userNamespace)
+	previousSecret.Annotations = map[string]string{"owner": "me"}
+	customDomain := &customdomainv1alpha1.CustomDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: customdomainv1alpha1.CustomDomainSpec{
+			Domain:      "test.foo.com",
+			Scope:       "External",
+			Certificate: corev1.SecretReference{Name: "previous", Namespace: userNamespace},
+		},
+	}
+
+	objs := newTestClusterObjects(clusterDomain, &configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+	objs = append(objs, previousSecret, newTestSecret("next", userNamespace), customDomain, newTestDNSRecord("test", clusterDomain))
+	cl := NewTestMock(t, objs...)
+	r := &CustomDomainReconciler{Client: cl, Scheme: cl.Scheme()}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}}
+
+	getUserSecret := func(t *testing.T, name string) *corev1.Secret {
+		secret := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: userNamespace}, secret); err != nil {
+			t.Fatalf("get user secret: (%v)", err)
+		}
+		return secret
+	}
+
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if names := secretCustomDomains(getUserSecret(t, "previous")); !reflect.DeepEqual(names, []string{"test"}) {
+		t.Fatalf("previous secret does not list the custom domain: (%v)", names)
+	}
+
+	if err := cl.Get(context.TODO(), req.NamespacedName, customDomain); err != nil {
+		t.Fatalf("get custom domain: (%v)", err)
+	}
+	customDomain.Spec.Certificate.Name = "next"
+	if err := cl.Update(context.TODO(), customDomain); err != nil {
+		t.Fatalf("update custom domain: (%v)", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	previous := getUserSecret(t, "previous")
+	if _, ok := previous.Labels[managedLabelName]; ok {
+		t.Errorf("previous secret is still labeled: (%v)", previous.Labels)
+	}
+	if expected := map[string]string{"owner": "me"}; !reflect.DeepEqual(previous.Annotations, expected) {
+		t.Errorf("previous secret annotations were not restored: expected (%v), got (%v)", expected, previous.Annotations)
+	}
+	if names := secretCustomDomains(getUserSecret(t, "next")); !reflect.DeepEqual(names, []string{"test"}) {
+		t.Errorf("next secret does not list the custom domain: (%v)", names)
+	}
+}
@@ -0,0 +1,164 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// OrphanSweeperMode determines what the OrphanSweeper does with orphaned resources
+type OrphanSweeperMode string
+
+const (
+	// OrphanSweeperModeReport only reports orphaned resources with a metric and an event
+	OrphanSweeperModeReport OrphanSweeperMode = "report"
+
+	// OrphanSweeperModeDelete deletes orphaned resources
+	OrphanSweeperModeDelete OrphanSweeperMode = "delete"
+)
+
+var orphanedResources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "custom_domains_operator_orphaned_resources",
+	Help: "Number of resources labeled as managed by the custom domains operator that do not belong to a CustomDomain",
+}, []string{"kind"})
+
+func init() {
+	metrics.Registry.MustRegister(orphanedResources)
+}
+
+// OrphanSweeper periodically looks for ingresscontrollers and secrets labeled as managed by the operator whose
+// CustomDomain no longer exists, e.g. because its finalizer was removed by hand, and reports or deletes them. The
+// resources left in place by the deletion policy of their CustomDomain are not orphans.
+type OrphanSweeper struct {
+	Client   client.Client
+	Recorder record.EventRecorder
+	Interval time.Duration
+	Mode     OrphanSweeperMode
+	// Config holds the operator configuration. Defaults to DefaultOperatorConfig.
+	Config *OperatorConfigStore
+}
+
+var _ manager.LeaderElectionRunnable = &OrphanSweeper{}
+
+// Start runs the sweeper until the context is cancelled
+func (s *OrphanSweeper) Start(ctx context.Context) error {
+	reqLogger := logf.FromContext(ctx).WithName("orphan-sweeper")
+	reqLogger.Info(fmt.Sprintf("Sweeping orphaned resources every %s in %s mode", s.Interval, s.Mode))
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.Sweep(ctx, reqLogger); err != nil {
+			reqLogger.Error(err, "Failed to sweep orphaned resources")
+		}
+	}, s.Interval)
+	return nil
+}
+
+// NeedLeaderElection makes only the leader sweep orphaned resources
+func (s *OrphanSweeper) NeedLeaderElection() bool {
+	return true
+}
+
+// Sweep reports or deletes the orphaned resources once
+func (s *OrphanSweeper) Sweep(ctx context.Context, reqLogger logr.Logger) error {
+	customDomains := &customdomainv1alpha1.CustomDomainList{}
+	err := s.Client.List(ctx, customDomains)
+	if err != nil {
+		return err
+	}
+	secretNames := []string{}
+	ingressNames := []string{}
+	for i := range customDomains.Items {
+		instance := &customDomains.Items[i]
+		secretNames = append(secretNames, instance.Name)
+		ingressNames = append(ingressNames, instance.Name, ingressControllerName(instance))
+		if instance.Status.ScopeMigration != nil {
+			ingressNames = append(ingressNames, instance.Status.ScopeMigration.PreviousIngressController, instance.Status.ScopeMigration.IngressController)
+		}
+	}
+
+	// Resources created since the last sweep may belong to a CustomDomain that is not in the cache yet
+	createdBefore := time.Now().Add(-s.Interval)
+
+	ingressList := &operatorv1.IngressControllerList{}
+	err = s.Client.List(ctx, ingressList, client.InNamespace(ingressOperatorNamespace), client.HasLabels{managedLabelName})
+	if err != nil {
+		return err
+	}
+	orphanedIngresses := 0
+	for i := range ingressList.Items {
+		ingress := &ingressList.Items[i]
+		if contains(ingressNames, ingress.Name) || contains(operatorConfigOrDefault(s.Config).RestrictedIngressNames, ingress.Name) || ingress.CreationTimestamp.After(createdBefore) || isLeftInPlace(ingress) {
+			continue
+		}
+		orphanedIngresses++
+		err = s.handleOrphan(ctx, reqLogger, ingress, "IngressController")
+		if err != nil {
+			return err
+		}
+	}
+
+	secretList := &corev1.SecretList{}
+	err = s.Client.List(ctx, secretList, client.InNamespace(ingressNamespace), client.HasLabels{managedLabelName})
+	if err != nil {
+		return err
+	}
+	orphanedSecrets := 0
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		if contains(secretNames, secret.Name) || secret.CreationTimestamp.After(createdBefore) || isLeftInPlace(secret) {
+			continue
+		}
+		orphanedSecrets++
+		err = s.handleOrphan(ctx, reqLogger, secret, "Secret")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Deleted resources are no longer orphaned
+	if s.Mode == OrphanSweeperModeDelete {
+		orphanedIngresses, orphanedSecrets = 0, 0
+	}
+	orphanedResources.WithLabelValues("IngressController").Set(float64(orphanedIngresses))
+	orphanedResources.WithLabelValues("Secret").Set(float64(orphanedSecrets))
+	return nil
+}
+
+// isLeftInPlace returns true if a resource was deliberately left in place by the deletion policy of its CustomDomain.
+// The marker is removed when a recreated CustomDomain manages the resource again.
+func isLeftInPlace(obj client.Object) bool {
+	policy := customdomainv1alpha1.DeletionPolicyType(obj.GetAnnotations()[deletionPolicyAnnotationName])
+	return policy == customdomainv1alpha1.DeletionPolicyOrphan || policy == customdomainv1alpha1.DeletionPolicyRetain
+}
+
+// handleOrphan reports an orphaned resource, and deletes it in delete mode
+func (s *OrphanSweeper) handleOrphan(ctx context.Context, reqLogger logr.Logger, obj client.Object, kind string) error {
+	// Orphaned resources are only reported while the operator is paused
+	if s.Mode != OrphanSweeperModeDelete || operatorConfigOrDefault(s.Config).Paused {
+		reqLogger.Info(fmt.Sprintf("Found orphaned %s %s/%s", kind, obj.GetNamespace(), obj.GetName()))
+		s.Recorder.Event(obj, corev1.EventTypeWarning, "OrphanedResource",
+			fmt.Sprintf("%s is labeled with %s but does not belong to a CustomDomain", kind, managedLabelName))
+		return nil
+	}
+
+	reqLogger.Info(fmt.Sprintf("Deleting orphaned %s %s/%s", kind, obj.GetNamespace(), obj.GetName()))
+	err := s.Client.Delete(ctx, obj)
+	if err != nil && !kerr.IsNotFound(err) {
+		return err
+	}
+	s.Recorder.Event(obj, corev1.EventTypeNormal, "OrphanedResourceDeleted",
+		fmt.Sprintf("Deleted %s that did not belong to a CustomDomain", kind))
+	return nil
+}
@@ -0,0 +1,100 @@
+package managed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// TestOrphanSweeper tests that managed resources without a CustomDomain are reported or deleted
+func TestOrphanSweeper(t *testing.T) {
+	created := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	newObjects := func() []client.Object {
+		return []client.Object{
+			&customdomainv1alpha1.CustomDomain{ObjectMeta: metav1.ObjectMeta{Name: "owned"}},
+			&operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "owned", Namespace: ingressOperatorNamespace, Labels: labelsForOwnedResources(), CreationTimestamp: created}},
+			&operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "orphaned", Namespace: ingressOperatorNamespace, Labels: labelsForOwnedResources(), CreationTimestamp: created}},
+			&operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: ingressOperatorNamespace, CreationTimestamp: created}},
+			&operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: ingressOperatorNamespace, Labels: labelsForOwnedResources(), CreationTimestamp: metav1.Now()}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "owned", Namespace: ingressNamespace, Labels: labelsForOwnedResources(), CreationTimestamp: created}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "orphaned", Namespace: ingressNamespace, Labels: labelsForOwnedResources(), CreationTimestamp: created}},
+			// left in place by the deletion policy of their CustomDomain
+			&operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "kept", Namespace: ingressOperatorNamespace, Labels: labelsForOwnedResources(), CreationTimestamp: created,
+				Annotations: map[string]string{deletionPolicyAnnotationName: string(customdomainv1alpha1.DeletionPolicyOrphan)}}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "kept", Namespace: ingressNamespace, Labels: labelsForOwnedResources(), CreationTimestamp: created,
+				Annotations: map[string]string{deletionPolicyAnnotationName: string(customdomainv1alpha1.DeletionPolicyRetain)}}},
+		}
+	}
+
+	exists := func(t *testing.T, cl client.Client, obj client.Object, namespace, name string) bool {
+		err := cl.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, obj)
+		if err != nil && !kerr.IsNotFound(err) {
+			t.Fatalf("get %s: (%v)", name, err)
+		}
+		return err == nil
+	}
+
+	tests := []struct {
+		name          string
+		mode          OrphanSweeperMode
+		paused        bool
+		expectDeleted bool
+	}{
+		{
+			name: "report",
+			mode: OrphanSweeperModeReport,
+		},
+		{
+			name:          "delete",
+			mode:          OrphanSweeperModeDelete,
+			expectDeleted: true,
+		},
+		{
+			name:   "delete while paused",
+			mode:   OrphanSweeperModeDelete,
+			paused: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := NewTestMock(t, newObjects()...)
+			recorder := record.NewFakeRecorder(10)
+			config := newTestOperatorConfigStore(func(c *OperatorConfig) { c.Paused = tt.paused })
+			s := &OrphanSweeper{Client: cl, Recorder: recorder, Interval: time.Hour, Mode: tt.mode, Config: config}
+			if err := s.Sweep(context.TODO(), logf.Log); err != nil {
+				t.Fatalf("sweep: (%v)", err)
+			}
+
+			if len(recorder.Events) != 2 {
+				t.Errorf("expected 2 events, got (%d)", len(recorder.Events))
+			}
+			if exists(t, cl, &operatorv1.IngressController{}, ingressOperatorNamespace, "orphaned") == tt.expectDeleted {
+				t.Errorf("orphaned ingresscontroller deleted mismatch: expected (%v)", tt.expectDeleted)
+			}
+			if exists(t, cl, &corev1.Secret{}, ingressNamespace, "orphaned") == tt.expectDeleted {
+				t.Errorf("orphaned secret deleted mismatch: expected (%v)", tt.expectDeleted)
+			}
+			for _, name := range []string{"owned", "unmanaged", "new", "kept"} {
+				if !exists(t, cl, &operatorv1.IngressController{}, ingressOperatorNamespace, name) {
+					t.Errorf("ingresscontroller %s was deleted", name)
+				}
+			}
+			for _, name := range []string{"owned", "kept"} {
+				if !exists(t, cl, &corev1.Secret{}, ingressNamespace, name) {
+					t.Errorf("secret %s was deleted", name)
+				}
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package managed
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"regexp"
 
 	"github.com/go-logr/logr"
@@ -119,7 +118,7 @@ func FindCustomDomainCondition(conditions []customdomainv1alpha1.CustomDomainCon
 func (r *CustomDomainReconciler) returnIngressToClusterIngressOperator(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) (ctrl.Result, error) {
 	reqLogger.Info(fmt.Sprintf("Removing operator management labels from %s's underlying ingress controller", instance.Name))
 
-	ingressName := instance.Name
+	ingressName := ingressControllerName(instance)
 	customIngress := &operatorv1.IngressController{}
 
 	reqLogger.Info(fmt.Sprintf("Fetching ingress controller: %s/%s", ingressOperatorNamespace, ingressName))
@@ -148,22 +147,13 @@ func (r *CustomDomainReconciler) returnIngressToClusterIngressOperator(reqLogger
 		return reconcile.Result{}, err
 	}
 
-	userSecret := &corev1.Secret{}
-	err = r.Client.Get(context.TODO(), types.NamespacedName{
-		Namespace: instance.Spec.Certificate.Namespace,
-		Name:      instance.Spec.Certificate.Name,
-	}, userSecret)
-	reqLogger.Info(fmt.Sprintf("Updating secret to remove custom domain labels from secret %s", userSecret.Name))
-	delete(userSecret.Labels, managedLabelName)
+	// Requeue on errors, as the dependent ingress controller has already been updated
+	err = r.releaseUserSecret(reqLogger, instance)
 	if err != nil {
-		reqLogger.Error(err, fmt.Sprintf("Error fetching secret %s in %s namespace", userSecret.Name, userSecret.Namespace))
 		return reconcile.Result{}, err
 	}
-
-	err = r.Client.Update(context.TODO(), userSecret)
+	err = r.releasePreviousUserSecrets(reqLogger, instance)
 	if err != nil {
-		reqLogger.Error(err, fmt.Sprintf("Error updating secret %s in %s namespace", userSecret.Name, userSecret.Namespace))
-		// Requeue, as the dependent ingress controller has already been updated
 		return reconcile.Result{}, err
 	}
 
@@ -183,10 +173,32 @@ func (r *CustomDomainReconciler) returnIngressToClusterIngressOperator(reqLogger
 
 // finalizeCustomDomain cleans up left over resources once a CustomDomain CR is deleted
 func (r *CustomDomainReconciler) finalizeCustomDomain(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) error {
+	err := r.releaseUserSecret(reqLogger, instance)
+	if err != nil {
+		return err
+	}
+	err = r.releasePreviousUserSecrets(reqLogger, instance)
+	if err != nil {
+		return err
+	}
+
+	switch instance.Spec.DeletionPolicy {
+	case customdomainv1alpha1.DeletionPolicyOrphan:
+		return r.orphanCustomDomainResources(reqLogger, instance)
+	case customdomainv1alpha1.DeletionPolicyRetain:
+		return r.retainCustomDomainResources(reqLogger, instance)
+	}
+
 	reqLogger.Info("Deleting old resources...")
+	err = r.deleteDNSRecord(reqLogger, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to delete the published CNAME record")
+		return err
+	}
+
 	// get and delete the secret in openshift-ingress
 	ingressSecret := &corev1.Secret{}
-	err := r.Client.Get(context.TODO(), types.NamespacedName{
+	err = r.Client.Get(context.TODO(), types.NamespacedName{
 		Namespace: ingressNamespace,
 		Name:      instance.Name,
 	}, ingressSecret)
@@ -208,38 +220,188 @@ func (r *CustomDomainReconciler) finalizeCustomDomain(reqLogger logr.Logger, ins
 		}
 	}
 
-	// get and delete the custom ingresscontroller
+	// get and delete the custom ingresscontroller, along with the other ingresscontroller of an unfinished scope migration
+	ingressNames := []string{ingressControllerName(instance)}
+	if instance.Status.ScopeMigration != nil {
+		ingressNames = append(ingressNames, instance.Status.ScopeMigration.PreviousIngressController, instance.Status.ScopeMigration.IngressController)
+	}
+	for _, ingressName := range ingressNames {
+		err = r.deleteIngressController(reqLogger, ingressName)
+		if err != nil {
+			return err
+		}
+	}
+	reqLogger.Info(fmt.Sprintf("Customdomain %s successfully finalized", instance.Name))
+	return nil
+}
+
+// orphanCustomDomainResources leaves the ingresscontrollers and certificate of a deleted CustomDomain in place with
+// their management labels, and marks them with the deletion policy so the orphaned resource sweeper leaves them alone
+func (r *CustomDomainReconciler) orphanCustomDomainResources(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) error {
+	reqLogger.Info(fmt.Sprintf("Customdomain %s has %s deletion policy, leaving resources in place", instance.Name, instance.Spec.DeletionPolicy))
+	objs := []client.Object{}
+	ingressSecret := &corev1.Secret{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: ingressNamespace, Name: instance.Name}, ingressSecret)
+	if err != nil {
+		if !kerr.IsNotFound(err) {
+			return err
+		}
+	} else {
+		objs = append(objs, ingressSecret)
+	}
+	ingressNames := []string{ingressControllerName(instance)}
+	if migration := instance.Status.ScopeMigration; migration != nil {
+		ingressNames = append(ingressNames, migration.PreviousIngressController, migration.IngressController)
+	}
+	for _, name := range ingressNames {
+		customIngress := &operatorv1.IngressController{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: ingressOperatorNamespace, Name: name}, customIngress)
+		if err != nil {
+			if !kerr.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		objs = append(objs, customIngress)
+	}
+
+	for _, obj := range objs {
+		if _, ok := obj.GetLabels()[managedLabelName]; !ok || !markDeletionPolicy(obj, instance.Spec.DeletionPolicy) {
+			continue
+		}
+		err := r.Client.Update(context.TODO(), obj)
+		if err != nil {
+			reqLogger.Error(err, fmt.Sprintf("Error marking %s/%s as orphaned", obj.GetNamespace(), obj.GetName()))
+			return err
+		}
+	}
+	return nil
+}
+
+// markDeletionPolicy records the deletion policy a resource was left in place with. It returns true if the resource
+// changed.
+func markDeletionPolicy(obj client.Object, policy customdomainv1alpha1.DeletionPolicyType) bool {
+	annotations := obj.GetAnnotations()
+	if annotations[deletionPolicyAnnotationName] == string(policy) {
+		return false
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[deletionPolicyAnnotationName] = string(policy)
+	obj.SetAnnotations(annotations)
+	return true
+}
+
+// clearDeletionPolicy removes the deletion policy a resource was left in place with, once a CustomDomain manages it
+// again. It returns true if the resource changed.
+func clearDeletionPolicy(obj client.Object) bool {
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[deletionPolicyAnnotationName]; !ok {
+		return false
+	}
+	delete(annotations, deletionPolicyAnnotationName)
+	obj.SetAnnotations(annotations)
+	return true
+}
+
+// retainCustomDomainResources keeps the ingresscontroller and certificate of a deleted CustomDomain serving traffic,
+// but removes the operator's management labels from them. They are marked with the deletion policy, so the orphaned
+// resource sweeper leaves them alone if they are labeled again. The other ingresscontroller of an unfinished scope migration
+// is not serving the CustomDomain and is deleted.
+func (r *CustomDomainReconciler) retainCustomDomainResources(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) error {
+	ingressName := ingressControllerName(instance)
+	reqLogger.Info(fmt.Sprintf("Retaining ingresscontroller %s and removing operator management labels", ingressName))
+
+	ingressSecret := &corev1.Secret{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{
+		Namespace: ingressNamespace,
+		Name:      instance.Name,
+	}, ingressSecret)
+	if err != nil {
+		if !kerr.IsNotFound(err) {
+			reqLogger.Error(err, fmt.Sprintf("Failed to get %s secret", instance.Name))
+			return err
+		}
+		reqLogger.Info(fmt.Sprintf("Secret %s was not found, skipping.", instance.Name))
+	} else if _, ok := ingressSecret.Labels[managedLabelName]; ok {
+		delete(ingressSecret.Labels, managedLabelName)
+		markDeletionPolicy(ingressSecret, customdomainv1alpha1.DeletionPolicyRetain)
+		err = r.Client.Update(context.TODO(), ingressSecret)
+		if err != nil {
+			reqLogger.Error(err, fmt.Sprintf("Error updating secret %s in %s namespace", ingressSecret.Name, ingressSecret.Namespace))
+			return err
+		}
+	}
+
 	customIngress := &operatorv1.IngressController{}
 	err = r.Client.Get(context.TODO(), types.NamespacedName{
 		Namespace: ingressOperatorNamespace,
-		Name:      instance.Name,
+		Name:      ingressName,
 	}, customIngress)
 	if err != nil {
 		if !kerr.IsNotFound(err) {
-			reqLogger.Error(err, fmt.Sprintf("Failed to get %s ingresscontroller", instance.Name))
+			reqLogger.Error(err, fmt.Sprintf("Failed to get %s ingresscontroller", ingressName))
 			return err
 		}
-		reqLogger.Info(fmt.Sprintf("IngressController %s was not found, skipping.", instance.Name))
-	} else {
-		// Only delete the IngressController if it has the proper labels and does not have a restricted name
-		if _, ok := customIngress.Labels[managedLabelName]; ok {
-			if !contains(restrictedIngressNames, customIngress.Name) {
-				err = r.Client.Delete(context.TODO(), customIngress)
-				if err != nil {
-					reqLogger.Error(err, fmt.Sprintf("Failed to delete %s ingresscontroller", customIngress.Name))
-					return err
-				}
-			} else {
-				reqLogger.Info(fmt.Sprintf("IngressController %s has a restricted name, not deleting.", customIngress.Name))
+		reqLogger.Info(fmt.Sprintf("IngressController %s was not found, skipping.", ingressName))
+	} else if _, ok := customIngress.Labels[managedLabelName]; ok {
+		delete(customIngress.Labels, managedLabelName)
+		markDeletionPolicy(customIngress, customdomainv1alpha1.DeletionPolicyRetain)
+		err = r.Client.Update(context.TODO(), customIngress)
+		if err != nil {
+			reqLogger.Error(err, fmt.Sprintf("Error updating ingresscontroller %s in %s namespace", ingressName, ingressOperatorNamespace))
+			return err
+		}
+	}
+
+	if migration := instance.Status.ScopeMigration; migration != nil {
+		for _, name := range []string{migration.PreviousIngressController, migration.IngressController} {
+			if name == ingressName {
+				continue
+			}
+			err = r.deleteIngressController(reqLogger, name)
+			if err != nil {
+				return err
 			}
-		} else {
-			reqLogger.Info(fmt.Sprintf("IngressController %s did not have proper labels, not deleting.", customIngress.Name))
 		}
 	}
 	reqLogger.Info(fmt.Sprintf("Customdomain %s successfully finalized", instance.Name))
 	return nil
 }
 
+// deleteIngressController deletes an ingresscontroller managed by the operator
+func (r *CustomDomainReconciler) deleteIngressController(reqLogger logr.Logger, name string) error {
+	customIngress := &operatorv1.IngressController{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{
+		Namespace: ingressOperatorNamespace,
+		Name:      name,
+	}, customIngress)
+	if err != nil {
+		if !kerr.IsNotFound(err) {
+			reqLogger.Error(err, fmt.Sprintf("Failed to get %s ingresscontroller", name))
+			return err
+		}
+		reqLogger.Info(fmt.Sprintf("IngressController %s was not found, skipping.", name))
+		return nil
+	}
+	// Only delete the IngressController if it has the proper labels and does not have a restricted name
+	if _, ok := customIngress.Labels[managedLabelName]; !ok {
+		reqLogger.Info(fmt.Sprintf("IngressController %s did not have proper labels, not deleting.", customIngress.Name))
+		return nil
+	}
+	if contains(r.config().RestrictedIngressNames, customIngress.Name) {
+		reqLogger.Info(fmt.Sprintf("IngressController %s has a restricted name, not deleting.", customIngress.Name))
+		return nil
+	}
+	err = r.Client.Delete(context.TODO(), customIngress)
+	if err != nil {
+		reqLogger.Error(err, fmt.Sprintf("Failed to delete %s ingresscontroller", customIngress.Name))
+		return err
+	}
+	return nil
+}
+
 // addFinalizer is a function that adds a finalizer for the CustomDomain CR
 func (r *CustomDomainReconciler) addFinalizer(reqLogger logr.Logger, m *customdomainv1alpha1.CustomDomain) error {
 	reqLogger.Info("Adding Finalizer for the CustomDomain")
@@ -266,6 +428,27 @@ func SetCustomDomainStatus(reqLogger logr.Logger, instance *customdomainv1alpha1
 	reqLogger.Info(fmt.Sprintf("CustomDomain (%s) status updated: condition: (%s), state: (%s)", instance.Name, string(condition), string(state)))
 }
 
+// reportBlocker reports a problem that stops the reconcile with its condition and returns true, or sets the condition
+// to False with the resolved message if there is no problem and the condition was reported before
+func (r *CustomDomainReconciler) reportBlocker(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain, condition customdomainv1alpha1.CustomDomainConditionType, problem, resolved string) (bool, error) {
+	if problem == "" {
+		if FindCustomDomainCondition(instance.Status.Conditions, condition) != nil {
+			instance.Status.Conditions = SetCustomDomainCondition(instance.Status.Conditions, condition,
+				corev1.ConditionFalse, resolved, UpdateConditionIfReasonOrMessageChange)
+		}
+		return false, nil
+	}
+
+	reqLogger.Info(problem)
+	SetCustomDomainStatus(
+		reqLogger,
+		instance,
+		problem,
+		condition,
+		customdomainv1alpha1.CustomDomainStateNotReady)
+	return true, r.statusUpdate(reqLogger, instance)
+}
+
 // statusUpdate helper function to set the actual status update
 func (r *CustomDomainReconciler) statusUpdate(reqLogger logr.Logger, instance *customdomainv1alpha1.CustomDomain) error {
 	err := r.Client.Status().Update(context.TODO(), instance)
@@ -296,25 +479,15 @@ func remove(list []string, s string) []string {
 	return list
 }
 
-// letters used by randSeq
-var letters = []rune("abcdefghijklmnopqrstuvwxyz")
-
-// randSeq is a function to generate a fixed length string with random letters
-func randSeq(n int) string {
-	b := make([]rune, n)
-	for i := range b {
-		// #nosec G404
-		b[i] = letters[rand.Intn(len(letters))]
-	}
-	return string(b)
-}
-
 // GetPlatformType returns the cloud platform type for the cluster
 func GetPlatformType(kclient client.Client) (*configv1.PlatformType, error) {
 	infra, err := GetInfrastructureObject(kclient)
 	if err != nil {
 		return nil, err
 	}
+	if infra.Status.PlatformStatus == nil {
+		return nil, fmt.Errorf("platform status is not set on infrastructure %s", infra.Name)
+	}
 	return &infra.Status.PlatformStatus.Type, nil
 }
 
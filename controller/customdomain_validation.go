@@ -0,0 +1,126 @@
+package managed
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// CustomDomainNameProblem returns why the operator refuses a CustomDomain name, or an empty string if it doesn't
+func CustomDomainNameProblem(c OperatorConfig, name string) string {
+	if contains(c.RestrictedIngressNames, name) {
+		return fmt.Sprintf("name %s clashes with the reserved names %v", name, c.RestrictedIngressNames)
+	}
+	if !validObjectNames.MatchString(name) {
+		return fmt.Sprintf("name %s is not a DNS-1035 label (regex used for validation is '%s')", name, validObjectNames.String())
+	}
+	return ""
+}
+
+// CustomDomainSpecProblems lists why the operator would not serve a CustomDomain, from the CustomDomain alone: an
+// invalid name or domain, a domain the domain policy doesn't allow, values the CRD schema rejects, fields that are
+// ignored or conflict with the endpoint publishing strategy, and invalid selectors. The base domain of the cluster is
+// only checked if it is not empty.
+func CustomDomainSpecProblems(c OperatorConfig, instance *customdomainv1alpha1.CustomDomain, baseDomain string) []string {
+	problems := []string{}
+	if problem := CustomDomainNameProblem(c, instance.Name); problem != "" {
+		problems = append(problems, problem)
+	}
+
+	spec := instance.Spec
+	switch {
+	case spec.Domain == "":
+		problems = append(problems, "domain is required")
+	case strings.HasPrefix(spec.Domain, "*."):
+		problems = append(problems, fmt.Sprintf("domain %s must not be a wildcard, the routes under %s are served", spec.Domain, strings.TrimPrefix(spec.Domain, "*.")))
+	default:
+		if errs := validation.IsDNS1123Subdomain(normalizeDomain(spec.Domain)); len(errs) > 0 {
+			problems = append(problems, fmt.Sprintf("domain %s is not a valid DNS name: %s", spec.Domain, strings.Join(errs, ", ")))
+		} else if violation := domainPolicyViolation(c.DomainPolicy, spec.Domain, baseDomain); violation != "" {
+			problems = append(problems, violation)
+		}
+	}
+	if spec.Certificate.Name == "" || spec.Certificate.Namespace == "" {
+		problems = append(problems, "certificate.name and certificate.namespace are required")
+	}
+
+	problems = append(problems, enumProblem("scope", spec.Scope, "External", "Internal")...)
+	problems = append(problems, enumProblem("loadBalancerType", string(spec.LoadBalancerType),
+		string(operatorv1.AWSClassicLoadBalancer), string(operatorv1.AWSNetworkLoadBalancer))...)
+	problems = append(problems, enumProblem("endpointPublishingStrategy", string(spec.EndpointPublishingStrategy),
+		string(operatorv1.LoadBalancerServiceStrategyType), string(operatorv1.HostNetworkStrategyType),
+		string(operatorv1.NodePortServiceStrategyType), string(operatorv1.PrivateStrategyType))...)
+	problems = append(problems, enumProblem("scopeChangePolicy", string(spec.ScopeChangePolicy),
+		string(customdomainv1alpha1.ScopeChangePolicyReject), string(customdomainv1alpha1.ScopeChangePolicyRecreate))...)
+	problems = append(problems, enumProblem("deletionPolicy", string(spec.DeletionPolicy),
+		string(customdomainv1alpha1.DeletionPolicyDelete), string(customdomainv1alpha1.DeletionPolicyRetain), string(customdomainv1alpha1.DeletionPolicyOrphan))...)
+	if spec.EndpointHost != "" {
+		if errs := validation.IsDNS1123Label(spec.EndpointHost); len(errs) > 0 {
+			problems = append(problems, fmt.Sprintf("endpointHost %s is not a valid DNS label: %s", spec.EndpointHost, strings.Join(errs, ", ")))
+		}
+	}
+
+	strategy := spec.EndpointPublishingStrategy
+	if strategy == "" {
+		strategy = ingressDefaultEndpointPublishingStrategy
+	}
+	if strategy != operatorv1.LoadBalancerServiceStrategyType {
+		// the defaults set by the CRD are fine, only a value chosen for the load balancer is a mistake
+		if spec.Scope == "Internal" {
+			problems = append(problems, fmt.Sprintf("scope %s is ignored with the %s endpoint publishing strategy", spec.Scope, strategy))
+		}
+		if spec.LoadBalancerType == operatorv1.AWSNetworkLoadBalancer {
+			problems = append(problems, fmt.Sprintf("loadBalancerType %s is ignored with the %s endpoint publishing strategy", spec.LoadBalancerType, strategy))
+		}
+	}
+	if spec.HostNetwork != nil && strategy != operatorv1.HostNetworkStrategyType {
+		problems = append(problems, fmt.Sprintf("hostNetwork is ignored with the %s endpoint publishing strategy", strategy))
+	}
+	if spec.NodePort != nil && strategy != operatorv1.NodePortServiceStrategyType {
+		problems = append(problems, fmt.Sprintf("nodePort is ignored with the %s endpoint publishing strategy", strategy))
+	}
+	if spec.ProxyProtocol {
+		if err := proxyProtocolSpecError(*instance, strategy); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if _, err := metav1.LabelSelectorAsSelector(spec.NamespaceSelector); err != nil {
+		problems = append(problems, fmt.Sprintf("namespaceSelector is invalid: %v", err))
+	}
+	if _, err := metav1.LabelSelectorAsSelector(spec.RouteSelector); err != nil {
+		problems = append(problems, fmt.Sprintf("routeSelector is invalid: %v", err))
+	}
+	return problems
+}
+
+// enumProblem returns why an optional field doesn't hold one of its allowed values, if it doesn't
+func enumProblem(field, value string, allowed ...string) []string {
+	if value == "" || contains(allowed, value) {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s %s is not one of %s", field, value, strings.Join(allowed, ", "))}
+}
+
+// CustomDomainConflictProblems lists the other CustomDomains whose domain overlaps with the domain of a CustomDomain.
+// The operator only serves the oldest of them.
+func CustomDomainConflictProblems(instance *customdomainv1alpha1.CustomDomain, customDomains []customdomainv1alpha1.CustomDomain) []string {
+	problems := []string{}
+	for _, other := range overlappingCustomDomains(customDomains, instance) {
+		problems = append(problems, domainConflictMessage(instance, &other))
+	}
+	return problems
+}
+
+// CustomDomainProblems lists why the operator would not serve a CustomDomain with the given TLS secret, without
+// looking at the cluster: the problems of its spec, and a certificate that can't serve the custom domain.
+func CustomDomainProblems(c OperatorConfig, instance *customdomainv1alpha1.CustomDomain, secret *corev1.Secret, baseDomain string, now time.Time) []string {
+	problems := CustomDomainSpecProblems(c, instance, baseDomain)
+	return append(problems, CertificateProblems(secret, instance.Spec.Domain, now)...)
+}
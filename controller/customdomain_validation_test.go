@@ -0,0 +1,107 @@
+package managed
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCustomDomainProblems(t *testing.T) {
+	now := time.Now()
+	secret := newTestCertificateSecret(t, "my-secret", "my-project", []string{"*.apps.example.com", "*.co.uk"}, now.Add(time.Hour))
+	tests := []struct {
+		name     string
+		domain   string
+		expected []string
+	}{
+		{name: "tenant", domain: "apps.example.com"},
+		{name: "apps", domain: "apps.example.com", expected: []string{"reserved names"}},
+		{name: "Tenant.1", domain: "apps.example.com", expected: []string{"DNS-1035"}},
+		{name: "tenant", domain: "co.uk", expected: []string{"public suffix"}},
+		{name: "tenant", domain: "apps.foo.com", expected: []string{"doesn't cover"}},
+	}
+	for _, tt := range tests {
+		instance := &customdomainv1alpha1.CustomDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: tt.name},
+			Spec: customdomainv1alpha1.CustomDomainSpec{
+				Domain:      tt.domain,
+				Certificate: corev1.SecretReference{Name: secret.Name, Namespace: secret.Namespace},
+			},
+		}
+		problems := CustomDomainProblems(DefaultOperatorConfig(), instance, secret, "", now)
+		if len(problems) != len(tt.expected) {
+			t.Errorf("%s %s: expected %v, got %v", tt.name, tt.domain, tt.expected, problems)
+			continue
+		}
+		for i := range problems {
+			if !strings.Contains(problems[i], tt.expected[i]) {
+				t.Errorf("%s %s: expected %v, got %v", tt.name, tt.domain, tt.expected, problems)
+			}
+		}
+	}
+}
+
+func TestCustomDomainSpecProblems(t *testing.T) {
+	tests := []struct {
+		description string
+		spec        customdomainv1alpha1.CustomDomainSpec
+		expected    []string
+	}{
+		{
+			description: "valid",
+			spec:        customdomainv1alpha1.CustomDomainSpec{Domain: "apps.example.com", Scope: "Internal", LoadBalancerType: operatorv1.AWSNetworkLoadBalancer},
+		},
+		{
+			description: "wildcard domain",
+			spec:        customdomainv1alpha1.CustomDomainSpec{Domain: "*.apps.example.com"},
+			expected:    []string{"must not be a wildcard"},
+		},
+		{
+			description: "invalid domain",
+			spec:        customdomainv1alpha1.CustomDomainSpec{Domain: "apps_example.com"},
+			expected:    []string{"not a valid DNS name"},
+		},
+		{
+			description: "invalid enums",
+			spec:        customdomainv1alpha1.CustomDomainSpec{Domain: "apps.example.com", Scope: "Public", DeletionPolicy: "Keep"},
+			expected:    []string{"scope Public is not one of External, Internal", "deletionPolicy Keep is not one of"},
+		},
+		{
+			description: "load balancer settings with HostNetwork",
+			spec: customdomainv1alpha1.CustomDomainSpec{Domain: "apps.example.com", Scope: "Internal", LoadBalancerType: operatorv1.AWSNetworkLoadBalancer,
+				EndpointPublishingStrategy: operatorv1.HostNetworkStrategyType, NodePort: &operatorv1.NodePortStrategy{}},
+			expected: []string{"scope Internal is ignored", "loadBalancerType NLB is ignored", "nodePort is ignored"},
+		},
+		{
+			description: "PROXY protocol with NLB",
+			spec:        customdomainv1alpha1.CustomDomainSpec{Domain: "apps.example.com", LoadBalancerType: operatorv1.AWSNetworkLoadBalancer, ProxyProtocol: true},
+			expected:    []string{"not supported with NLB"},
+		},
+		{
+			description: "invalid selector",
+			spec: customdomainv1alpha1.CustomDomainSpec{Domain: "apps.example.com", RouteSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "shard", Operator: metav1.LabelSelectorOpIn}},
+			}},
+			expected: []string{"routeSelector is invalid"},
+		},
+	}
+	for _, tt := range tests {
+		tt.spec.Certificate = corev1.SecretReference{Name: "my-secret", Namespace: "my-project"}
+		instance := &customdomainv1alpha1.CustomDomain{ObjectMeta: metav1.ObjectMeta{Name: "tenant"}, Spec: tt.spec}
+		problems := CustomDomainSpecProblems(DefaultOperatorConfig(), instance, "")
+		if len(problems) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.description, tt.expected, problems)
+			continue
+		}
+		for i := range problems {
+			if !strings.Contains(problems[i], tt.expected[i]) {
+				t.Errorf("%s: expected %v, got %v", tt.description, tt.expected, problems)
+			}
+		}
+	}
+}
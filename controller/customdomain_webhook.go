@@ -0,0 +1,214 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	routev1 "github.com/openshift/api/route/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// allowDeletionAnnotation disables the deletion protection of a CustomDomain when set to "true"
+	allowDeletionAnnotation = "customdomains.managed.openshift.io/allow-deletion"
+
+	// maxListedRoutes is the number of routes listed in a denied deletion's message
+	maxListedRoutes = 10
+)
+
+// The quota, domain policy and domain conflict checks of creations and updates are best-effort: they are skipped while
+// the webhook is unavailable, and the controller reports what they would have denied. The deletion protection fails
+// closed instead, so CustomDomains can't be deleted while the webhook is unavailable.
+//+kubebuilder:webhook:path=/validate-managed-openshift-io-v1alpha1-customdomain,mutating=false,failurePolicy=ignore,sideEffects=None,groups=managed.openshift.io,resources=customdomains,verbs=create;update,versions=v1alpha1,name=vcustomdomain.managed.openshift.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-managed-openshift-io-v1alpha1-customdomain,mutating=false,failurePolicy=fail,sideEffects=None,groups=managed.openshift.io,resources=customdomains,verbs=delete,versions=v1alpha1,name=vcustomdomaindeletion.managed.openshift.io,admissionReviewVersions=v1
+
+// CustomDomainValidator enforces the CustomDomain quota and domain policy, denies duplicate or overlapping custom
+// domains, and protects CustomDomains whose ingresscontroller still
+// serves admitted routes from deletion
+type CustomDomainValidator struct {
+	// Reader lists routes directly from the API server, so the operator doesn't have to cache every route in the cluster
+	Reader client.Reader
+	// Config holds the operator configuration. Defaults to DefaultOperatorConfig.
+	Config *OperatorConfigStore
+}
+
+var _ admission.CustomValidator = &CustomDomainValidator{}
+
+// SetupWebhookWithManager registers the validating webhook with the Manager.
+func (v *CustomDomainValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&customdomainv1alpha1.CustomDomain{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate denies the creation of a CustomDomain over the quota, or whose domain is not allowed by the domain
+// policy or overlaps with the domain of another CustomDomain
+func (v *CustomDomainValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	instance, ok := obj.(*customdomainv1alpha1.CustomDomain)
+	if !ok {
+		return nil, fmt.Errorf("expected a CustomDomain but got a %T", obj)
+	}
+	if err := v.validateDomainPolicy(ctx, instance); err != nil {
+		return nil, err
+	}
+	if err := v.validateDomainConflict(ctx, instance); err != nil {
+		return nil, err
+	}
+	return nil, v.validateQuota(ctx, instance)
+}
+
+// ValidateUpdate denies moving a CustomDomain to a scope that is over the quota, or to a domain that is not allowed by
+// the domain policy or overlaps with the domain of another CustomDomain
+func (v *CustomDomainValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldInstance, ok := oldObj.(*customdomainv1alpha1.CustomDomain)
+	if !ok {
+		return nil, fmt.Errorf("expected a CustomDomain but got a %T", oldObj)
+	}
+	instance, ok := newObj.(*customdomainv1alpha1.CustomDomain)
+	if !ok {
+		return nil, fmt.Errorf("expected a CustomDomain but got a %T", newObj)
+	}
+	if normalizeDomain(oldInstance.Spec.Domain) != normalizeDomain(instance.Spec.Domain) {
+		if err := v.validateDomainPolicy(ctx, instance); err != nil {
+			return nil, err
+		}
+		if err := v.validateDomainConflict(ctx, instance); err != nil {
+			return nil, err
+		}
+	}
+	if customDomainScope(oldInstance) == customDomainScope(instance) {
+		return nil, nil
+	}
+	return nil, v.validateQuota(ctx, instance)
+}
+
+// validateDomainConflict returns an error if the custom domain overlaps with the domain of another CustomDomain
+func (v *CustomDomainValidator) validateDomainConflict(ctx context.Context, instance *customdomainv1alpha1.CustomDomain) error {
+	customDomains := &customdomainv1alpha1.CustomDomainList{}
+	err := v.Reader.List(ctx, customDomains)
+	if err != nil {
+		return err
+	}
+	if overlapping := overlappingCustomDomains(customDomains.Items, instance); len(overlapping) > 0 {
+		return fmt.Errorf("domain conflict: %s", domainConflictMessage(instance, &overlapping[0]))
+	}
+	return nil
+}
+
+// validateDomainPolicy returns an error if the domain policy doesn't allow the custom domain
+func (v *CustomDomainValidator) validateDomainPolicy(ctx context.Context, instance *customdomainv1alpha1.CustomDomain) error {
+	baseDomain, err := clusterBaseDomain(ctx, v.Reader)
+	if err != nil {
+		return err
+	}
+	if violation := domainPolicyViolation(operatorConfigOrDefault(v.Config).DomainPolicy, instance.Spec.Domain, baseDomain); violation != "" {
+		return fmt.Errorf("custom domain not allowed: %s", violation)
+	}
+	return nil
+}
+
+// validateQuota returns an error if adding the CustomDomain to the others exceeds the quota
+func (v *CustomDomainValidator) validateQuota(ctx context.Context, instance *customdomainv1alpha1.CustomDomain) error {
+	quota := operatorConfigOrDefault(v.Config).Quota
+	if quota.MaxCustomDomains == nil && quota.MaxExternal == nil && quota.MaxInternal == nil {
+		return nil
+	}
+	customDomains, err := listCountedCustomDomains(ctx, v.Reader)
+	if err != nil {
+		return err
+	}
+	scope := customDomainScope(instance)
+	total, scoped := 0, 0
+	for i := range customDomains {
+		if customDomains[i].Name == instance.Name {
+			continue
+		}
+		total++
+		if customDomainScope(&customDomains[i]) == scope {
+			scoped++
+		}
+	}
+	if violation := quotaViolation(quota, scope, total, scoped); violation != "" {
+		return fmt.Errorf("CustomDomain quota exceeded: %s", violation)
+	}
+	return nil
+}
+
+// ValidateDelete denies the deletion of a CustomDomain while its ingresscontroller has admitted routes,
+// unless the CustomDomain has the allow-deletion annotation or a deletion policy that keeps the ingresscontroller
+func (v *CustomDomainValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	instance, ok := obj.(*customdomainv1alpha1.CustomDomain)
+	if !ok {
+		return nil, fmt.Errorf("expected a CustomDomain but got a %T", obj)
+	}
+	if instance.Annotations[allowDeletionAnnotation] == "true" {
+		return nil, nil
+	}
+	if instance.Spec.DeletionPolicy == customdomainv1alpha1.DeletionPolicyRetain || instance.Spec.DeletionPolicy == customdomainv1alpha1.DeletionPolicyOrphan {
+		return nil, nil
+	}
+
+	routes, err := v.admittedRoutes(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return nil, nil
+	}
+
+	listed := routes
+	if len(listed) > maxListedRoutes {
+		listed = listed[:maxListedRoutes]
+	}
+	message := strings.Join(listed, ", ")
+	if len(routes) > maxListedRoutes {
+		message = fmt.Sprintf("%s and %d more", message, len(routes)-maxListedRoutes)
+	}
+	return nil, fmt.Errorf("CustomDomain %s still serves %d admitted route(s): %s. Move the routes to another router or set the %s=true annotation to delete it anyway",
+		instance.Name, len(routes), message, allowDeletionAnnotation)
+}
+
+// admittedRoutes returns the namespace/name of the routes admitted by the ingresscontrollers of a CustomDomain
+func (v *CustomDomainValidator) admittedRoutes(ctx context.Context, instance *customdomainv1alpha1.CustomDomain) ([]string, error) {
+	routerNames := []string{ingressControllerName(instance)}
+	if instance.Status.ScopeMigration != nil {
+		routerNames = append(routerNames, instance.Status.ScopeMigration.PreviousIngressController, instance.Status.ScopeMigration.IngressController)
+	}
+
+	routeList := &routev1.RouteList{}
+	err := v.Reader.List(ctx, routeList)
+	if err != nil {
+		return nil, err
+	}
+	routes := []string{}
+	for _, route := range routeList.Items {
+		if isRouteAdmittedBy(&route, routerNames) {
+			routes = append(routes, fmt.Sprintf("%s/%s", route.Namespace, route.Name))
+		}
+	}
+	sort.Strings(routes)
+	return routes, nil
+}
+
+// isRouteAdmittedBy returns true if one of the given routers has admitted the route
+func isRouteAdmittedBy(route *routev1.Route, routerNames []string) bool {
+	for _, ingress := range route.Status.Ingress {
+		if !contains(routerNames, ingress.RouterName) {
+			continue
+		}
+		for _, condition := range ingress.Conditions {
+			if condition.Type == routev1.RouteAdmitted && condition.Status == corev1.ConditionTrue {
+				return true
+			}
+		}
+	}
+	return false
+}
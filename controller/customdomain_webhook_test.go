@@ -0,0 +1,101 @@
+package managed
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestCustomDomainValidatorDeletion tests that CustomDomains serving admitted routes are protected from deletion
+func TestCustomDomainValidatorDeletion(t *testing.T) {
+	const instanceName = "test"
+
+	newRoute := func(namespace, name, routerName string, admitted corev1.ConditionStatus) *routev1.Route {
+		return &routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Status: routev1.RouteStatus{
+				Ingress: []routev1.RouteIngress{
+					{
+						RouterName: routerName,
+						Conditions: []routev1.RouteIngressCondition{
+							{Type: routev1.RouteAdmitted, Status: admitted},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		policy      customdomainv1alpha1.DeletionPolicyType
+		routes      []client.Object
+		expectError bool
+	}{
+		{
+			name: "no routes",
+		},
+		{
+			name: "routes of other routers",
+			routes: []client.Object{
+				newRoute("my-project", "my-route", "default", corev1.ConditionTrue),
+				newRoute("my-project", "other-route", instanceName, corev1.ConditionFalse),
+			},
+		},
+		{
+			name: "admitted routes",
+			routes: []client.Object{
+				newRoute("my-project", "my-route", instanceName, corev1.ConditionTrue),
+			},
+			expectError: true,
+		},
+		{
+			name:        "admitted routes with override annotation",
+			annotations: map[string]string{allowDeletionAnnotation: "true"},
+			routes: []client.Object{
+				newRoute("my-project", "my-route", instanceName, corev1.ConditionTrue),
+			},
+		},
+		{
+			name:   "admitted routes with retain deletion policy",
+			policy: customdomainv1alpha1.DeletionPolicyRetain,
+			routes: []client.Object{
+				newRoute("my-project", "my-route", instanceName, corev1.ConditionTrue),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customdomain := &customdomainv1alpha1.CustomDomain{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        instanceName,
+					Annotations: tt.annotations,
+				},
+				Spec: customdomainv1alpha1.CustomDomainSpec{
+					Domain:         "apps.foo.com",
+					DeletionPolicy: tt.policy,
+				},
+			}
+			v := &CustomDomainValidator{Reader: NewTestMock(t, tt.routes...)}
+			_, err := v.ValidateDelete(context.TODO(), customdomain)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("deletion was not denied")
+				}
+				if !strings.Contains(err.Error(), "my-project/my-route") {
+					t.Errorf("denial does not list the admitted route: (%v)", err)
+				}
+			} else if err != nil {
+				t.Errorf("deletion was denied: (%v)", err)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 	"time"
@@ -29,13 +30,16 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
 	operatoringressv1 "github.com/openshift/api/operatoringress/v1"
+	routev1 "github.com/openshift/api/route/v1"
 	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	"github.com/openshift/custom-domains-operator/config"
 	customdomaincontrollers "github.com/openshift/custom-domains-operator/controller"
 	//+kubebuilder:scaffold:imports
 )
@@ -55,6 +59,8 @@ func init() {
 	utilruntime.Must(operatorv1.AddToScheme(scheme))
 
 	utilruntime.Must(operatoringressv1.AddToScheme(scheme))
+
+	utilruntime.Must(routev1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -62,21 +68,75 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var enableWebhooks bool
+	var orphanSweeperMode string
+	var orphanSweeperInterval time.Duration
+	var verifyDelegation bool
+	var dnsResolverAddress string
+	var ownershipVerification string
+	var fileDNSProviderDir string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Enable the admission webhooks. "+
+			"Requires a serving certificate in /tmp/k8s-webhook-server/serving-certs.")
+	flag.StringVar(&orphanSweeperMode, "orphan-sweeper-mode", string(customdomaincontrollers.OrphanSweeperModeReport),
+		"What to do with resources labeled as managed by the operator that do not belong to a CustomDomain. "+
+			"One of report or delete.")
+	flag.DurationVar(&orphanSweeperInterval, "orphan-sweeper-interval", time.Hour,
+		"How often to look for orphaned resources.")
+	flag.BoolVar(&verifyDelegation, "verify-domain-delegation", false,
+		"Verify that custom domains resolve to their endpoint and report it in the DomainDelegated condition.")
+	flag.StringVar(&dnsResolverAddress, "dns-resolver", "",
+		"The host:port of the DNS server used to verify custom domains. Defaults to the system resolver.")
+	flag.StringVar(&ownershipVerification, "domain-ownership-verification", string(customdomaincontrollers.OwnershipVerificationDisabled),
+		"Whether custom domains must prove their ownership with a TXT record. "+
+			"One of disabled, warn or block.")
+	flag.StringVar(&fileDNSProviderDir, "file-dns-provider-dir", "",
+		"The directory the File DNS provider writes its records to, for development and testing. "+
+			"The File DNS provider is disabled if empty.")
 	opts := zap.Options{
 		Development: true,
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	sweeperMode := customdomaincontrollers.OrphanSweeperMode(orphanSweeperMode)
+	if sweeperMode != customdomaincontrollers.OrphanSweeperModeReport && sweeperMode != customdomaincontrollers.OrphanSweeperModeDelete {
+		setupLog.Error(nil, "invalid orphan sweeper mode", "mode", orphanSweeperMode)
+		os.Exit(1)
+	}
+	ownershipMode := customdomaincontrollers.OwnershipVerificationMode(ownershipVerification)
+	switch ownershipMode {
+	case customdomaincontrollers.OwnershipVerificationDisabled, customdomaincontrollers.OwnershipVerificationWarn, customdomaincontrollers.OwnershipVerificationBlock:
+	default:
+		setupLog.Error(nil, "invalid domain ownership verification mode", "mode", ownershipVerification)
+		os.Exit(1)
+	}
+
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
-	syncPeriod := 15 * time.Minute
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	// The flags are the defaults of the settings that are not set in the CustomDomainsOperatorConfig
+	defaults := customdomaincontrollers.DefaultOperatorConfig()
+	defaults.VerifyDelegation = verifyDelegation
+	defaults.OwnershipVerification = ownershipMode
+	configStore := customdomaincontrollers.NewOperatorConfigStore(defaults)
+
+	restConfig := ctrl.GetConfigOrDie()
+	configReader, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+		os.Exit(1)
+	}
+	if err = configStore.LoadOperatorConfig(context.Background(), configReader); err != nil {
+		setupLog.Error(err, "unable to load the operator configuration, using the defaults")
+	}
+	syncPeriod := configStore.SyncPeriod()
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
@@ -91,14 +151,67 @@ func main() {
 		os.Exit(1)
 	}
 
+	configChanges := &customdomaincontrollers.ConfigChangeSource{}
 	if err = (&customdomaincontrollers.CustomDomainReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		APIReader:          mgr.GetAPIReader(),
+		Resolver:           customdomaincontrollers.NewDNSResolver(dnsResolverAddress),
+		Config:             configStore,
+		ConfigChanges:      configChanges,
+		DNSProviderFactory: customdomaincontrollers.NewDNSProviderFactory(fileDNSProviderDir),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CustomDomain")
 		os.Exit(1)
 	}
 
+	if err = (&customdomaincontrollers.OperatorConfigReconciler{
+		Client:  mgr.GetClient(),
+		Store:   configStore,
+		Changes: configChanges,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CustomDomainsOperatorConfig")
+		os.Exit(1)
+	}
+
+	if err = (&customdomaincontrollers.CustomDomainClaimReconciler{
+		Client: mgr.GetClient(),
+		Config: configStore,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CustomDomainClaim")
+		os.Exit(1)
+	}
+
+	if err = (&customdomaincontrollers.RouteMigrationReconciler{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Config:    configStore,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RouteMigration")
+		os.Exit(1)
+	}
+
+	if enableWebhooks {
+		if err = (&customdomaincontrollers.CustomDomainValidator{
+			Reader: mgr.GetAPIReader(),
+			Config: configStore,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "CustomDomain")
+			os.Exit(1)
+		}
+	}
+
+	if err = mgr.Add(&customdomaincontrollers.OrphanSweeper{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor(config.OperatorName),
+		Interval: orphanSweeperInterval,
+		Mode:     sweeperMode,
+		Config:   configStore,
+	}); err != nil {
+		setupLog.Error(err, "unable to add orphan sweeper")
+		os.Exit(1)
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {